@@ -0,0 +1,75 @@
+package decision
+
+import "fmt"
+
+// accountList 返回参与决策的账户列表
+// 未配置 Accounts 时视为单账户（仅 ctx.Account）
+func (ctx *Context) accountList() []AccountInfo {
+	if len(ctx.Accounts) > 0 {
+		return ctx.Accounts
+	}
+	return []AccountInfo{ctx.Account}
+}
+
+// isMultiAccount 是否为多子账户模式
+func (ctx *Context) isMultiAccount() bool {
+	return len(ctx.Accounts) > 1
+}
+
+// accountFor 查找决策对应的账户
+// 单账户模式忽略 id；多账户模式下 id 必须匹配某个子账户
+func (ctx *Context) accountFor(id string) (AccountInfo, error) {
+	accounts := ctx.accountList()
+	if !ctx.isMultiAccount() {
+		return accounts[0], nil
+	}
+
+	if id == "" {
+		return AccountInfo{}, fmt.Errorf("多账户模式下必须指定account字段")
+	}
+	for _, acct := range accounts {
+		if acct.ID == id {
+			return acct, nil
+		}
+	}
+	return AccountInfo{}, fmt.Errorf("未知的account: %s", id)
+}
+
+// positionsFor 返回属于指定账户的持仓（单账户模式返回全部持仓）
+func (ctx *Context) positionsFor(id string) []PositionInfo {
+	if !ctx.isMultiAccount() {
+		return ctx.Positions
+	}
+
+	var positions []PositionInfo
+	for _, pos := range ctx.Positions {
+		if pos.Account == id {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// decisionsFor 返回指定账户的决策（单账户模式返回全部决策）
+func (ctx *Context) decisionsFor(id string, decisions []Decision) []Decision {
+	if !ctx.isMultiAccount() {
+		return decisions
+	}
+
+	var result []Decision
+	for _, d := range decisions {
+		if d.Account == id {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// positionNotional 计算持仓的名义价值合计（数量 × 标记价格）
+func positionNotional(positions []PositionInfo) float64 {
+	total := 0.0
+	for _, pos := range positions {
+		total += pos.Quantity * pos.MarkPrice
+	}
+	return total
+}
@@ -0,0 +1,61 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+// multiAccountContext 两个子账户：main 净值 10000，sub 净值 1000
+func multiAccountContext() *Context {
+	ctx := testContext()
+	ctx.Accounts = []AccountInfo{
+		{ID: "main", TotalEquity: 10000, AvailableBalance: 10000},
+		{ID: "sub", TotalEquity: 1000, AvailableBalance: 1000},
+	}
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	return ctx
+}
+
+func TestValidateDecisionsAppliesPerAccountCaps(t *testing.T) {
+	ctx := multiAccountContext()
+
+	// 山寨币单币种上限为子账户净值的1.5倍：main 15000，sub 1500
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 5000
+	d.RiskUSD = 250
+
+	d.Account = "main"
+	if err := validateDecisions([]Decision{d}, ctx); err != nil {
+		t.Fatalf("main 账户内的仓位应通过: %v", err)
+	}
+
+	d.Account = "sub"
+	if err := validateDecisions([]Decision{d}, ctx); ruleCodeOf(err) != RulePositionValue {
+		t.Fatalf("超过 sub 账户上限的仓位应被拒绝，得到 %v", err)
+	}
+}
+
+func TestValidateDecisionsRequiresKnownAccount(t *testing.T) {
+	ctx := multiAccountContext()
+
+	d := longDecision("SOLUSDT")
+	if err := validateDecisions([]Decision{d}, ctx); err == nil || !strings.Contains(err.Error(), "account") {
+		t.Fatalf("多账户模式下缺少 account 应被拒绝，得到 %v", err)
+	}
+
+	d.Account = "other"
+	if err := validateDecisions([]Decision{d}, ctx); err == nil || !strings.Contains(err.Error(), "未知的account") {
+		t.Fatalf("未知的 account 应被拒绝，得到 %v", err)
+	}
+}
+
+func TestSingleAccountIsDefault(t *testing.T) {
+	ctx := testContext()
+	accounts := ctx.accountList()
+	if len(accounts) != 1 || accounts[0].TotalEquity != ctx.Account.TotalEquity {
+		t.Fatalf("未配置 Accounts 时应只有 ctx.Account，得到 %+v", accounts)
+	}
+	if acct, err := ctx.accountFor("anything"); err != nil || acct.TotalEquity != 10000 {
+		t.Fatalf("单账户模式应忽略 account 字段，得到 %+v / %v", acct, err)
+	}
+}
@@ -0,0 +1,106 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Action 决策动作类型
+type Action string
+
+// 所有合法的决策动作
+const (
+	ActionOpenLong    Action = "open_long"
+	ActionOpenShort   Action = "open_short"
+	ActionCloseLong   Action = "close_long"
+	ActionCloseShort  Action = "close_short"
+	ActionScaleIn     Action = "scale_in"   // 按现有持仓方向加仓
+	ActionReduceAll   Action = "reduce_all" // 所有持仓按同一比例减仓（整体降风险）
+	ActionHold        Action = "hold"
+	ActionWait        Action = "wait"
+	ActionNote        Action = "note"         // 仅记录观点（研究用），执行器忽略
+	ActionRequestData Action = "request_data" // 请求下个周期提供更多数据（先分析后决策），执行器忽略
+)
+
+// validActions 合法动作集合
+var validActions = map[Action]bool{
+	ActionOpenLong:    true,
+	ActionOpenShort:   true,
+	ActionCloseLong:   true,
+	ActionCloseShort:  true,
+	ActionScaleIn:     true,
+	ActionReduceAll:   true,
+	ActionHold:        true,
+	ActionWait:        true,
+	ActionNote:        true,
+	ActionRequestData: true,
+}
+
+// ParseAction 将字符串解析为Action（忽略大小写和首尾空白）
+func ParseAction(s string) (Action, error) {
+	action := normalizeAction(s)
+	if !action.IsValid() {
+		return "", fmt.Errorf("无效的action: %s", s)
+	}
+	return action, nil
+}
+
+// IsValid 是否为合法动作
+func (a Action) IsValid() bool {
+	return validActions[a]
+}
+
+// IsOpen 是否为开仓动作
+func (a Action) IsOpen() bool {
+	return a == ActionOpenLong || a == ActionOpenShort
+}
+
+// IsClose 是否为平仓动作
+func (a Action) IsClose() bool {
+	return a == ActionCloseLong || a == ActionCloseShort
+}
+
+// String 实现 fmt.Stringer
+func (a Action) String() string {
+	return string(a)
+}
+
+// UnmarshalJSON 解析JSON字符串并标准化大小写
+// 无效值原样保留，由 validateDecision 给出明确的错误信息，而不是让整个JSON解析失败
+func (a *Action) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("action必须是字符串: %s", string(data))
+	}
+	*a = normalizeAction(s)
+	return nil
+}
+
+// localizedActions 模型偶尔会把 action 本地化（如"做多"），映射回标准动作
+var localizedActions = map[string]Action{
+	"做多":  ActionOpenLong,
+	"开多":  ActionOpenLong,
+	"开多仓": ActionOpenLong,
+	"做空":  ActionOpenShort,
+	"开空":  ActionOpenShort,
+	"开空仓": ActionOpenShort,
+	"平多":  ActionCloseLong,
+	"平多仓": ActionCloseLong,
+	"平空":  ActionCloseShort,
+	"平空仓": ActionCloseShort,
+	"加仓":  ActionScaleIn,
+	"持有":  ActionHold,
+	"持仓":  ActionHold,
+	"观望":  ActionWait,
+	"等待":  ActionWait,
+}
+
+// normalizeAction 标准化动作字符串（本地化的动作词转换为标准动作）
+func normalizeAction(s string) Action {
+	trimmed := strings.TrimSpace(s)
+	if action, ok := localizedActions[trimmed]; ok {
+		return action
+	}
+	return Action(strings.ToLower(trimmed))
+}
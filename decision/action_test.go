@@ -0,0 +1,75 @@
+package decision
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseActionValid(t *testing.T) {
+	cases := map[string]Action{
+		"open_long":    ActionOpenLong,
+		"OPEN_SHORT":   ActionOpenShort,
+		" close_long ": ActionCloseLong,
+		"Close_Short":  ActionCloseShort,
+		"hold":         ActionHold,
+		"wait":         ActionWait,
+	}
+	for input, want := range cases {
+		got, err := ParseAction(input)
+		if err != nil {
+			t.Errorf("ParseAction(%q) 返回错误: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseAction(%q) = %s，期望 %s", input, got, want)
+		}
+	}
+}
+
+func TestParseActionInvalid(t *testing.T) {
+	for _, input := range []string{"", "buy", "open-long", "long"} {
+		if action, err := ParseAction(input); err == nil {
+			t.Errorf("ParseAction(%q) 应返回错误，得到 %s", input, action)
+		}
+	}
+}
+
+func TestActionUnmarshalJSON(t *testing.T) {
+	var d struct {
+		Action Action `json:"action"`
+	}
+	if err := json.Unmarshal([]byte(`{"action":"OPEN_LONG"}`), &d); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if d.Action != ActionOpenLong {
+		t.Fatalf("action = %s，期望 %s", d.Action, ActionOpenLong)
+	}
+
+	// 无效值原样保留，交给 validateDecision 报错
+	if err := json.Unmarshal([]byte(`{"action":"buy"}`), &d); err != nil {
+		t.Fatalf("无效动作不应导致JSON解析失败: %v", err)
+	}
+	if d.Action.IsValid() {
+		t.Fatalf("action %q 不应是合法动作", d.Action)
+	}
+
+	if err := json.Unmarshal([]byte(`{"action":1}`), &d); err == nil {
+		t.Fatal("非字符串的 action 应解析失败")
+	}
+}
+
+func TestExtractDecisionsNormalizesLocalizedActions(t *testing.T) {
+	response := `BTC 4h 下跌，ETH 跟随。
+[{"symbol":"ETHUSDT","action":"做空","reasoning":"跌破支撑"},{"symbol":"BTCUSDT","action":" 观望 ","reasoning":"等待"}]`
+
+	decisions, err := extractDecisions(response, true)
+	if err != nil {
+		t.Fatalf("extractDecisions: %v", err)
+	}
+	if decisions[0].Action != ActionOpenShort {
+		t.Errorf("\"做空\" 应标准化为 open_short，得到 %q", decisions[0].Action)
+	}
+	if decisions[1].Action != ActionWait {
+		t.Errorf("\"观望\" 应标准化为 wait，得到 %q", decisions[1].Action)
+	}
+}
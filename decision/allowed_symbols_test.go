@@ -0,0 +1,57 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOnlyWhitelistedCandidatesSurvive(t *testing.T) {
+	stubOITop(t, nil, nil)
+
+	provider := newCountingProvider(map[string]float64{"BTCUSDT": 100, "SOLUSDT": 20, "DOGEUSDT": 0.1, "XRPUSDT": 0.5})
+	ctx := testContext()
+	ctx.MarketDataProvider = provider
+	ctx.AllowedSymbols = []string{"BTCUSDT", "SOLUSDT"}
+	ctx.CandidateCoins = []CandidateCoin{
+		{Symbol: "SOLUSDT", Sources: []string{"ai500"}},
+		{Symbol: "DOGEUSDT", Sources: []string{"ai500"}},
+	}
+	// 白名单外的现有持仓仍需管理
+	ctx.Positions = []PositionInfo{{Symbol: "XRPUSDT", Side: "long", EntryPrice: 0.5, MarkPrice: 0.5, Quantity: 100, Leverage: 3}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if len(ctx.CandidateCoins) != 1 || ctx.CandidateCoins[0].Symbol != "SOLUSDT" {
+		t.Fatalf("只应保留白名单内的候选币种: %+v", ctx.CandidateCoins)
+	}
+	if provider.callCount("DOGEUSDT") != 0 {
+		t.Fatal("白名单外的候选币种不应获取数据")
+	}
+	if _, ok := ctx.MarketDataMap["XRPUSDT"]; !ok {
+		t.Fatal("白名单外的现有持仓仍应获取数据")
+	}
+}
+
+func TestValidateDecisionEnforcesWhitelist(t *testing.T) {
+	ctx := testContext()
+	ctx.AllowedSymbols = []string{"SOLUSDT"}
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.MarketDataMap["DOGEUSDT"] = testMarketData("DOGEUSDT", 100)
+	ctx.Positions = []PositionInfo{{Symbol: "DOGEUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 3}}
+
+	d := longDecision("DOGEUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil || !strings.Contains(err.Error(), "白名单") {
+		t.Fatalf("白名单外的币种禁止开仓，得到 %v", err)
+	}
+
+	d = Decision{Symbol: "DOGEUSDT", Action: ActionCloseLong, Reasoning: "退出"}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("白名单外的现有持仓仍可平仓: %v", err)
+	}
+
+	d = longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("白名单内的币种可以开仓: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package decision
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPortfolioBTCBetaForBTCHeavyBook(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	// BTC 多单名义价值 20000 = 2倍净值
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 200, Leverage: 5}}
+
+	beta, ok := calculatePortfolioBTCBeta(ctx)
+	if !ok {
+		t.Fatal("有BTC数据和持仓时应能计算beta")
+	}
+	if math.Abs(beta-2) > 1e-9 {
+		t.Fatalf("beta = %.4f，期望 2", beta)
+	}
+	if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, "组合BTC Beta**: 2.00") {
+		t.Fatal("user prompt 应显示组合BTC beta")
+	}
+
+	// 同等名义价值的BTC空单抵消敞口
+	ctx.Positions = append(ctx.Positions, PositionInfo{Symbol: "BTCUSDT", Side: "short", EntryPrice: 100, MarkPrice: 100, Quantity: 200, Leverage: 5})
+	if beta, _ := calculatePortfolioBTCBeta(ctx); math.Abs(beta) > 1e-9 {
+		t.Fatalf("多空对冲后 beta = %.4f，期望 0", beta)
+	}
+}
+
+func TestPortfolioBTCBetaWithoutBTCData(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 10, Leverage: 3}}
+	if _, ok := calculatePortfolioBTCBeta(ctx); ok {
+		t.Fatal("缺少BTC数据时不应计算beta")
+	}
+}
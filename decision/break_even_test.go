@@ -0,0 +1,35 @@
+package decision
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBreakEvenPriceIncludesFees(t *testing.T) {
+	long := PositionInfo{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100}
+	if be := long.BreakEvenPrice(0.1); be <= long.EntryPrice || math.Abs(be-100.1) > 1e-9 {
+		t.Fatalf("多仓保本价应高于入场价（100.1），得到 %.4f", be)
+	}
+	short := PositionInfo{Symbol: "BTCUSDT", Side: "short", EntryPrice: 100}
+	if be := short.BreakEvenPrice(0.1); be >= short.EntryPrice || math.Abs(be-99.9) > 1e-9 {
+		t.Fatalf("空仓保本价应低于入场价（99.9），得到 %.4f", be)
+	}
+	if be := long.BreakEvenPrice(0); be != 100 {
+		t.Fatalf("无手续费时保本价等于入场价，得到 %.4f", be)
+	}
+}
+
+func TestBreakEvenRenderedInPositionBlock(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 101, Quantity: 1, Leverage: 5}}
+
+	if strings.Contains(buildUserPrompt(ctx), "保本价") {
+		t.Fatal("未配置手续费时不显示保本价")
+	}
+	ctx.RoundTripFeePct = 0.1
+	if user := buildUserPrompt(ctx); !strings.Contains(user, "保本价(含手续费0.100%)**: 100.1000") {
+		t.Fatal("持仓信息应显示含手续费的保本价")
+	}
+}
@@ -0,0 +1,71 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+// bearishBTC BTC 4小时下跌（涨跌幅为负且 4h EMA20 < EMA50）
+func bearishBTC() *market.Data {
+	data := testMarketData("BTCUSDT", 100)
+	data.PriceChange4h = -2.5
+	data.LongerTermContext.EMA20 = 98
+	data.LongerTermContext.EMA50 = 101
+	return data
+}
+
+func TestAltLongBlockedUnderBearishBTC(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.MarketDataMap["BTCUSDT"] = bearishBTC()
+
+	d := longDecision("SOLUSDT")
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "禁止做多山寨币") {
+		t.Fatalf("BTC下跌时做多山寨币应被拒绝，得到 %v", err)
+	}
+
+	// 信心度极高时例外
+	d = longDecision("SOLUSDT")
+	d.Confidence = 96
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("信心度达到阈值时应允许: %v", err)
+	}
+}
+
+func TestAltLongAllowedUnderNeutralBTC(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+
+	d := longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("BTC未下跌时应允许做多山寨币: %v", err)
+	}
+}
+
+func TestVerboseValidationIncludesIndicatorValues(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.MarketDataMap["BTCUSDT"] = bearishBTC()
+
+	d := longDecision("SOLUSDT")
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || strings.Contains(err.Error(), "[指标:") {
+		t.Fatalf("默认不应附带指标数值，得到 %v", err)
+	}
+
+	ctx.VerboseValidation = true
+	d = longDecision("SOLUSDT")
+	err = validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil {
+		t.Fatal("BTC下跌时做多山寨币应被拒绝")
+	}
+	for _, want := range []string{"BTCUSDT price=100.0000", "ema20=99.0000", "4h=-2.50%", "ema20_4h=98.0000", "ema50_4h=101.0000"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误信息缺少 %q: %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package decision
+
+import (
+	"testing"
+
+	"nofx/mcp"
+)
+
+func TestOnDecisionCallbackFires(t *testing.T) {
+	stubOITop(t, nil, nil)
+	ctx := testContext()
+
+	var received *FullDecision
+	ctx.OnDecision = func(fd *FullDecision) { received = fd }
+	ctx.OnError = func(err error) { t.Errorf("成功时不应调用 OnError: %v", err) }
+
+	decision, err := GetFullDecision(ctx, mcp.New())
+	if err != nil {
+		t.Fatalf("GetFullDecision: %v", err)
+	}
+	if received == nil || received != decision {
+		t.Fatal("OnDecision 应收到返回的决策")
+	}
+}
+
+func TestOnErrorCallbackFires(t *testing.T) {
+	stubOITop(t, nil, nil)
+	ctx := testContext()
+	ctx.CallAIWhenEmpty = true // 强制调用未设置密钥的客户端，产生错误
+
+	var received error
+	ctx.OnError = func(err error) { received = err }
+	ctx.OnDecision = func(*FullDecision) { t.Error("失败时不应调用 OnDecision") }
+
+	_, err := GetFullDecision(ctx, mcp.New())
+	if err == nil || received != err {
+		t.Fatalf("OnError 应收到返回的错误: err=%v received=%v", err, received)
+	}
+}
@@ -0,0 +1,57 @@
+package decision
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestClampRetainsOriginalValues(t *testing.T) {
+	ctx := testContext()
+	ctx.BTCETHLeverage = 20
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.RiskConfig.SymbolLeverageTiers = map[string][]int{"BTCUSDT": {3, 5, 10, 20}}
+	ctx.RiskConfig.ScaleSizeByMarginHeadroom = true
+	ctx.Account.MarginUsed = 7000
+	ctx.Account.MarginUsedPct = 70
+
+	d := longDecision("BTCUSDT")
+	d.Leverage = 17
+	d.PositionSizeUSD = 30000
+	d.RiskUSD = 1500
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if d.Leverage != 10 || d.OriginalLeverage != 17 {
+		t.Errorf("杠杆 %dx（原始 %dx），期望 10x（原始 17x）", d.Leverage, d.OriginalLeverage)
+	}
+	if d.PositionSizeUSD >= 30000 || d.OriginalSizeUSD != 30000 {
+		t.Errorf("仓位 %.2f（原始 %.2f），期望缩小且保留原始 30000", d.PositionSizeUSD, d.OriginalSizeUSD)
+	}
+
+	// 多次调整时保留模型最初的值
+	d.clampSize(100)
+	if d.OriginalSizeUSD != 30000 {
+		t.Errorf("再次调整后原始仓位应仍为 30000，得到 %.2f", d.OriginalSizeUSD)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"original_leverage":17`) || !strings.Contains(string(data), `"original_size_usd":30000`) {
+		t.Errorf("原始值应出现在JSON中: %s", data)
+	}
+}
+
+func TestUnclampedDecisionHasNoOriginalValues(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	d := longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if d.OriginalLeverage != 0 || d.OriginalSizeUSD != 0 {
+		t.Fatalf("未调整的决策不应记录原始值: %+v", d)
+	}
+}
@@ -0,0 +1,42 @@
+package decision
+
+import (
+	"fmt"
+
+	"nofx/market"
+)
+
+// 候选币种市场数据不完整时的处理方式（Context.IncompleteDataPolicy）
+const (
+	IncompleteDataIgnore = ""     // 不检查（默认）
+	IncompleteDataSkip   = "skip" // 跳过缺少必需指标的候选币种
+	IncompleteDataFlag   = "flag" // 保留，但在prompt中标注缺失的指标
+)
+
+// validateIncompleteDataPolicy 检查数据完整性策略配置
+func validateIncompleteDataPolicy(policy string) error {
+	switch policy {
+	case IncompleteDataIgnore, IncompleteDataSkip, IncompleteDataFlag:
+		return nil
+	}
+	return fmt.Errorf("未知的数据完整性策略: %q（可用: %q, %q）", policy, IncompleteDataSkip, IncompleteDataFlag)
+}
+
+// missingIndicators 返回市场数据中缺失的必需指标（交易清淡的币种常缺少日内序列或4h数据）
+func missingIndicators(data *market.Data) []string {
+	var missing []string
+	intraday := data.IntradaySeries
+	if intraday == nil || len(intraday.MidPrices) == 0 {
+		missing = append(missing, "3m价格序列")
+	}
+	if intraday == nil || len(intraday.MACDValues) == 0 {
+		missing = append(missing, "MACD")
+	}
+	if intraday == nil || len(intraday.RSI7Values) == 0 {
+		missing = append(missing, "RSI")
+	}
+	if data.LongerTermContext == nil || data.LongerTermContext.EMA20 <= 0 {
+		missing = append(missing, "4h趋势数据")
+	}
+	return missing
+}
@@ -0,0 +1,64 @@
+package decision
+
+import (
+	"testing"
+
+	"nofx/market"
+)
+
+// thinDataContext THINUSDT 为缺少日内序列和4h数据的候选币种，DUSTUSDT 为同样缺数据的现有持仓
+func thinDataContext() *Context {
+	ctx := testContext()
+	ctx.MarketDataProvider = MarketDataProviderFunc(func(symbol string) (*market.Data, error) {
+		data := testMarketData(symbol, 100)
+		if symbol == "THINUSDT" || symbol == "DUSTUSDT" {
+			data.IntradaySeries = nil
+			data.LongerTermContext = nil
+		}
+		return data, nil
+	})
+	ctx.Positions = []PositionInfo{{Symbol: "DUSTUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 3}}
+	ctx.CandidateCoins = []CandidateCoin{
+		{Symbol: "SOLUSDT", Sources: []string{"ai500"}},
+		{Symbol: "THINUSDT", Sources: []string{"ai500"}},
+	}
+	return ctx
+}
+
+func TestIncompleteCandidateSkipped(t *testing.T) {
+	stubOITop(t, nil, nil)
+	ctx := thinDataContext()
+	ctx.IncompleteDataPolicy = IncompleteDataSkip
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["THINUSDT"]; ok {
+		t.Fatal("缺少指标的候选币种应被跳过")
+	}
+	if _, ok := ctx.MarketDataMap["SOLUSDT"]; !ok {
+		t.Fatal("数据完整的候选币种应保留")
+	}
+	if _, ok := ctx.MarketDataMap["DUSTUSDT"]; !ok {
+		t.Fatal("现有持仓不受完整性检查影响")
+	}
+}
+
+func TestIncompleteCandidateFlagged(t *testing.T) {
+	stubOITop(t, nil, nil)
+	ctx := thinDataContext()
+	ctx.IncompleteDataPolicy = IncompleteDataFlag
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["THINUSDT"]; !ok {
+		t.Fatal("flag 模式应保留缺少指标的候选币种")
+	}
+	if missing := ctx.IncompleteData["THINUSDT"]; len(missing) != 4 {
+		t.Fatalf("应记录4项缺失的指标，得到 %v", missing)
+	}
+	if _, ok := ctx.IncompleteData["DUSTUSDT"]; ok {
+		t.Fatal("现有持仓不应被标注")
+	}
+}
@@ -0,0 +1,106 @@
+package decision
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// confidenceWords 部分模型只愿意用文字表达信心度，映射为代表性的数值
+var confidenceWords = map[string]int{
+	"low":       60,
+	"medium":    75,
+	"high":      85,
+	"very high": 92,
+}
+
+// decisionFields Decision 的全部JSON字段名（小写，与 encoding/json 一样不区分大小写匹配）
+var decisionFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(Decision{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+	return fields
+}()
+
+// errUnknownDecisionField 决策JSON中出现 Decision 没有的字段（仅 AI 响应解析时检查）
+var errUnknownDecisionField = errors.New("决策JSON包含未知字段")
+
+// UnmarshalJSON 解析决策，confidence 兼容数字、数字字符串以及 low/medium/high/very high 等文字
+// 未知字段直接忽略（重新读取旧日志或带新字段的数据时不会失败）
+func (d *Decision) UnmarshalJSON(data []byte) error {
+	return decodeDecision(data, d, false)
+}
+
+// strictDecision 出现未知字段时报错的 Decision（只在解析AI响应时使用，见 parseDecisionArray）
+type strictDecision Decision
+
+// UnmarshalJSON 解析决策，出现未知字段时返回 errUnknownDecisionField
+func (s *strictDecision) UnmarshalJSON(data []byte) error {
+	return decodeDecision(data, (*Decision)(s), true)
+}
+
+// decodeDecision 解析单个决策；rejectUnknown 为 true 时出现未知字段返回 errUnknownDecisionField
+func decodeDecision(data []byte, d *Decision, rejectUnknown bool) error {
+	if rejectUnknown {
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err == nil {
+			for key := range raw {
+				if !decisionFields[strings.ToLower(key)] {
+					return fmt.Errorf("%w: %q", errUnknownDecisionField, key)
+				}
+			}
+		}
+	}
+
+	type decisionAlias Decision
+	aux := struct {
+		*decisionAlias
+		Confidence json.RawMessage `json:"confidence,omitempty"`
+	}{decisionAlias: (*decisionAlias)(d)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	confidence, err := parseConfidence(aux.Confidence)
+	if err != nil {
+		return err
+	}
+	d.Confidence = confidence
+	return nil
+}
+
+// parseConfidence 解析 confidence 原始JSON值（缺省或null时为0）
+func parseConfidence(raw json.RawMessage) (int, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var num float64
+	if err := json.Unmarshal(raw, &num); err == nil {
+		return int(math.Round(num)), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0, fmt.Errorf("confidence 必须是数字或字符串: %s", string(raw))
+	}
+	word := strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(s, "_", " "))), " ")
+	if value, ok := confidenceWords[word]; ok {
+		return value, nil
+	}
+	if num, err := strconv.ParseFloat(word, 64); err == nil {
+		return int(math.Round(num)), nil
+	}
+	return 0, fmt.Errorf("无法识别的confidence: %q", s)
+}
@@ -0,0 +1,47 @@
+package decision
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConfidenceWordsParseToNumbers(t *testing.T) {
+	cases := map[string]int{
+		`"low"`:       60,
+		`"medium"`:    75,
+		`"high"`:      85,
+		`"very high"`: 92,
+		`"Very_High"`: 92,
+		`" HIGH "`:    85,
+		`"70"`:        70,
+		`72.6`:        73,
+	}
+	for raw, want := range cases {
+		var d Decision
+		if err := json.Unmarshal([]byte(`{"symbol":"BTCUSDT","action":"wait","confidence":`+raw+`}`), &d); err != nil {
+			t.Errorf("confidence %s: %v", raw, err)
+			continue
+		}
+		if d.Confidence != want {
+			t.Errorf("confidence %s = %d, want %d", raw, d.Confidence, want)
+		}
+	}
+}
+
+func TestConfidenceUnknownWordRejected(t *testing.T) {
+	var d Decision
+	if err := json.Unmarshal([]byte(`{"symbol":"BTCUSDT","action":"wait","confidence":"super"}`), &d); err == nil {
+		t.Fatal("无法识别的信心度文字应返回错误")
+	}
+}
+
+func TestDecisionUnmarshalIgnoresUnknownFields(t *testing.T) {
+	var full FullDecision
+	payload := `{"decisions":[{"symbol":"BTCUSDT","action":"wait","confidence":"high","added_later":1}]}`
+	if err := json.Unmarshal([]byte(payload), &full); err != nil {
+		t.Fatalf("重新读取带新字段的决策记录不应失败: %v", err)
+	}
+	if len(full.Decisions) != 1 || full.Decisions[0].Confidence != 85 {
+		t.Fatalf("解析结果错误: %+v", full.Decisions)
+	}
+}
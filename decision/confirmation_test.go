@@ -0,0 +1,44 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOpenWithOneConfirmationRejected(t *testing.T) {
+	ctx := testContext()
+	data := testMarketData("SOLUSDT", 100) // 价格 > EMA20：只有 EMA20 确认做多
+	data.CurrentMACD = -0.1
+	data.IntradaySeries.RSI7Values = []float64{60, 58, 55, 52}
+	ctx.MarketDataMap["SOLUSDT"] = data
+
+	if got := confirmingIndicators(ActionOpenLong, data); len(got) != 1 || got[0] != "EMA20" {
+		t.Fatalf("confirmingIndicators = %v，期望只有 EMA20", got)
+	}
+	d := longDecision("SOLUSDT")
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "仅1个指标确认方向") {
+		t.Fatalf("只有1个指标确认时应被拒绝，得到 %v", err)
+	}
+}
+
+func TestOpenWithThreeConfirmationsAccepted(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MinConfirmingIndicators = 3
+	data := testMarketData("SOLUSDT", 100)
+	data.IntradaySeries.RSI7Values = []float64{45, 48, 52, 55}
+	ctx.MarketDataMap["SOLUSDT"] = data
+
+	if got := confirmingIndicators(ActionOpenLong, data); len(got) != 3 {
+		t.Fatalf("confirmingIndicators = %v，期望 EMA20/MACD/RSI 全部确认", got)
+	}
+	d := longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("3个指标全部确认时应通过: %v", err)
+	}
+
+	// 同样的数据做空：没有指标确认
+	if got := confirmingIndicators(ActionOpenShort, data); len(got) != 0 {
+		t.Fatalf("做空时不应有指标确认，得到 %v", got)
+	}
+}
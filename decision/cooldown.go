@@ -0,0 +1,52 @@
+package decision
+
+import (
+	"log"
+	"time"
+)
+
+// bigWinCooldown 判断是否处于"大赢后冷却"期
+// 最近一笔平仓的盈亏百分比超过 BigWinPnLPct，且平仓时间在冷却时长内时返回 true（平仓时间未知时视为仍在冷却）
+func (ctx *Context) bigWinCooldown() (active bool, lastPnLPct float64) {
+	threshold := ctx.RiskConfig.BigWinPnLPct
+	if threshold <= 0 || ctx.Performance == nil {
+		return false, 0
+	}
+	perfData, _, err := parsePerformance(ctx.Performance)
+	if err != nil {
+		log.Printf("⚠️  历史表现数据无法解析，跳过大赢冷却检查: %v", err)
+		return false, 0
+	}
+	if len(perfData.RecentTrades) == 0 {
+		return false, 0
+	}
+
+	last := perfData.RecentTrades[len(perfData.RecentTrades)-1]
+	if last.PnLPct < threshold {
+		return false, last.PnLPct
+	}
+	if !last.CloseTime.IsZero() && time.Since(last.CloseTime) > ctx.bigWinCooldownDuration() {
+		return false, last.PnLPct
+	}
+	return true, last.PnLPct
+}
+
+// bigWinCooldownDuration 返回冷却时长（未配置时为一个决策周期）
+func (ctx *Context) bigWinCooldownDuration() time.Duration {
+	minutes := ctx.RiskConfig.BigWinCooldownMinutes
+	if minutes <= 0 {
+		minutes = ctx.ScanIntervalMinutes
+	}
+	if minutes <= 0 {
+		minutes = 3
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// bigWinSizeFactor 返回冷却期间的仓位上限系数（未配置时默认0.5）
+func (c RiskConfig) bigWinSizeFactor() float64 {
+	if c.BigWinSizeFactor <= 0 {
+		return 0.5
+	}
+	return c.BigWinSizeFactor
+}
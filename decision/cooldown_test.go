@@ -0,0 +1,45 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBigWinReducesNextTradeSize(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.BigWinPnLPct = 10
+	ctx.RiskConfig.BigWinCooldownMinutes = 30
+	ctx.Performance = newestFirstPerformance(time.Now(), 15)
+
+	// 山寨币上限 1.5 × 10000 = 15000，冷却期默认降至 50% 即 7500
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 10000
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "大赢后冷却") {
+		t.Fatalf("+15%% 大赢后 10000 USDT 仓位应超过冷却上限，得到 %v", err)
+	}
+
+	d = longDecision("SOLUSDT")
+	d.PositionSizeUSD = 5000
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("冷却上限内的仓位应通过: %v", err)
+	}
+
+	if user := buildUserPrompt(ctx); !strings.Contains(user, "大赢后冷却") {
+		t.Fatal("冷却期间 user prompt 应提示仓位上限降低")
+	}
+}
+
+func TestBigWinCooldownExpires(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.BigWinPnLPct = 10
+	ctx.RiskConfig.BigWinCooldownMinutes = 30
+	ctx.Performance = &PerformanceData{RecentTrades: []TradeOutcome{
+		{Symbol: "BTCUSDT", PnL: 150, PnLPct: 15, CloseTime: time.Now().Add(-time.Hour)},
+	}}
+	if active, _ := ctx.bigWinCooldown(); active {
+		t.Fatal("超过冷却时长后不应再限制仓位")
+	}
+}
@@ -0,0 +1,46 @@
+package decision
+
+import "testing"
+
+func TestExtractCoTTraceJSONOnly(t *testing.T) {
+	for _, response := range []string{
+		`[{"symbol":"BTCUSDT","action":"wait","reasoning":"观望"}]`,
+		"```json\n[{\"symbol\":\"BTCUSDT\",\"action\":\"wait\",\"reasoning\":\"观望\"}]\n```",
+	} {
+		cot, missing := extractCoTTrace(response)
+		if cot != "" || !missing {
+			t.Errorf("只有JSON时思维链应为空且 missing=true, got cot=%q missing=%v", cot, missing)
+		}
+	}
+}
+
+func TestExtractCoTTraceProseOnly(t *testing.T) {
+	response := "  市场震荡，没有合适的机会。  "
+	cot, missing := extractCoTTrace(response)
+	if cot != "市场震荡，没有合适的机会。" || missing {
+		t.Fatalf("没有JSON时整个响应都是思维链, got cot=%q missing=%v", cot, missing)
+	}
+}
+
+func TestExtractCoTTraceProseBeforeJSON(t *testing.T) {
+	cot, missing := extractCoTTrace("BTC趋势向上。\n[{\"symbol\":\"BTCUSDT\",\"action\":\"wait\",\"reasoning\":\"观望\"}]")
+	if cot != "BTC趋势向上。" || missing {
+		t.Fatalf("got cot=%q missing=%v", cot, missing)
+	}
+}
+
+func TestParseFullDecisionResponseFlagsMissingCoT(t *testing.T) {
+	ctx := testContext()
+	decision, err := parseFullDecisionResponse(`[{"symbol":"BTCUSDT","action":"wait","reasoning":"观望"}]`, ctx)
+	if err != nil {
+		t.Fatalf("parseFullDecisionResponse: %v", err)
+	}
+	if !decision.CoTMissing || decision.CoTTrace != "" {
+		t.Fatalf("应标记 CoTMissing, got missing=%v cot=%q", decision.CoTMissing, decision.CoTTrace)
+	}
+
+	_, err = parseFullDecisionResponse("没有JSON，只有分析", ctx)
+	if err == nil {
+		t.Fatal("没有JSON时应返回提取失败的错误")
+	}
+}
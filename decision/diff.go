@@ -0,0 +1,102 @@
+package decision
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DiffDecisions 比较两次完整决策，返回可读的差异列表（相同时返回空）
+// 按 账户+币种 分组对比（同一币种的多个决策按顺序逐个比较），用于修改prompt/规则后的回归检查
+func DiffDecisions(a, b *FullDecision) []string {
+	if a == nil || b == nil {
+		if a == b {
+			return nil
+		}
+		return []string{fmt.Sprintf("一侧决策为空: a=%v b=%v", a != nil, b != nil)}
+	}
+
+	groupsA, groupsB := groupDecisions(a.Decisions), groupDecisions(b.Decisions)
+	keys := make([]string, 0, len(groupsA)+len(groupsB))
+	for key := range groupsA {
+		keys = append(keys, key)
+	}
+	for key := range groupsB {
+		if _, ok := groupsA[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var diffs []string
+	for _, key := range keys {
+		listA, listB := groupsA[key], groupsB[key]
+		n := len(listA)
+		if len(listB) > n {
+			n = len(listB)
+		}
+		for i := 0; i < n; i++ {
+			switch {
+			case i >= len(listA):
+				diffs = append(diffs, fmt.Sprintf("%s: 新增 %s", key, listB[i].Action))
+			case i >= len(listB):
+				diffs = append(diffs, fmt.Sprintf("%s: 删除 %s", key, listA[i].Action))
+			default:
+				for _, change := range diffDecision(listA[i], listB[i]) {
+					diffs = append(diffs, fmt.Sprintf("%s: %s", key, change))
+				}
+			}
+		}
+	}
+	return diffs
+}
+
+// groupDecisions 按 账户+币种 分组（保持原顺序）
+func groupDecisions(decisions []Decision) map[string][]Decision {
+	groups := make(map[string][]Decision)
+	for _, d := range decisions {
+		key := d.Symbol
+		if key == "" {
+			key = "(全部)"
+		}
+		if d.Account != "" {
+			key = d.Account + "/" + key
+		}
+		groups[key] = append(groups[key], d)
+	}
+	return groups
+}
+
+// diffDecision 比较单个决策的动作和交易参数（不比较 reasoning）
+func diffDecision(a, b Decision) []string {
+	var changes []string
+	if a.Action != b.Action {
+		changes = append(changes, fmt.Sprintf("action %s → %s", a.Action, b.Action))
+	}
+	if a.Strategy != b.Strategy {
+		changes = append(changes, fmt.Sprintf("strategy %q → %q", a.Strategy, b.Strategy))
+	}
+	if a.Leverage != b.Leverage {
+		changes = append(changes, fmt.Sprintf("leverage %d → %d", a.Leverage, b.Leverage))
+	}
+	if a.Confidence != b.Confidence {
+		changes = append(changes, fmt.Sprintf("confidence %d → %d", a.Confidence, b.Confidence))
+	}
+	floats := []struct {
+		name string
+		a, b float64
+	}{
+		{"position_size_usd", a.PositionSizeUSD, b.PositionSizeUSD},
+		{"stop_loss", a.StopLoss, b.StopLoss},
+		{"take_profit", a.TakeProfit, b.TakeProfit},
+		{"entry_price", a.EntryPrice, b.EntryPrice},
+		{"risk_usd", a.RiskUSD, b.RiskUSD},
+		{"reduce_by_pct", a.ReduceByPct, b.ReduceByPct},
+		{"new_stop_loss", a.NewStopLoss, b.NewStopLoss},
+	}
+	for _, f := range floats {
+		if f.a != f.b {
+			changes = append(changes, fmt.Sprintf("%s %.4f → %.4f", f.name, f.a, f.b))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,23 @@
+package decision
+
+import "testing"
+
+func TestDiffDecisionsDetectsChangedAction(t *testing.T) {
+	a := &FullDecision{Decisions: []Decision{longDecision("SOLUSDT"), {Symbol: "BTCUSDT", Action: ActionHold}}}
+	b := &FullDecision{Decisions: []Decision{longDecision("SOLUSDT"), {Symbol: "BTCUSDT", Action: ActionCloseLong}}}
+
+	diffs := DiffDecisions(a, b)
+	if len(diffs) != 1 || diffs[0] != "BTCUSDT: action hold → close_long" {
+		t.Fatalf("应只报告 BTCUSDT 的动作变化，得到 %v", diffs)
+	}
+}
+
+func TestDiffDecisionsUnchangedPair(t *testing.T) {
+	a := &FullDecision{Decisions: []Decision{longDecision("SOLUSDT")}}
+	b := &FullDecision{Decisions: []Decision{longDecision("SOLUSDT")}}
+	b.Decisions[0].Reasoning = "不同的理由不算差异"
+
+	if diffs := DiffDecisions(a, b); len(diffs) != 0 {
+		t.Fatalf("相同决策不应有差异，得到 %v", diffs)
+	}
+}
@@ -0,0 +1,73 @@
+package decision
+
+// DecisionValidation 影子模式下单个决策的验证结果（Index 为决策下标，批次级别的结果为 -1）
+type DecisionValidation struct {
+	Index  int    `json:"index"`
+	Symbol string `json:"symbol,omitempty"`
+	Action Action `json:"action,omitempty"`
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"` // 未通过时的原因（被转为wait/hold时为转换说明）
+}
+
+// dryRunValidate 影子模式：对决策副本执行与正常模式相同的规则，只记录每个决策的结果，不修改、不过滤原决策
+func dryRunValidate(decisions []Decision, ctx *Context) []DecisionValidation {
+	work := make([]Decision, len(decisions))
+	copy(work, decisions)
+
+	results := make([]DecisionValidation, len(decisions))
+	for i, d := range decisions {
+		results[i] = DecisionValidation{Index: i, Symbol: d.Symbol, Action: d.Action, Passed: true}
+	}
+	fail := func(i int, reason string) {
+		results[i].Passed = false
+		results[i].Reason = reason
+	}
+
+	// 批次级别的转换规则（开仓转wait、平仓转hold）
+	enforceSharpeHalt(work, ctx)
+	enforceMinHold(work, ctx)
+	if err := enforceMaxNewOpens(work, ctx); err != nil {
+		for i := range work {
+			if work[i].Action.IsOpen() {
+				fail(i, err.Error())
+			}
+		}
+	}
+	enforceOpenThrottle(work, ctx)
+	for i := range work {
+		if results[i].Passed && work[i].Action != decisions[i].Action {
+			fail(i, work[i].Reasoning)
+		}
+	}
+
+	// 逐个验证（失败不影响其他决策）
+	var passed []Decision
+	for i := range work {
+		if !results[i].Passed {
+			continue
+		}
+		d := &work[i]
+		accountEquity := ctx.Account.TotalEquity
+		if d.Action.IsOpen() {
+			acct, err := ctx.accountFor(d.Account)
+			if err != nil {
+				fail(i, err.Error())
+				continue
+			}
+			accountEquity = acct.TotalEquity
+		}
+		if err := validateDecisionCached(d, accountEquity, ctx); err != nil {
+			fail(i, err.Error())
+			continue
+		}
+		passed = append(passed, *d)
+	}
+
+	// 组合层面的规则作用于通过验证的决策
+	for _, check := range []func([]Decision, *Context) error{validateGrossNotional, validateSameDirectionPositions} {
+		if err := check(passed, ctx); err != nil {
+			results = append(results, DecisionValidation{Index: -1, Passed: false, Reason: err.Error()})
+		}
+	}
+	return results
+}
@@ -0,0 +1,48 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDryRunRecordsValidationWithoutFiltering(t *testing.T) {
+	ctx := testContext()
+	ctx.DryRun = true
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	response := "SOL突破，ETH观望。\n" +
+		`[{"symbol":"SOLUSDT","action":"open_long","leverage":3,"position_size_usd":1000,"stop_loss":95,"take_profit":115,"confidence":80,"risk_usd":50,"reasoning":"突破"},` +
+		`{"symbol":"ETHUSDT","action":"wait","reasoning":"观望"}]`
+
+	decision, err := parseFullDecisionResponse(response, ctx)
+	if err != nil {
+		t.Fatalf("影子模式不应因验证失败报错: %v", err)
+	}
+	if !decision.DryRun || len(decision.Decisions) != 2 {
+		t.Fatalf("影子模式应原样返回全部决策，得到 dry_run=%v %+v", decision.DryRun, decision.Decisions)
+	}
+	for _, result := range decision.ValidationResults {
+		if !result.Passed {
+			t.Fatalf("合规决策应全部通过，得到 %+v", result)
+		}
+	}
+
+	// 风险回报比不足的决策：记录失败原因，但仍原样返回
+	response = strings.Replace(response, `"take_profit":115`, `"take_profit":101`, 1)
+	decision, err = parseFullDecisionResponse(response, ctx)
+	if err != nil {
+		t.Fatalf("影子模式不应因验证失败报错: %v", err)
+	}
+	if len(decision.Decisions) != 2 || decision.Decisions[0].Action != ActionOpenLong || decision.Decisions[0].TakeProfit != 101 {
+		t.Fatalf("未通过验证的决策也应原样返回，得到 %+v", decision.Decisions)
+	}
+	if len(decision.ValidationResults) != 2 {
+		t.Fatalf("应逐个记录验证结果，得到 %+v", decision.ValidationResults)
+	}
+	sol, eth := decision.ValidationResults[0], decision.ValidationResults[1]
+	if sol.Passed || sol.Symbol != "SOLUSDT" || !strings.Contains(sol.Reason, "风险回报比过低") {
+		t.Fatalf("SOLUSDT 应记录风险回报比失败原因，得到 %+v", sol)
+	}
+	if !eth.Passed || eth.Reason != "" {
+		t.Fatalf("ETHUSDT 应通过验证，得到 %+v", eth)
+	}
+}
@@ -0,0 +1,26 @@
+package decision
+
+import "testing"
+
+func TestEmptyArrayLeftEmptyByDefault(t *testing.T) {
+	ctx := testContext()
+	decision, err := parseFullDecisionResponse("没有合适的机会。\n[]", ctx)
+	if err != nil {
+		t.Fatalf("parseFullDecisionResponse: %v", err)
+	}
+	if !decision.EmptyResponse || len(decision.Decisions) != 0 {
+		t.Fatalf("默认保留空数组并标记 EmptyResponse: empty=%v decisions=%+v", decision.EmptyResponse, decision.Decisions)
+	}
+}
+
+func TestEmptyArrayAsWait(t *testing.T) {
+	ctx := testContext()
+	ctx.EmptyArrayAsWait = true
+	decision, err := parseFullDecisionResponse("没有合适的机会。\n[]", ctx)
+	if err != nil {
+		t.Fatalf("parseFullDecisionResponse: %v", err)
+	}
+	if !decision.EmptyResponse || len(decision.Decisions) != 1 || decision.Decisions[0].Action != ActionWait {
+		t.Fatalf("EmptyArrayAsWait 时应补充显式的 wait: empty=%v decisions=%+v", decision.EmptyResponse, decision.Decisions)
+	}
+}
@@ -0,0 +1,36 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/mcp"
+)
+
+func TestEmptyContextShortCircuitsWithoutCallingAI(t *testing.T) {
+	stubOITop(t, nil, nil)
+	ctx := testContext()
+
+	// 客户端未设置密钥，一旦调用AI就会返回错误
+	decision, err := GetFullDecision(ctx, mcp.New())
+	if err != nil {
+		t.Fatalf("无持仓且无候选币种时不应调用AI: %v", err)
+	}
+	if len(decision.Decisions) != 1 || decision.Decisions[0].Action != ActionWait {
+		t.Fatalf("应返回全部观望的决策: %+v", decision.Decisions)
+	}
+	if !strings.Contains(decision.CoTTrace, "未调用AI") {
+		t.Fatalf("CoTTrace 应说明未调用AI: %q", decision.CoTTrace)
+	}
+}
+
+func TestEmptyContextCallsAIWhenConfigured(t *testing.T) {
+	stubOITop(t, nil, nil)
+	ctx := testContext()
+	ctx.CallAIWhenEmpty = true
+
+	_, err := GetFullDecision(ctx, mcp.New())
+	if err == nil || !strings.Contains(err.Error(), "调用AI API失败") {
+		t.Fatalf("CallAIWhenEmpty 时应照常调用AI，得到 %v", err)
+	}
+}
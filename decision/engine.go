@@ -2,13 +2,17 @@ package decision
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"nofx/market"
 	"nofx/mcp"
-	"nofx/pool"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // PositionInfo 持仓信息
@@ -23,11 +27,60 @@ type PositionInfo struct {
 	UnrealizedPnLPct float64 `json:"unrealized_pnl_pct"`
 	LiquidationPrice float64 `json:"liquidation_price"`
 	MarginUsed       float64 `json:"margin_used"`
-	UpdateTime       int64   `json:"update_time"` // 持仓更新时间戳（毫秒）
+	UpdateTime       int64   `json:"update_time"`                 // 持仓更新时间戳（毫秒）
+	Account          string  `json:"account,omitempty"`           // 所属子账户ID（多账户模式）
+	TakeProfit       float64 `json:"take_profit,omitempty"`       // 止盈价（开仓时设置，未知时为0）
+	StopLoss         float64 `json:"stop_loss,omitempty"`         // 止损价（开仓时设置，未知时为0，按强平价估算风险）
+	IntendedQuantity float64 `json:"intended_quantity,omitempty"` // 开仓时计划的数量（部分成交时大于实际 Quantity，未知时为0）
+}
+
+// FillRatio 实际数量占计划数量的比例（未记录计划数量时返回 false）
+func (p PositionInfo) FillRatio() (float64, bool) {
+	if p.IntendedQuantity <= 0 {
+		return 0, false
+	}
+	return p.Quantity / p.IntendedQuantity, true
+}
+
+// BreakEvenPrice 计入开平仓手续费后的保本价
+// roundTripFeePct 为开仓+平仓手续费合计占名义价值的百分比（如 0.08 表示 0.08%），按入场名义价值近似计算
+func (p PositionInfo) BreakEvenPrice(roundTripFeePct float64) float64 {
+	fee := roundTripFeePct / 100
+	if p.Side == "short" {
+		return p.EntryPrice * (1 - fee)
+	}
+	return p.EntryPrice * (1 + fee)
+}
+
+// HoldingDuration 持仓时长（UpdateTime 未知时返回0）
+func (p PositionInfo) HoldingDuration() time.Duration {
+	if p.UpdateTime <= 0 {
+		return 0
+	}
+	return time.Duration(time.Now().UnixMilli()-p.UpdateTime) * time.Millisecond
+}
+
+// staleHoldMinProfitPct 无止盈价时，盈亏百分比低于该值视为"未接近目标"
+const staleHoldMinProfitPct = 5.0
+
+// isNearTarget 判断持仓是否已接近止盈目标
+// 有止盈价时：价格已走完入场→止盈距离的一半以上；否则按未实现盈亏百分比判断
+func (p PositionInfo) isNearTarget() bool {
+	if p.TakeProfit > 0 && p.EntryPrice > 0 && p.TakeProfit != p.EntryPrice {
+		var progress float64
+		if p.Side == "long" {
+			progress = (p.MarkPrice - p.EntryPrice) / (p.TakeProfit - p.EntryPrice)
+		} else {
+			progress = (p.EntryPrice - p.MarkPrice) / (p.EntryPrice - p.TakeProfit)
+		}
+		return progress >= 0.5
+	}
+	return p.UnrealizedPnLPct >= staleHoldMinProfitPct
 }
 
 // AccountInfo 账户信息
 type AccountInfo struct {
+	ID               string  `json:"id,omitempty"`      // 子账户标识（多账户模式）
 	TotalEquity      float64 `json:"total_equity"`      // 账户净值
 	AvailableBalance float64 `json:"available_balance"` // 可用余额
 	TotalPnL         float64 `json:"total_pnl"`         // 总盈亏
@@ -55,31 +108,390 @@ type OITopData struct {
 
 // Context 交易上下文（传递给AI的完整信息）
 type Context struct {
-	CurrentTime         string                  `json:"current_time"`
-	RuntimeMinutes      int                     `json:"runtime_minutes"`
-	CallCount           int                     `json:"call_count"`
-	Account             AccountInfo             `json:"account"`
-	Positions           []PositionInfo          `json:"positions"`
-	CandidateCoins      []CandidateCoin         `json:"candidate_coins"`
-	MarketDataMap       map[string]*market.Data `json:"-"` // 不序列化，但内部使用
-	OITopDataMap        map[string]*OITopData   `json:"-"` // OI Top数据映射
-	Performance         interface{}             `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
-	BTCETHLeverage      int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
-	AltcoinLeverage     int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
-	ScanIntervalMinutes int                     `json:"-"` // 决策间隔（分钟，从配置读取）
+	CurrentTime              string                  `json:"current_time"`
+	RuntimeMinutes           int                     `json:"runtime_minutes"`
+	CallCount                int                     `json:"call_count"`
+	Account                  AccountInfo             `json:"account"`            // 账户信息（多账户时为汇总数据）
+	Accounts                 []AccountInfo           `json:"accounts,omitempty"` // 子账户列表（为空时视为只有 Account 一个账户）
+	Positions                []PositionInfo          `json:"positions"`
+	CandidateCoins           []CandidateCoin         `json:"candidate_coins"`
+	MarketDataMap            map[string]*market.Data `json:"-"` // 不序列化，但内部使用
+	OITopDataMap             map[string]*OITopData   `json:"-"` // OI Top数据映射
+	Performance              interface{}             `json:"-"` // 历史表现分析（logger.PerformanceAnalysis）
+	BTCETHLeverage           int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
+	AltcoinLeverage          int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	ScanIntervalMinutes      int                     `json:"-"` // 决策间隔（分钟，从配置读取）
+	PendingOpens             int                     `json:"-"` // 已提交但尚未成交的开仓数量（使用限价开仓的集成方填写；AutoTrader 以市价单开仓，始终为0）
+	StrictFields             bool                    `json:"-"` // 严格模式：决策JSON中出现未知字段时直接拒绝（否则仅记录日志）
+	RequireOITop             bool                    `json:"-"` // OI Top数据为必需：加载失败时中止本次决策
+	StaleHoldMinutes         int                     `json:"-"` // 持仓超过该时长仍未接近目标时提示重新评估（0表示不提示）
+	StrictNonOpenFields      bool                    `json:"-"` // 严格模式：平仓/持有/等待决策携带开仓参数时直接拒绝（否则仅记录日志）
+	MarketDataProvider       MarketDataProvider      `json:"-"` // 市场数据来源（为空时使用 market.Get）
+	PositionDataProvider     MarketDataProvider      `json:"-"` // 持仓币种的市场数据来源（为空时使用 MarketDataProvider；可配置为不走缓存的实时数据源）
+	PositionDataMaxAge       time.Duration           `json:"-"` // 持仓币种市场数据的最大时效（>0且数据源实现 FreshnessAwareProvider 时生效）
+	PlainText                bool                    `json:"-"` // 输出纯文本prompt（无emoji、少markdown），适配部分本地模型
+	MinAbs4hChangePct        float64                 `json:"-"` // 候选币种4小时涨跌幅绝对值下限（%），低于则跳过（0表示不过滤）
+	MinOIValueUSD            float64                 `json:"-"` // 候选币种持仓价值（持仓量 × 价格）下限（USD，0时默认15M），低于则跳过，现有持仓不受影响
+	CandidateWinRateWeight   float64                 `json:"-"` // 按历史胜率重排候选币种的权重（胜率100%时最多前移的名次，0表示不重排）
+	CandidateJitterSeed      int64                   `json:"-"` // 候选币种组内随机扰动的种子（避免多个机器人扎堆同一交易，0表示不扰动，固定种子结果可复现）
+	CandidateJitterBand      int                     `json:"-"` // 扰动分组大小（按排名每N个一组，只在组内打乱，0时默认3）
+	LiteMarketData           bool                    `json:"-"` // 精简模式：每个币种只输出一行最新指标摘要，不输出序列数据
+	MinHoldMinutes           int                     `json:"-"` // 最小持仓时间（分钟，0时默认30）
+	AllowEarlyClose          bool                    `json:"-"` // 允许未满最小持仓时间的平仓（默认转为hold，触及止损/止盈或接近强平时除外）
+	DryRun                   bool                    `json:"-"` // 影子模式：决策原样返回，不因验证失败报错，逐个记录验证结果到 FullDecision.ValidationResults
+	ScaleMinHoldByVolatility bool                    `json:"-"` // 高波动币种按波动率缩短最小持仓时间（不低于10分钟）
+	VerboseValidation        bool                    `json:"-"` // 趋势类验证失败时在错误信息中附带具体指标数值（EMA/MACD/RSI）
+	SectionOrder             []string                `json:"-"` // user prompt 主要段落顺序（performance/account/positions/candidates，为空时使用默认顺序）
+	CalibrationMinGap        float64                 `json:"-"` // 盈利与亏损交易平均信心度之差低于该值时提示信心度未校准（0时默认5）
+	PerformanceHalfLife      float64                 `json:"-"` // 表现统计的时间衰减半衰期（以交易笔数计，>0时在原始统计旁显示衰减加权的胜率/盈亏比）
+	ValidationRetries        int                     `json:"-"` // 决策验证失败后附带拒绝原因重新提示模型的次数（0表示不重试）
+	ExplainRejections        bool                    `json:"-"` // 重新提示时把拒绝原因转换为对应规则的修正建议（否则附带原始错误）
+	ValidationCache          *ValidationCache        `json:"-"` // 决策验证结果缓存（可选，跨重试复用同一实例）
+	SyntheticMarketData      map[string]*market.Data `json:"-"` // 注入的合成币种市场数据（用于压力测试策略），视为权威数据，不通过数据源获取；不读取预填的 MarketDataMap（每次调用都会重建，避免复用 Context 时把上一轮价格当作权威数据）
+	BypassSyntheticFilters   bool                    `json:"-"` // SyntheticMarketData 中的合成币种跳过白名单/流动性/波动过滤
+	MaxPromptChars           int                     `json:"-"` // system + user prompt 字符数硬上限，超过时不调用AI直接返回错误（0表示不限制）
+	MaxOutputTokens          int                     `json:"-"` // 要求模型输出（思维链 + JSON）不超过的token预算，写入 system prompt 并记录实际用量（0表示不限制）
+	AllowedSymbols           []string                `json:"-"` // 交易币种白名单（非空时只分析/开仓名单内的币种，名单外的现有持仓仍正常管理）
+	AllowedStrategies        []string                `json:"-"` // 允许的策略标签（非空时决策的 strategy 必须在名单内，大小写不敏感）
+	IncompleteDataPolicy     string                  `json:"-"` // 候选币种缺少必需指标（MACD/RSI/4h数据）时的处理: ""不检查, "skip"跳过, "flag"标注（现有持仓不受影响）
+	IncompleteData           map[string][]string     `json:"-"` // 输出: "flag" 模式下各候选币种缺失的指标
+	RoundTripFeePct          float64                 `json:"-"` // 开仓+平仓手续费合计（占名义价值%），>0时在持仓信息中显示计入手续费的保本价
+	LiquidationWarnPct       float64                 `json:"-"` // 持仓当前价距强平价小于该百分比时在prompt最前面发出强平风险警报（0时默认5）
+	CallAIWhenEmpty          bool                    `json:"-"` // 既无持仓也无可分析的候选币种时仍调用AI（默认直接返回全部观望，节省token）
+	EmptyArrayAsWait         bool                    `json:"-"` // 模型返回空数组 [] 时补充一条显式的 wait 决策（否则保持为空）
+	LastOpenTime             time.Time               `json:"-"` // 最近一次开仓的时间（任意币种，零值表示未知），用于全局开仓节流
+	CycleReturns             []float64               `json:"-"` // 逐周期收益率（可选，提供时在包内计算夏普比率，覆盖 Performance 中的值）
+	RiskFreeRate             float64                 `json:"-"` // 计算夏普比率时的每周期无风险利率（默认0）
+	PromptReport             *PromptReport           `json:"-"` // 输出: 最近一次构建的 user prompt 包含/省略的段落和截断操作（由 buildUserPrompt 写入）
+	OnDecision               func(*FullDecision)     `json:"-"` // 成功获取并验证决策后的回调（通知、持久化等，可选）
+	OnError                  func(error)             `json:"-"` // 获取决策失败时的回调（可选）
+
+	OnlyManagePositionsWhenFull bool       `json:"-"` // 持仓已满时跳过候选币种分析，prompt只聚焦持仓管理
+	RiskConfig                  RiskConfig `json:"-"` // 风控参数（零值使用默认值）
+}
+
+// RiskConfig 风控参数配置
+type RiskConfig struct {
+	MaxPositions                   int                      `json:"max_positions"`                       // 最多同时持仓数量（0时默认3）
+	MaxGrossNotionalMultiple       float64                  `json:"max_gross_notional_multiple"`         // 总名义仓位上限（账户净值的倍数，0表示不限制）
+	ScaleSizeByMarginHeadroom      bool                     `json:"scale_size_by_margin_headroom"`       // 新开仓所需保证金超过剩余额度（距保证金使用率上限）时缩小仓位
+	MarginCapPct                   float64                  `json:"margin_cap_pct"`                      // 保证金使用率上限（%，0时默认80）
+	SymbolLeverageCaps             map[string]int           `json:"symbol_leverage_caps"`                // 币种专属杠杆上限（优先于BTC/ETH和山寨币两档配置）
+	SymbolMinNotional              map[string]float64       `json:"symbol_min_notional"`                 // 交易所单币种最小下单名义价值（USDT），低于该值的订单无法下单
+	MinTargetCandleMultiple        float64                  `json:"min_target_candle_multiple"`          // 止盈距离至少为3分钟K线典型波动的倍数（0表示不检查）
+	SymbolSlippagePct              map[string]float64       `json:"symbol_slippage_pct"`                 // 币种预估滑点（占价格的百分比，含点差），未配置的币种不检查
+	MinStopSlippageMultiple        float64                  `json:"min_stop_slippage_multiple"`          // 止损距离至少为预估滑点的倍数（0表示不检查）
+	SymbolLeverageTiers            map[string][]int         `json:"symbol_leverage_tiers"`               // 币种可用的离散杠杆档位（如 {3,5,10,20}），未配置的币种不限制
+	LeverageConfidenceTiers        []LeverageConfidenceTier `json:"leverage_confidence_tiers"`           // 杠杆越高要求的信心度越高（如 >10x 需≥85，>15x 需≥90），为空时不检查
+	RejectOffTierLeverage          bool                     `json:"reject_off_tier_leverage"`            // 杠杆不在档位上时直接拒绝（否则向下取整到最近的档位）
+	BTCBearishAltLongMinConfidence int                      `json:"btc_bearish_alt_long_min_confidence"` // BTC 4h明确下跌时做多山寨币所需的最低信心度（0时默认95，>100表示完全禁止）
+	EnforceTrendAlignment          bool                     `json:"enforce_trend_alignment"`             // 禁止逆4h主趋势开仓（RSI极端值+高信心度时例外）
+	RejectTrendConflict            bool                     `json:"reject_trend_conflict"`               // 4h与3m趋势方向相反时拒绝开仓
+	MinConfirmingIndicators        int                      `json:"min_confirming_indicators"`           // 开仓至少需要 {EMA20位置, MACD符号, RSI方向} 中几个指标确认方向（0时默认2）
+	RSIExtremeHigh                 float64                  `json:"rsi_extreme_high"`                    // 极端超买阈值（0时默认80），上升趋势中超过该值允许做空
+	RSIExtremeLow                  float64                  `json:"rsi_extreme_low"`                     // 极端超卖阈值（0时默认20），下跌趋势中低于该值允许做多
+	CounterTrendMinConfidence      int                      `json:"counter_trend_min_confidence"`        // 逆势例外所需的最低信心度（0时默认85）
+	MaxPortfolioHeatPct            float64                  `json:"max_portfolio_heat_pct"`              // 组合热度（全部止损时亏损占净值%）警告阈值（0时默认10）
+	MaxNewOpensPerCycle            int                      `json:"max_new_opens_per_cycle"`             // 每个周期最多新开仓数量（0时默认1）
+	RejectExcessOpens              bool                     `json:"reject_excess_opens"`                 // 开仓数超出上限时拒绝整批决策（否则只保留信心度最高的，其余转为wait）
+	MaxSameDirectionPositions      int                      `json:"max_same_direction_positions"`        // 同方向（多/空）最多同时持仓数量（现有 + 本批净新开，0表示不限制）
+	MinSecondsBetweenOpens         int                      `json:"min_seconds_between_opens"`           // 任意两次开仓的最小间隔（秒，0表示不限制），间隔内的开仓转为wait
+	AllowStopLoosening             bool                     `json:"allow_stop_loosening"`                // 允许 hold 决策的 new_stop_loss 放宽止损（默认禁止，止损只能向有利方向移动）
+	RiskUSDTolerancePct            float64                  `json:"risk_usd_tolerance_pct"`              // risk_usd 与按仓位/止损计算的风险允许的偏差（%，0时默认20）
+	RejectRiskUSDMismatch          bool                     `json:"reject_risk_usd_mismatch"`            // risk_usd 偏差超限时拒绝（否则改写为计算值）
+	MinRiskReward                  float64                  `json:"min_risk_reward"`                     // 开仓最低风险回报比（收益/风险，0时默认2，即 1:2），prompt中的要求与验证共用该值
+	SharpeThresholds               SharpeThresholds         `json:"sharpe_thresholds"`                   // 夏普比率状态分界线（全为0时默认 -0.5 / 0 / 0.7）
+	ReducedRegimeMaxLeverage       int                      `json:"reduced_regime_max_leverage"`         // 夏普比率处于轻微亏损（收缩模式）时的杠杆上限，独立于币种上限（0表示不限制）
+	BigWinPnLPct                   float64                  `json:"big_win_pnl_pct"`                     // 最近一笔平仓盈利超过该百分比时进入冷却期，降低仓位上限（0表示不启用）
+	BigWinSizeFactor               float64                  `json:"big_win_size_factor"`                 // 冷却期间单币种仓位上限的系数（0时默认0.5）
+	BigWinCooldownMinutes          int                      `json:"big_win_cooldown_minutes"`            // 冷却时长（分钟，0时为一个决策周期）
+	OITrendPolicy                  string                   `json:"oi_trend_policy"`                     // 开仓方向与OI/价格关系相反时的处理: ""不检查, "penalize"降低信心度, "reject"拒绝
+	OITrendPenalty                 int                      `json:"oi_trend_penalty"`                    // penalize 模式下扣减的信心度（0时默认10）
+}
+
+// LeverageConfidenceTier 杠杆超过 AboveLeverage 时开仓所需的最低信心度
+type LeverageConfidenceTier struct {
+	AboveLeverage int `json:"above_leverage"`
+	MinConfidence int `json:"min_confidence"`
+}
+
+// requiredConfidenceForLeverage 返回该杠杆开仓所需的最低信心度（取所有满足条件的档位中最高的要求，无要求时返回0）
+func (c RiskConfig) requiredConfidenceForLeverage(leverage int) int {
+	required := 0
+	for _, tier := range c.LeverageConfidenceTiers {
+		if leverage > tier.AboveLeverage && tier.MinConfidence > required {
+			required = tier.MinConfidence
+		}
+	}
+	return required
+}
+
+// marginCapPct 返回保证金使用率上限（未配置时默认80%）
+func (c RiskConfig) marginCapPct() float64 {
+	if c.MarginCapPct <= 0 {
+		return 80
+	}
+	return c.MarginCapPct
+}
+
+// maxPositions 返回最多持仓数量（未配置时默认3）
+func (c RiskConfig) maxPositions() int {
+	if c.MaxPositions <= 0 {
+		return 3
+	}
+	return c.MaxPositions
+}
+
+// minConfirmingIndicators 返回开仓所需的最少确认指标数（未配置时默认2）
+func (c RiskConfig) minConfirmingIndicators() int {
+	if c.MinConfirmingIndicators <= 0 {
+		return 2
+	}
+	return c.MinConfirmingIndicators
+}
+
+// rsiExtremes 返回极端超卖/超买阈值（未配置时默认20/80）
+func (c RiskConfig) rsiExtremes() (low, high float64) {
+	low, high = c.RSIExtremeLow, c.RSIExtremeHigh
+	if low <= 0 {
+		low = 20
+	}
+	if high <= 0 {
+		high = 80
+	}
+	return low, high
+}
+
+// counterTrendMinConfidence 返回逆势例外所需的最低信心度（未配置时默认85）
+func (c RiskConfig) counterTrendMinConfidence() int {
+	if c.CounterTrendMinConfidence <= 0 {
+		return 85
+	}
+	return c.CounterTrendMinConfidence
+}
+
+// maxNewOpensPerCycle 返回每个周期最多新开仓数量（未配置时默认1）
+func (c RiskConfig) maxNewOpensPerCycle() int {
+	if c.MaxNewOpensPerCycle <= 0 {
+		return 1
+	}
+	return c.MaxNewOpensPerCycle
+}
+
+// minRiskReward 返回开仓最低风险回报比（未配置时默认2）
+func (c RiskConfig) minRiskReward() float64 {
+	if c.MinRiskReward <= 0 {
+		return 2
+	}
+	return c.MinRiskReward
+}
+
+// formatRatio 按原样输出比值（2.25 输出 "2.25"，3 输出 "3"），用于prompt中的风险回报比
+func formatRatio(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// maxPortfolioHeatPct 返回组合热度警告阈值（未配置时默认10%）
+func (c RiskConfig) maxPortfolioHeatPct() float64 {
+	if c.MaxPortfolioHeatPct <= 0 {
+		return defaultMaxPortfolioHeatPct
+	}
+	return c.MaxPortfolioHeatPct
+}
+
+// btcBearishAltLongMinConfidence 返回BTC下跌时做多山寨币所需的最低信心度（未配置时默认95）
+func (c RiskConfig) btcBearishAltLongMinConfidence() int {
+	if c.BTCBearishAltLongMinConfidence <= 0 {
+		return 95
+	}
+	return c.BTCBearishAltLongMinConfidence
+}
+
+// clone 深拷贝风控参数（map/切片不与调用方共享，之后修改配置不影响已记录的快照）
+func (c RiskConfig) clone() RiskConfig {
+	cloned := c
+	if c.SymbolLeverageCaps != nil {
+		cloned.SymbolLeverageCaps = make(map[string]int, len(c.SymbolLeverageCaps))
+		for symbol, leverageCap := range c.SymbolLeverageCaps {
+			cloned.SymbolLeverageCaps[symbol] = leverageCap
+		}
+	}
+	if c.LeverageConfidenceTiers != nil {
+		cloned.LeverageConfidenceTiers = append([]LeverageConfidenceTier(nil), c.LeverageConfidenceTiers...)
+	}
+	if c.SymbolLeverageTiers != nil {
+		cloned.SymbolLeverageTiers = make(map[string][]int, len(c.SymbolLeverageTiers))
+		for symbol, tiers := range c.SymbolLeverageTiers {
+			cloned.SymbolLeverageTiers[symbol] = append([]int(nil), tiers...)
+		}
+	}
+	if c.SymbolMinNotional != nil {
+		cloned.SymbolMinNotional = make(map[string]float64, len(c.SymbolMinNotional))
+		for symbol, minNotional := range c.SymbolMinNotional {
+			cloned.SymbolMinNotional[symbol] = minNotional
+		}
+	}
+	if c.SymbolSlippagePct != nil {
+		cloned.SymbolSlippagePct = make(map[string]float64, len(c.SymbolSlippagePct))
+		for symbol, slippagePct := range c.SymbolSlippagePct {
+			cloned.SymbolSlippagePct[symbol] = slippagePct
+		}
+	}
+	return cloned
+}
+
+// maxLeverageLimit 杠杆倍数的合理上限（交易所普遍不超过125倍）
+const maxLeverageLimit = 125
+
+// Validate 检查风控参数的取值范围和内部一致性
+// 配置错误（如负数、0杠杆）会导致运行时行为难以理解，应在调用AI之前尽早暴露
+func (c RiskConfig) Validate() error {
+	if c.MaxPositions < 0 {
+		return fmt.Errorf("max_positions 不能为负数: %d", c.MaxPositions)
+	}
+	if c.MarginCapPct < 0 || c.MarginCapPct > 100 {
+		return fmt.Errorf("margin_cap_pct 必须在0-100之间: %.2f", c.MarginCapPct)
+	}
+	if c.MaxGrossNotionalMultiple < 0 {
+		return fmt.Errorf("max_gross_notional_multiple 不能为负数: %.2f", c.MaxGrossNotionalMultiple)
+	}
+	if c.BTCBearishAltLongMinConfidence < 0 {
+		return fmt.Errorf("btc_bearish_alt_long_min_confidence 不能为负数: %d", c.BTCBearishAltLongMinConfidence)
+	}
+	if c.RSIExtremeLow < 0 || c.RSIExtremeHigh < 0 || c.RSIExtremeLow > 100 || c.RSIExtremeHigh > 100 {
+		return fmt.Errorf("RSI极端阈值必须在0-100之间: low=%.1f high=%.1f", c.RSIExtremeLow, c.RSIExtremeHigh)
+	}
+	if low, high := c.rsiExtremes(); low >= high {
+		return fmt.Errorf("rsi_extreme_low(%.1f) 必须小于 rsi_extreme_high(%.1f)", low, high)
+	}
+	if c.CounterTrendMinConfidence < 0 || c.CounterTrendMinConfidence > 100 {
+		return fmt.Errorf("counter_trend_min_confidence 必须在0-100之间: %d", c.CounterTrendMinConfidence)
+	}
+	if c.MinConfirmingIndicators < 0 || c.MinConfirmingIndicators > 3 {
+		return fmt.Errorf("min_confirming_indicators 必须在0-3之间: %d", c.MinConfirmingIndicators)
+	}
+	if c.MaxNewOpensPerCycle < 0 {
+		return fmt.Errorf("max_new_opens_per_cycle 不能为负数: %d", c.MaxNewOpensPerCycle)
+	}
+	if c.RiskUSDTolerancePct < 0 {
+		return fmt.Errorf("risk_usd_tolerance_pct 不能为负数: %.2f", c.RiskUSDTolerancePct)
+	}
+	if c.MinSecondsBetweenOpens < 0 {
+		return fmt.Errorf("min_seconds_between_opens 不能为负数: %d", c.MinSecondsBetweenOpens)
+	}
+	if c.MaxSameDirectionPositions < 0 {
+		return fmt.Errorf("max_same_direction_positions 不能为负数: %d", c.MaxSameDirectionPositions)
+	}
+	if c.MaxPortfolioHeatPct < 0 {
+		return fmt.Errorf("max_portfolio_heat_pct 不能为负数: %.2f", c.MaxPortfolioHeatPct)
+	}
+	if c.MinRiskReward < 0 || (c.MinRiskReward > 0 && c.MinRiskReward < 1) {
+		return fmt.Errorf("min_risk_reward 必须≥1（0表示使用默认值2）: %.2f", c.MinRiskReward)
+	}
+	if c.ReducedRegimeMaxLeverage < 0 || c.ReducedRegimeMaxLeverage > maxLeverageLimit {
+		return fmt.Errorf("reduced_regime_max_leverage 必须在0-%d之间: %d", maxLeverageLimit, c.ReducedRegimeMaxLeverage)
+	}
+	if err := c.sharpeThresholds().validate(); err != nil {
+		return err
+	}
+	if c.BigWinPnLPct < 0 || c.BigWinCooldownMinutes < 0 {
+		return fmt.Errorf("big_win_pnl_pct / big_win_cooldown_minutes 不能为负数")
+	}
+	if c.BigWinSizeFactor < 0 || c.BigWinSizeFactor > 1 {
+		return fmt.Errorf("big_win_size_factor 必须在0-1之间: %.2f", c.BigWinSizeFactor)
+	}
+	switch c.OITrendPolicy {
+	case "", oiTrendPenalize, oiTrendReject:
+	default:
+		return fmt.Errorf("oi_trend_policy 无效: %q（可选: %s, %s）", c.OITrendPolicy, oiTrendPenalize, oiTrendReject)
+	}
+	if c.OITrendPenalty < 0 || c.OITrendPenalty > 100 {
+		return fmt.Errorf("oi_trend_penalty 必须在0-100之间: %d", c.OITrendPenalty)
+	}
+	if c.MinTargetCandleMultiple < 0 {
+		return fmt.Errorf("min_target_candle_multiple 不能为负数: %.2f", c.MinTargetCandleMultiple)
+	}
+	if c.MinStopSlippageMultiple < 0 {
+		return fmt.Errorf("min_stop_slippage_multiple 不能为负数: %.2f", c.MinStopSlippageMultiple)
+	}
+	for symbol, minNotional := range c.SymbolMinNotional {
+		if minNotional < 0 {
+			return fmt.Errorf("symbol_min_notional[%s] 不能为负数: %.2f", symbol, minNotional)
+		}
+	}
+	for symbol, slippagePct := range c.SymbolSlippagePct {
+		if slippagePct < 0 {
+			return fmt.Errorf("symbol_slippage_pct[%s] 不能为负数: %.4f", symbol, slippagePct)
+		}
+	}
+	for symbol, leverageCap := range c.SymbolLeverageCaps {
+		if symbol == "" {
+			return fmt.Errorf("symbol_leverage_caps 中存在空币种名")
+		}
+		if leverageCap < 1 || leverageCap > maxLeverageLimit {
+			return fmt.Errorf("symbol_leverage_caps[%s] 必须在1-%d之间: %d", symbol, maxLeverageLimit, leverageCap)
+		}
+	}
+	for _, tier := range c.LeverageConfidenceTiers {
+		if tier.AboveLeverage < 1 || tier.AboveLeverage > maxLeverageLimit {
+			return fmt.Errorf("leverage_confidence_tiers 的 above_leverage 必须在1-%d之间: %d", maxLeverageLimit, tier.AboveLeverage)
+		}
+		if tier.MinConfidence < 0 || tier.MinConfidence > 100 {
+			return fmt.Errorf("leverage_confidence_tiers 的 min_confidence 必须在0-100之间: %d", tier.MinConfidence)
+		}
+	}
+	for symbol, tiers := range c.SymbolLeverageTiers {
+		if len(tiers) == 0 {
+			return fmt.Errorf("symbol_leverage_tiers[%s] 不能为空", symbol)
+		}
+		for _, tier := range tiers {
+			if tier < 1 || tier > maxLeverageLimit {
+				return fmt.Errorf("symbol_leverage_tiers[%s] 的档位必须在1-%d之间: %d", symbol, maxLeverageLimit, tier)
+			}
+		}
+	}
+	return nil
 }
 
 // Decision AI的交易决策
 type Decision struct {
 	Symbol          string  `json:"symbol"`
-	Action          string  `json:"action"` // "open_long", "open_short", "close_long", "close_short", "hold", "wait"
+	Account         string  `json:"account,omitempty"` // 目标子账户ID（多账户模式下开仓必填）
+	Action          Action  `json:"action"`            // "open_long", "open_short", "close_long", "close_short", "scale_in", "reduce_all", "hold", "wait", "note", "request_data"
 	Leverage        int     `json:"leverage,omitempty"`
 	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
+	PositionSizePct float64 `json:"position_size_pct,omitempty"` // 仓位大小（占账户净值的百分比，可替代 position_size_usd）
 	StopLoss        float64 `json:"stop_loss,omitempty"`
 	TakeProfit      float64 `json:"take_profit,omitempty"`
-	Confidence      int     `json:"confidence,omitempty"` // 信心度 (0-100)
-	RiskUSD         float64 `json:"risk_usd,omitempty"`   // 最大美元风险
+	EntryPrice      float64 `json:"entry_price,omitempty"`   // 预期入场价（可选，未提供时按当前市价计算风险回报比）
+	Confidence      int     `json:"confidence,omitempty"`    // 信心度 (0-100)
+	RiskUSD         float64 `json:"risk_usd,omitempty"`      // 最大美元风险
+	Timeframe       string  `json:"timeframe,omitempty"`     // request_data: 请求的K线周期（如 "1m", "15m", "1h"）
+	Lookback        int     `json:"lookback,omitempty"`      // request_data: 请求的K线数量
+	ReduceByPct     float64 `json:"reduce_by_pct,omitempty"` // reduce_all: 所有持仓的减仓比例 (0-100]
+	NewStopLoss     float64 `json:"new_stop_loss,omitempty"` // hold: 调整现有持仓的止损价（只能收紧）
+	Strategy        string  `json:"strategy,omitempty"`      // 可选的策略标签（如 scalp/swing），供下游按策略路由到不同的执行逻辑
 	Reasoning       string  `json:"reasoning"`
+
+	// 验证时被自动调整前的原始值（用于审计，未调整时为0）
+	OriginalLeverage int     `json:"original_leverage,omitempty"`
+	OriginalSizeUSD  float64 `json:"original_size_usd,omitempty"`
+}
+
+// clampLeverage 调整杠杆并保留模型给出的原始值（多次调整时只记录第一次的原始值）
+func (d *Decision) clampLeverage(leverage int) {
+	if d.OriginalLeverage == 0 {
+		d.OriginalLeverage = d.Leverage
+	}
+	d.Leverage = leverage
+}
+
+// clampSize 调整仓位大小并保留模型给出的原始值（多次调整时只记录第一次的原始值）
+func (d *Decision) clampSize(sizeUSD float64) {
+	if d.OriginalSizeUSD == 0 {
+		d.OriginalSizeUSD = d.PositionSizeUSD
+	}
+	d.PositionSizeUSD = sizeUSD
 }
 
 // FullDecision AI的完整决策（包含思维链）
@@ -88,40 +500,236 @@ type FullDecision struct {
 	CoTTrace   string     `json:"cot_trace"`   // 思维链分析（AI输出）
 	Decisions  []Decision `json:"decisions"`   // 具体决策列表
 	Timestamp  time.Time  `json:"timestamp"`
+
+	IntegrityWarnings []string `json:"integrity_warnings,omitempty"`  // 决策与当前持仓不一致的警告
+	CoTMissing        bool     `json:"cot_missing,omitempty"`         // AI只输出了JSON，没有给出思维链分析（可据此重新请求）
+	OutputTokens      int      `json:"output_tokens,omitempty"`       // AI输出的估算token数
+	OutputTokenBudget int      `json:"output_token_budget,omitempty"` // 本次的输出token预算（0表示不限制）
+	EmptyResponse     bool     `json:"empty_response,omitempty"`      // AI返回了空决策数组（开启 EmptyArrayAsWait 时已补充为 wait）
+	SharpeFiltered    int      `json:"sharpe_filtered,omitempty"`     // 夏普比率处于停用区间时被转为wait的开仓/加仓决策数量
+	MinHoldOverridden int      `json:"min_hold_overridden,omitempty"` // 未满最小持仓时间被转为hold的平仓决策数量
+
+	DryRun            bool                 `json:"dry_run,omitempty"`            // 影子模式生成的决策（未经过滤，不应执行）
+	ValidationResults []DecisionValidation `json:"validation_results,omitempty"` // 影子模式下每个决策的验证结果
+
+	Regime            string            `json:"regime,omitempty"`             // 本周期生效的风控状态（见 DetermineRegime）
+	RegimeConstraints map[string]string `json:"regime_constraints,omitempty"` // 风控状态对应的具体约束
+
+	RiskConfig RiskConfig `json:"risk_config"` // 本周期实际生效的风控参数快照（用于事后审计决策通过/被拒的原因）
 }
 
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
 func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
-	// 1. 为所有币种获取市场数据
-	if err := fetchMarketDataForContext(ctx); err != nil {
-		return nil, fmt.Errorf("获取市场数据失败: %w", err)
+	decision, err := getFullDecision(ctx, mcpClient)
+	if err != nil {
+		if ctx.OnError != nil {
+			ctx.OnError(err)
+		}
+		return nil, err
 	}
+	if ctx.OnDecision != nil {
+		ctx.OnDecision(decision)
+	}
+	return decision, nil
+}
 
-	// 2. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
-	systemPrompt := buildSystemPrompt(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, ctx.ScanIntervalMinutes)
-	userPrompt := buildUserPrompt(ctx)
-
-	// 3. 调用AI API（使用 system + user prompt）
-	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+// getFullDecision GetFullDecision 的实现（不含回调）
+func getFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
+	// 0-2. 检查配置、获取市场数据、构建 System Prompt（固定规则）和 User Prompt（动态数据）
+	systemPrompt, userPrompt, err := preparePrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
 	if err != nil {
-		return nil, fmt.Errorf("调用AI API失败: %w", err)
+		return nil, err
 	}
 
-	// 4. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
-	if err != nil {
-		return nil, fmt.Errorf("解析AI响应失败: %w", err)
+	// 既无持仓也无候选币种数据时没有可决策的对象，模型只会凭空编造，直接返回观望
+	if len(ctx.Positions) == 0 && len(ctx.MarketDataMap) == 0 && !ctx.CallAIWhenEmpty {
+		log.Printf("ℹ️  无持仓且无候选币种，跳过AI调用")
+		return emptyContextDecision(ctx, userPrompt), nil
+	}
+
+	// 超长prompt会被AI服务商以难以理解的错误拒绝，提前给出明确错误
+	if ctx.MaxPromptChars > 0 {
+		systemChars, userChars := utf8.RuneCountInString(systemPrompt), utf8.RuneCountInString(userPrompt)
+		if systemChars+userChars > ctx.MaxPromptChars {
+			return nil, fmt.Errorf("prompt过长: %d 字符（system %d + user %d），超过上限 %d，请减少候选币种或开启精简模式",
+				systemChars+userChars, systemChars, userChars, ctx.MaxPromptChars)
+		}
+	}
+
+	// 3-4. 调用AI API（使用 system + user prompt）并解析响应
+	// 验证失败时按配置附带拒绝原因重新提示模型
+	var aiResponse string
+	var decision *FullDecision
+	prompt := userPrompt
+	for attempt := 0; ; attempt++ {
+		aiResponse, err = mcpClient.CallWithMessages(systemPrompt, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("调用AI API失败: %w", err)
+		}
+
+		decision, err = parseFullDecisionResponse(aiResponse, ctx)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrDecisionValidation) || attempt >= ctx.ValidationRetries {
+			return nil, fmt.Errorf("解析AI响应失败: %w", err)
+		}
+		log.Printf("⚠️  重新提示模型（第 %d/%d 次）: %v", attempt+1, ctx.ValidationRetries, strings.SplitN(err.Error(), "\n\n", 2)[0])
+		prompt = userPrompt + "\n" + rejectionFeedback(err, ctx.ExplainRejections)
+	}
+
+	// 记录输出长度与预算，超出时记录日志便于调整
+	decision.OutputTokens = estimateTokens(aiResponse)
+	decision.OutputTokenBudget = ctx.MaxOutputTokens
+	if ctx.MaxOutputTokens > 0 && decision.OutputTokens > ctx.MaxOutputTokens {
+		log.Printf("⚠️  AI输出约 %d tokens，超出预算 %d（+%d）", decision.OutputTokens, ctx.MaxOutputTokens, decision.OutputTokens-ctx.MaxOutputTokens)
 	}
 
+	// 5. 检查决策与真实持仓的一致性（仅警告，不拦截）
+	decision.IntegrityWarnings = ReconcileWithPositions(decision.Decisions, ctx.Positions)
+	for _, warning := range decision.IntegrityWarnings {
+		log.Printf("⚠️  决策一致性警告: %s", warning)
+	}
+
+	// 6. 记录本周期生效的风控状态
+	decision.Regime, decision.RegimeConstraints = DetermineRegime(ctx, ctx.RiskConfig)
+	log.Printf("🧭 风控状态: %s | %s", decision.Regime, formatRegimeConstraints(decision.RegimeConstraints))
+
 	decision.Timestamp = time.Now()
 	decision.UserPrompt = userPrompt // 保存输入prompt
 	return decision, nil
 }
 
+// emptyContextDecision 无持仓且无候选币种时的空操作决策（全部观望，不调用AI）
+func emptyContextDecision(ctx *Context, userPrompt string) *FullDecision {
+	reason := "无持仓且无候选币种，本周期无需决策"
+	return &FullDecision{
+		UserPrompt: userPrompt,
+		CoTTrace:   reason + "（未调用AI）",
+		Decisions:  []Decision{{Action: ActionWait, Reasoning: reason}},
+		Timestamp:  time.Now(),
+		RiskConfig: ctx.RiskConfig.clone(),
+	}
+}
+
+// PreviewPrompts 获取市场数据并构建 system/user prompt，但不调用AI（用于零成本检查模型将看到的数据）
+// 在上下文的浅拷贝上执行，不改写调用方的 MarketDataMap、CandidateCoins 等字段
+func PreviewPrompts(ctx *Context, btcEthLev, altLev int) (system, user string, err error) {
+	preview := *ctx
+	return preparePrompts(&preview, btcEthLev, altLev)
+}
+
+// preparePrompts 检查配置、获取市场数据并构建 system/user prompt
+func preparePrompts(ctx *Context, btcEthLev, altLev int) (system, user string, err error) {
+	// 检查风控配置和段落顺序（配置错误时不调用AI）
+	if err := ctx.RiskConfig.Validate(); err != nil {
+		return "", "", fmt.Errorf("风控配置无效: %w", err)
+	}
+	if len(ctx.SectionOrder) > 0 {
+		if err := validateSectionOrder(ctx.SectionOrder); err != nil {
+			return "", "", err
+		}
+	}
+	if err := validateIncompleteDataPolicy(ctx.IncompleteDataPolicy); err != nil {
+		return "", "", err
+	}
+
+	// 为所有币种获取市场数据
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		return "", "", fmt.Errorf("获取市场数据失败: %w", err)
+	}
+
+	system = buildSystemPrompt(ctx.systemPromptOptions(btcEthLev, altLev))
+	user = buildUserPrompt(ctx)
+	return system, user, nil
+}
+
+// dedupeCandidates 合并重复的候选币种（保留首次出现的位置，合并来源）
+func dedupeCandidates(candidates []CandidateCoin) []CandidateCoin {
+	index := make(map[string]int, len(candidates))
+	result := make([]CandidateCoin, 0, len(candidates))
+	for _, coin := range candidates {
+		i, seen := index[coin.Symbol]
+		if !seen {
+			index[coin.Symbol] = len(result)
+			coin.Sources = append([]string(nil), coin.Sources...)
+			result = append(result, coin)
+			continue
+		}
+		for _, source := range coin.Sources {
+			if !containsString(result[i].Sources, source) {
+				result[i].Sources = append(result[i].Sources, source)
+			}
+		}
+	}
+	return result
+}
+
+// validateStrategy 验证策略标签在允许的名单内（未配置名单时接受任意标签），并统一为名单中的写法
+func validateStrategy(d *Decision, ctx *Context) error {
+	strategy := strings.TrimSpace(d.Strategy)
+	if len(ctx.AllowedStrategies) == 0 {
+		d.Strategy = strategy
+		return nil
+	}
+	for _, allowed := range ctx.AllowedStrategies {
+		if strings.EqualFold(strategy, allowed) {
+			d.Strategy = allowed
+			return nil
+		}
+	}
+	return fmt.Errorf("%s 的策略标签 %q 不在允许的名单中（可用: %s）", d.Symbol, d.Strategy, strings.Join(ctx.AllowedStrategies, ", "))
+}
+
+// isSymbolAllowed 判断币种是否在交易白名单中（未配置白名单时全部允许）
+func (ctx *Context) isSymbolAllowed(symbol string) bool {
+	return len(ctx.AllowedSymbols) == 0 || containsString(ctx.AllowedSymbols, symbol)
+}
+
+// filterAllowedCandidates 去掉不在交易白名单中的候选币种
+func filterAllowedCandidates(candidates []CandidateCoin, ctx *Context) []CandidateCoin {
+	if len(ctx.AllowedSymbols) == 0 {
+		return candidates
+	}
+	result := candidates[:0]
+	for _, coin := range candidates {
+		if ctx.isSymbolAllowed(coin.Symbol) {
+			result = append(result, coin)
+		} else {
+			log.Printf("⚠️  %s 不在交易白名单中，跳过此候选币种", coin.Symbol)
+		}
+	}
+	return result
+}
+
+// isCandidate 判断币种是否已在候选列表中
+func isCandidate(candidates []CandidateCoin, symbol string) bool {
+	for _, coin := range candidates {
+		if coin.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
 // fetchMarketDataForContext 为上下文中的所有币种获取市场数据和OI数据
 func fetchMarketDataForContext(ctx *Context) error {
+	// 注入的合成币种数据视为权威数据，不再通过数据源获取
+	// （MarketDataMap 每次调用都重新获取，同一 Context 多次调用时不会沿用上一次的价格）
+	injected := ctx.SyntheticMarketData
 	ctx.MarketDataMap = make(map[string]*market.Data)
 	ctx.OITopDataMap = make(map[string]*OITopData)
+	ctx.IncompleteData = nil
 
 	// 收集所有需要获取数据的币种
 	symbolSet := make(map[string]bool)
@@ -131,7 +739,10 @@ func fetchMarketDataForContext(ctx *Context) error {
 		symbolSet[pos.Symbol] = true
 	}
 
-	// 2. 候选币种数量根据账户状态动态调整
+	// 2. 候选币种数量根据账户状态动态调整（截断前先去重、按历史胜率重排）
+	ctx.CandidateCoins = filterAllowedCandidates(dedupeCandidates(ctx.CandidateCoins), ctx)
+	applyWinRateRanking(ctx)
+	applyCandidateJitter(ctx)
 	maxCandidates := calculateMaxCandidates(ctx)
 	for i, coin := range ctx.CandidateCoins {
 		if i >= maxCandidates {
@@ -140,6 +751,27 @@ func fetchMarketDataForContext(ctx *Context) error {
 		symbolSet[coin.Symbol] = true
 	}
 
+	// 3. 注入的合成币种参与分析（不受候选数量截断影响），并补充到候选列表；
+	// 持仓已满且开启 OnlyManagePositionsWhenFull 时与普通候选币种一样跳过（已持仓的除外）
+	injectedSymbols := make([]string, 0, len(injected))
+	for symbol := range injected {
+		injectedSymbols = append(injectedSymbols, symbol)
+	}
+	sort.Strings(injectedSymbols) // 保证合成币种在prompt中的顺序稳定
+	for _, symbol := range injectedSymbols {
+		if !symbolSet[symbol] && ctx.skipCandidates() {
+			continue
+		}
+		if !symbolSet[symbol] && !ctx.BypassSyntheticFilters && !ctx.isSymbolAllowed(symbol) {
+			log.Printf("⚠️  %s 不在交易白名单中，跳过此合成币种", symbol)
+			continue
+		}
+		if !symbolSet[symbol] && !isCandidate(ctx.CandidateCoins, symbol) {
+			ctx.CandidateCoins = append(ctx.CandidateCoins, CandidateCoin{Symbol: symbol, Sources: []string{"synthetic"}})
+		}
+		symbolSet[symbol] = true
+	}
+
 	// 并发获取市场数据
 	// 持仓币种集合（用于判断是否跳过OI检查）
 	positionSymbols := make(map[string]bool)
@@ -147,14 +779,34 @@ func fetchMarketDataForContext(ctx *Context) error {
 		positionSymbols[pos.Symbol] = true
 	}
 
+	provider := ctx.marketDataProvider()
+	fetchedCount := 0
+	var lastFetchErr error
 	for symbol := range symbolSet {
-		data, err := market.Get(symbol)
-		if err != nil {
-			// 单个币种失败不影响整体，只记录错误
-			continue
+		data, isInjected := injected[symbol]
+		if isInjected {
+			fetchedCount++
+			if ctx.BypassSyntheticFilters {
+				ctx.MarketDataMap[symbol] = data
+				continue
+			}
+		} else {
+			// 持仓币种走更严格的数据路径（独立数据源/时效要求），避免基于过期价格平仓
+			var err error
+			if positionSymbols[symbol] {
+				data, err = ctx.fetchPositionMarketData(symbol)
+			} else {
+				data, err = provider.Get(symbol)
+			}
+			if err != nil {
+				// 单个币种失败不影响整体，只记录错误
+				lastFetchErr = err
+				continue
+			}
+			fetchedCount++
 		}
 
-		// ⚠️ 流动性过滤：持仓价值低于15M USD的币种不做（多空都不做）
+		// ⚠️ 流动性过滤：持仓价值低于下限（默认15M USD）的币种不做（多空都不做）
 		// 持仓价值 = 持仓量 × 当前价格
 		// 但现有持仓必须保留（需要决策是否平仓）
 		isExistingPosition := positionSymbols[symbol]
@@ -162,19 +814,49 @@ func fetchMarketDataForContext(ctx *Context) error {
 			// 计算持仓价值（USD）= 持仓量 × 当前价格
 			oiValue := data.OpenInterest.Latest * data.CurrentPrice
 			oiValueInMillions := oiValue / 1_000_000 // 转换为百万美元单位
-			if oiValueInMillions < 15 {
-				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
-					symbol, oiValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
+			if minOIValue := ctx.minOIValueUSD(); oiValue < minOIValue {
+				log.Printf("⚠️  %s 持仓价值过低(%.2fM USD < %.2fM)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
+					symbol, oiValueInMillions, minOIValue/1_000_000, data.OpenInterest.Latest, data.CurrentPrice)
 				continue
 			}
 		}
 
+		// ⚠️ 波动过滤：4小时几乎不动的币种不值得占用prompt篇幅（现有持仓除外）
+		if !isExistingPosition && ctx.MinAbs4hChangePct > 0 && math.Abs(data.PriceChange4h) < ctx.MinAbs4hChangePct {
+			log.Printf("⚠️  %s 4小时波动过小(%.2f%% < %.2f%%)，跳过此币种", symbol, data.PriceChange4h, ctx.MinAbs4hChangePct)
+			continue
+		}
+
+		// ⚠️ 完整性过滤：缺少必需指标的候选币种在prompt中只会显示空白，按配置跳过或标注（现有持仓除外）
+		if !isExistingPosition && ctx.IncompleteDataPolicy != IncompleteDataIgnore {
+			if missing := missingIndicators(data); len(missing) > 0 {
+				if ctx.IncompleteDataPolicy == IncompleteDataSkip {
+					log.Printf("⚠️  %s 市场数据不完整(缺少 %s)，跳过此币种", symbol, strings.Join(missing, ", "))
+					continue
+				}
+				if ctx.IncompleteData == nil {
+					ctx.IncompleteData = make(map[string][]string)
+				}
+				ctx.IncompleteData[symbol] = missing
+			}
+		}
+
 		ctx.MarketDataMap[symbol] = data
 	}
 
-	// 加载OI Top数据（不影响主流程）
-	oiPositions, err := pool.GetOITopPositions()
-	if err == nil {
+	// 全部获取失败（数据源故障）时不能让AI盲目交易，直接跳过本周期
+	if len(symbolSet) > 0 && fetchedCount == 0 {
+		return fmt.Errorf("所有币种(%d个)的市场数据均获取失败，跳过本周期: %v", len(symbolSet), lastFetchErr)
+	}
+
+	// 加载OI Top数据（默认不影响主流程，RequireOITop 时为必需数据）
+	oiPositions, err := poolOITopPositions()
+	if err != nil {
+		if ctx.RequireOITop {
+			return fmt.Errorf("加载OI Top数据失败: %w", err)
+		}
+		log.Printf("⚠️  加载OI Top数据失败（继续决策，但缺少OI信号）: %v", err)
+	} else {
 		for _, pos := range oiPositions {
 			// 标准化符号匹配
 			symbol := pos.Symbol
@@ -192,16 +874,94 @@ func fetchMarketDataForContext(ctx *Context) error {
 	return nil
 }
 
+// defaultMinOIValueUSD 候选币种默认的持仓价值下限（USD）
+const defaultMinOIValueUSD = 15_000_000
+
+// minOIValueUSD 返回候选币种的持仓价值下限（未配置时默认15M USD）
+func (ctx *Context) minOIValueUSD() float64 {
+	if ctx.MinOIValueUSD <= 0 {
+		return defaultMinOIValueUSD
+	}
+	return ctx.MinOIValueUSD
+}
+
+// calculateAvailableSlots 计算本周期还能开多少个新仓位以及可用保证金
+// 可开仓数 = 最大持仓数 - 当前持仓数 - 待成交开仓数（不超过单周期开仓上限）
+// 可用保证金 = min(可用余额, 保证金使用率上限(默认80%) - 已用保证金)
+func calculateAvailableSlots(ctx *Context) (slots int, freeMargin float64) {
+	slots = ctx.RiskConfig.maxPositions() - len(ctx.Positions) - ctx.PendingOpens
+	if slots < 0 {
+		slots = 0
+	}
+	// 单周期开仓数量上限
+	if perCycle := ctx.RiskConfig.maxNewOpensPerCycle(); slots > perCycle {
+		slots = perCycle
+	}
+
+	freeMargin = ctx.Account.TotalEquity*ctx.RiskConfig.marginCapPct()/100 - ctx.Account.MarginUsed
+	if ctx.Account.AvailableBalance < freeMargin {
+		freeMargin = ctx.Account.AvailableBalance
+	}
+	if freeMargin <= 0 {
+		// 没有可用保证金时，即使有空位也不能开仓
+		return 0, 0
+	}
+
+	return slots, freeMargin
+}
+
+// skipCandidates 持仓已满且开启 OnlyManagePositionsWhenFull 时跳过候选币种
+func (ctx *Context) skipCandidates() bool {
+	return ctx.OnlyManagePositionsWhenFull && len(ctx.Positions) >= ctx.RiskConfig.maxPositions()
+}
+
 // calculateMaxCandidates 根据账户状态计算需要分析的候选币种数量
 func calculateMaxCandidates(ctx *Context) int {
+	// 持仓已满且配置了只管理持仓时，不再分析候选币种
+	if ctx.skipCandidates() {
+		return 0
+	}
+
 	// 直接返回候选池的全部币种数量
 	// 因为候选池已经在 auto_trader.go 中筛选过了
 	// 固定分析前20个评分最高的币种（来自AI500）
 	return len(ctx.CandidateCoins)
 }
 
+// systemPromptOptions 构建 System Prompt 所需的参数（由 Context 和风控配置汇总，见 Context.systemPromptOptions）
+type systemPromptOptions struct {
+	AccountEquity       float64
+	BTCETHLeverage      int
+	AltcoinLeverage     int
+	ScanIntervalMinutes int
+	PlainText           bool
+	Sharpe              SharpeThresholds
+	MinRiskReward       float64
+	MarginCapPct        float64
+	MaxPositions        int
+	MaxOutputTokens     int
+	MinHoldMinutes      int // 最小持仓时间（分钟），与 enforceMinHold 使用的配置一致
+}
+
+// systemPromptOptions 汇总本周期构建 System Prompt 的参数
+func (ctx *Context) systemPromptOptions(btcEthLev, altLev int) systemPromptOptions {
+	return systemPromptOptions{
+		AccountEquity:       ctx.Account.TotalEquity,
+		BTCETHLeverage:      btcEthLev,
+		AltcoinLeverage:     altLev,
+		ScanIntervalMinutes: ctx.ScanIntervalMinutes,
+		PlainText:           ctx.PlainText,
+		Sharpe:              ctx.RiskConfig.sharpeThresholds(),
+		MinRiskReward:       ctx.RiskConfig.minRiskReward(),
+		MarginCapPct:        ctx.RiskConfig.marginCapPct(),
+		MaxPositions:        ctx.RiskConfig.maxPositions(),
+		MaxOutputTokens:     ctx.MaxOutputTokens,
+		MinHoldMinutes:      ctx.minHoldMinutes(),
+	}
+}
+
 // buildSystemPrompt 构建 System Prompt（固定规则，可缓存）
-func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, scanIntervalMinutes int) string {
+func buildSystemPrompt(opts systemPromptOptions) string {
 	var sb strings.Builder
 
 	// === 合规声明（针对中国模型）===
@@ -226,7 +986,7 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("你是一个**自主加密货币交易智能体**，在实盘市场中进行系统化交易。\n\n")
 	sb.WriteString("**你的身份**: AI Trading Agent (Autonomous)\n")
 	sb.WriteString("**你的使命**: 通过系统化、纪律性的交易，最大化风险调整后收益（夏普比率）\n")
-	sb.WriteString(fmt.Sprintf("**你的环境**: 7×24小时永续合约市场，每%d分钟决策一次\n\n", scanIntervalMinutes))
+	sb.WriteString(fmt.Sprintf("**你的环境**: 7×24小时永续合约市场，每%d分钟决策一次\n\n", opts.ScanIntervalMinutes))
 	sb.WriteString("---\n\n")
 
 	// === 核心目标（风险优先） ===
@@ -251,15 +1011,15 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("3. **confidence** (信心度 0-100): 基于专业判断诚实评估（可参考下方评分框架，但允许灵活调整）\n")
 	sb.WriteString("4. **risk_usd** (风险金额): |入场价 - 止损价| × 仓位数量\n\n")
 	sb.WriteString("**硬性约束**:\n")
-	sb.WriteString(fmt.Sprintf("- **风险回报比**: 必须 ≥ 1:2（冒1%%风险，赚2%%+收益）\n"))
-	sb.WriteString("- **最多持仓**: 3个币种（质量>数量）\n")
+	sb.WriteString(fmt.Sprintf("- **风险回报比**: 必须 ≥ 1:%s（冒1%%风险，赚%s%%+收益）\n", formatRatio(opts.MinRiskReward), formatRatio(opts.MinRiskReward)))
+	sb.WriteString(fmt.Sprintf("- **最多持仓**: %d个币种（质量>数量）\n", opts.MaxPositions))
 	sb.WriteString(fmt.Sprintf("- **单币仓位**: 山寨币 %.0f-%.0f USDT | BTC/ETH %.0f-%.0f USDT\n",
-		accountEquity*0.8, accountEquity*1.5, accountEquity*5, accountEquity*10))
-	sb.WriteString("- **保证金使用率**: ≤ 80%（避免强平风险）\n")
+		opts.AccountEquity*0.8, opts.AccountEquity*1.5, opts.AccountEquity*5, opts.AccountEquity*10))
+	sb.WriteString(fmt.Sprintf("- **保证金使用率**: ≤ %.0f%%（避免强平风险）\n", opts.MarginCapPct))
 	sb.WriteString("- **强平价距离**: 确保强平价距离入场价 >15%\n\n")
 	sb.WriteString("**⚠️ 杠杆限制（HyperLiquid 平台规则，严格遵守）**:\n")
-	sb.WriteString(fmt.Sprintf("- **BTC/ETH**: 最大杠杆 %dx（整数，例如：1, 2, 3, ..., %d）\n", btcEthLeverage, btcEthLeverage))
-	sb.WriteString(fmt.Sprintf("- **所有其他币种**（SOL, HYPE, BNB, XRP, DOGE, ZEC, ASTER 等）: 最大杠杆 %dx（整数，例如：1, 2, 3, 4, 5）\n", altcoinLeverage))
+	sb.WriteString(fmt.Sprintf("- **BTC/ETH**: 最大杠杆 %dx（整数，例如：1, 2, 3, ..., %d）\n", opts.BTCETHLeverage, opts.BTCETHLeverage))
+	sb.WriteString(fmt.Sprintf("- **所有其他币种**（SOL, HYPE, BNB, XRP, DOGE, ZEC, ASTER 等）: 最大杠杆 %dx（整数，例如：1, 2, 3, 4, 5）\n", opts.AltcoinLeverage))
 	sb.WriteString("- **禁止使用小数杠杆**（例如：2.5x, 3.7x 是无效的）\n")
 	sb.WriteString("- **超出限制的杠杆会导致交易失败**\n\n")
 	sb.WriteString("---\n\n")
@@ -347,16 +1107,19 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("  - 例如：ATR = 100，止损距离 = 150\n")
 	sb.WriteString("  - 做多：入场价 - 150 = 止损价\n")
 	sb.WriteString("  - 做空：入场价 + 150 = 止损价\n\n")
-	sb.WriteString("**止盈距离**: `3.0 × ATR`（保证风险回报比 ≥ 2:1）\n")
-	sb.WriteString("  - 例如：ATR = 100，止盈距离 = 300\n")
-	sb.WriteString("  - 做多：入场价 + 300 = 止盈价\n")
-	sb.WriteString("  - 做空：入场价 - 300 = 止盈价\n\n")
-	sb.WriteString("**风险回报比**: (止盈距离) / (止损距离) = 3.0 / 1.5 = 2:1 ✅\n\n")
+	minRR := formatRatio(opts.MinRiskReward)
+	takeProfitATR := formatRatio(1.5 * opts.MinRiskReward)
+	takeProfitDistance := formatRatio(150 * opts.MinRiskReward)
+	sb.WriteString(fmt.Sprintf("**止盈距离**: 至少 `%s × ATR`（保证风险回报比 ≥ %s:1）\n", takeProfitATR, minRR))
+	sb.WriteString(fmt.Sprintf("  - 例如：ATR = 100，止盈距离 = %s\n", takeProfitDistance))
+	sb.WriteString(fmt.Sprintf("  - 做多：入场价 + %s = 止盈价\n", takeProfitDistance))
+	sb.WriteString(fmt.Sprintf("  - 做空：入场价 - %s = 止盈价\n\n", takeProfitDistance))
+	sb.WriteString(fmt.Sprintf("**风险回报比**: (止盈距离) / (止损距离) = %s / 1.5 = %s:1 ✅\n\n", takeProfitATR, minRR))
 	sb.WriteString("## 高波动币种调整\n\n")
 	sb.WriteString("**对于高波动币种**（如 HYPE, ASTER）:\n")
 	sb.WriteString("  - 止损距离放宽至: `2.0 × ATR`（而非 1.5）\n")
-	sb.WriteString("  - 止盈距离保持: `3.0 × ATR`\n")
-	sb.WriteString("  - 风险回报比: 3.0 / 2.0 = 1.5:1（仍可接受）\n\n")
+	sb.WriteString(fmt.Sprintf("  - 止盈距离同步放宽至: 至少 `%s × ATR`\n", formatRatio(2*opts.MinRiskReward)))
+	sb.WriteString(fmt.Sprintf("  - 风险回报比仍须 ≥ %s:1（止损放宽不降低要求）\n\n", minRR))
 	sb.WriteString("## 移动止损（Trailing Stop）\n\n")
 	sb.WriteString("**当盈利达到 1.5 × ATR 时**:\n")
 	sb.WriteString("  - 将止损移至入场价（保本）\n")
@@ -447,7 +1210,7 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("   - R:R ≥ 1:5 = 20 分\n")
 	sb.WriteString("   - R:R ≥ 1:4 = 15 分\n")
 	sb.WriteString("   - R:R ≥ 1:3 = 10 分\n")
-	sb.WriteString("   - R:R < 1:2 = 0 分（禁止交易）\n\n")
+	sb.WriteString(fmt.Sprintf("   - R:R < 1:%s = 0 分（禁止交易）\n\n", formatRatio(opts.MinRiskReward)))
 	sb.WriteString("5. **市场环境 (0-20 分)**:\n")
 	sb.WriteString("   - BTC 趋势明确且与交易方向一致 = 20 分\n")
 	sb.WriteString("   - BTC 中性，币种独立走势 = 15 分\n")
@@ -472,21 +1235,21 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("# 🧬 PERFORMANCE FEEDBACK & ADAPTATION\n\n")
 	sb.WriteString("你将在每次调用时收到**夏普比率**作为绩效反馈。\n\n")
 	sb.WriteString("**根据夏普比率调整行为**:\n\n")
-	sb.WriteString("**夏普比率 < -0.5** (持续亏损):\n")
+	sb.WriteString(fmt.Sprintf("**夏普比率 < %.2g** (持续亏损):\n", opts.Sharpe.Halt))
 	sb.WriteString("  → 🛑 **暂停模式**: 停止开新仓至少18分钟（6个周期），仅管理现有持仓\n")
 	sb.WriteString("  → 🔍 **深度复盘**:\n")
 	sb.WriteString("     • 是否忽略了4小时主趋势？\n")
 	sb.WriteString("     • 是否使用了过高杠杆？\n")
 	sb.WriteString("     • 是否错过了做空机会（只做多）？\n")
 	sb.WriteString("     • 是否在震荡市场频繁交易？\n\n")
-	sb.WriteString("**夏普比率 -0.5 ~ 0** (轻微亏损):\n")
+	sb.WriteString(fmt.Sprintf("**夏普比率 %.2g ~ %.2g** (轻微亏损):\n", opts.Sharpe.Halt, opts.Sharpe.Caution))
 	sb.WriteString("  → ⚠️ **收缩模式**: 仅执行 confidence ≥ 85 的交易\n")
 	sb.WriteString("  → 仓位降低 20-30%\n")
 	sb.WriteString("  → 避免震荡币种，只做强趋势\n\n")
-	sb.WriteString("**夏普比率 0 ~ 0.7** (稳健正收益):\n")
+	sb.WriteString(fmt.Sprintf("**夏普比率 %.2g ~ %.2g** (稳健正收益):\n", opts.Sharpe.Caution, opts.Sharpe.Strong))
 	sb.WriteString("  → ✅ **保持节奏**: 继续当前策略\n")
 	sb.WriteString("  → 适度增加持仓时长（让利润奔跑）\n\n")
-	sb.WriteString("**夏普比率 > 0.7** (优异表现):\n")
+	sb.WriteString(fmt.Sprintf("**夏普比率 > %.2g** (优异表现):\n", opts.Sharpe.Strong))
 	sb.WriteString("  → 🚀 **扩张模式**: 可适当增加仓位至区间上限\n")
 	sb.WriteString("  → 但仍需严格遵守风控规则\n\n")
 	sb.WriteString("---\n\n")
@@ -502,7 +1265,7 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("3. **扫描新机会**（仅在有可用资金时）:\n")
 	sb.WriteString("   - 4小时趋势明确吗？\n")
 	sb.WriteString("   - 3分钟有强入场信号吗？\n")
-	sb.WriteString("   - 风险回报比 ≥ 1:2 吗？\n")
+	sb.WriteString(fmt.Sprintf("   - 风险回报比 ≥ 1:%s 吗？\n", formatRatio(opts.MinRiskReward)))
 	sb.WriteString("   - 信心度 ≥ 75 吗？\n")
 	sb.WriteString("4. **输出决策**: 思维链分析 + JSON决策数组\n\n")
 	sb.WriteString("**优先级**: 持仓管理 > 风险控制 > 寻找新机会\n\n")
@@ -513,25 +1276,36 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("# 📤 OUTPUT FORMAT SPECIFICATION\n\n")
 	sb.WriteString("**第一步: 思维链分析（纯文本，简洁）**\n\n")
 	sb.WriteString("用2-5句话说明你的核心思考过程。\n\n")
+	if opts.MaxOutputTokens > 0 {
+		sb.WriteString(fmt.Sprintf("**输出长度预算**: 思维链 + JSON 合计不超过约 %d tokens，超出预算的冗长分析只会浪费成本\n\n", opts.MaxOutputTokens))
+	}
 	sb.WriteString("**第二步: JSON决策数组（必须是有效的JSON）**\n\n")
 	sb.WriteString("```json\n")
 	sb.WriteString("[\n")
-	sb.WriteString(fmt.Sprintf("  {\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"position_size_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"4h下跌趋势+MACD死叉+RSI超买\"},\n", btcEthLeverage, accountEquity*5))
+	sb.WriteString(fmt.Sprintf("  {\"symbol\": \"BTCUSDT\", \"action\": \"open_short\", \"leverage\": %d, \"position_size_usd\": %.0f, \"stop_loss\": 97000, \"take_profit\": 91000, \"confidence\": 85, \"risk_usd\": 300, \"reasoning\": \"4h下跌趋势+MACD死叉+RSI超买\"},\n", opts.BTCETHLeverage, opts.AccountEquity*5))
 	sb.WriteString("  {\"symbol\": \"ETHUSDT\", \"action\": \"close_long\", \"reasoning\": \"触及止盈目标\"}\n")
 	sb.WriteString("]\n")
 	sb.WriteString("```\n\n")
 	sb.WriteString("**字段说明**:\n")
-	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | hold | wait\n")
+	sb.WriteString("- `action`: open_long | open_short | close_long | close_short | scale_in | reduce_all | hold | wait | note | request_data\n")
 	sb.WriteString("- `symbol`: 币种代码（如 BTCUSDT）\n")
-	sb.WriteString(fmt.Sprintf("- `leverage`: **整数**杠杆倍数（BTC/ETH: 1-%d，其他币种: 1-%d，**禁止小数如 2.5**）\n", btcEthLeverage, altcoinLeverage))
+	sb.WriteString(fmt.Sprintf("- `leverage`: **整数**杠杆倍数（BTC/ETH: 1-%d，其他币种: 1-%d，**禁止小数如 2.5**）\n", opts.BTCETHLeverage, opts.AltcoinLeverage))
 	sb.WriteString("- `position_size_usd`: 仓位大小（美元）\n")
+	sb.WriteString("- `position_size_pct`: 可选，仓位大小占账户净值的百分比（如 150 表示 1.5 倍净值），可替代 position_size_usd\n")
 	sb.WriteString("- `stop_loss`: 止损价格（必须合理）\n")
 	sb.WriteString("- `take_profit`: 止盈价格（必须合理）\n")
+	sb.WriteString("- `entry_price`: 可选，预期入场价（必须在止损和止盈之间），用于精确计算风险回报比；不填时按当前市价计算\n")
 	sb.WriteString("- `confidence`: 信心度（0-100，开仓建议 ≥ 75）\n")
 	sb.WriteString("- `risk_usd`: 风险金额（美元）\n")
+	sb.WriteString("- `strategy`: 可选，策略标签（如 scalp/swing），用于区分执行方式，可用标签见用户消息\n")
 	sb.WriteString("- `reasoning`: 决策理由（简洁，<200字）\n\n")
 	sb.WriteString("**开仓时必填**: leverage, position_size_usd, stop_loss, take_profit, confidence, risk_usd, reasoning\n")
-	sb.WriteString("**平仓/持有/等待时**: 只需 symbol, action, reasoning\n\n")
+	sb.WriteString("**平仓/持有/等待时**: 只需 symbol, action, reasoning\n")
+	sb.WriteString("**hold 调整止损（可选）**: 可附带 new_stop_loss 移动现有持仓的止损，只能向有利方向收紧（多仓上移、空仓下移），禁止放宽止损\n")
+	sb.WriteString("**scale_in（加仓）**: 按现有持仓方向和杠杆加仓，需 position_size_usd（新增部分）, stop_loss, take_profit（整个仓位的新止损止盈）, reasoning；加仓后总仓位不能超过单币种上限\n")
+	sb.WriteString("**reduce_all（整体降风险）**: 所有持仓按同一比例减仓，只需 action, reduce_by_pct（减仓百分比，0-100，如 50 表示每个持仓平掉一半）, reasoning，不需要 symbol\n")
+	sb.WriteString("**note（可选）**: 仅记录你对某币种的观点（不交易），只需 symbol, action, reasoning，用于事后复盘\n")
+	sb.WriteString("**request_data（可选）**: 需要更高精度或更长历史的数据才能决策时使用，需 symbol, action, timeframe（1m/3m/5m/15m/30m/1h/2h/4h/1d）, lookback（K线数量 ≤500）, reasoning，数据将在下个周期提供\n\n")
 	sb.WriteString("---\n\n")
 
 	// === 禁止事项清单（nof1.ai 范本）===
@@ -566,7 +1340,7 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("   - 不能凭感觉或\"直觉\"给出高 confidence\n")
 	sb.WriteString("   - 必须在 reasoning 中说明评分逻辑\n\n")
 	sb.WriteString("10. **❌ 频繁开平仓**\n")
-	sb.WriteString("    - 最小持仓时间 30 分钟（除非触发止损/止盈）\n")
+	sb.WriteString(fmt.Sprintf("    - 最小持仓时间 %d 分钟（除非触发止损/止盈）\n", opts.MinHoldMinutes))
 	sb.WriteString("    - 平仓后必须等待至少 1 个决策周期（冷静期）才能开新仓\n\n")
 	sb.WriteString("---\n\n")
 
@@ -581,7 +1355,7 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("- ❌ **移动止损**: 不要因为\"再等等\"而移动止损\n")
 	sb.WriteString("- ❌ **混淆时间框架**: 不要用3分钟信号对抗4小时趋势\n")
 	sb.WriteString("- ❌ **虚高的 Confidence**: 必须基于量化评分标准，不能凭感觉\n")
-	sb.WriteString("- ❌ **频繁开平仓**: 最小持仓时间 30 分钟（除非触发止损/止盈）\n")
+	sb.WriteString(fmt.Sprintf("- ❌ **频繁开平仓**: 最小持仓时间 %d 分钟（除非触发止损/止盈）\n", opts.MinHoldMinutes))
 	sb.WriteString("- ❌ **报复性交易**: 平仓后必须等待至少 1 个决策周期（冷静期）\n\n")
 	sb.WriteString("---\n\n")
 
@@ -589,16 +1363,16 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("# 🎯 FINAL INSTRUCTIONS\n\n")
 	sb.WriteString("**强制执行规则（违反将导致交易失败）**:\n\n")
 	sb.WriteString("1. **趋势优先级**: 必须先判断 4h 主趋势，禁止逆势交易\n")
-	sb.WriteString("2. **最小持仓时间**: 开仓后必须持有至少 30 分钟（除非触发止损/止盈）\n")
+	sb.WriteString(fmt.Sprintf("2. **最小持仓时间**: 开仓后必须持有至少 %d 分钟（除非触发止损/止盈）\n", opts.MinHoldMinutes))
 	sb.WriteString("3. **冷静期**: 平仓后必须等待至少 1 个决策周期才能开新仓\n")
 	sb.WriteString("4. **连续亏损保护**: 如果连续 3 笔亏损，暂停开新仓 1 个周期\n")
-	sb.WriteString("5. **夏普比率约束**: Sharpe < -0.5 时，完全禁止开新仓\n\n")
+	sb.WriteString(fmt.Sprintf("5. **夏普比率约束**: Sharpe < %.2g 时，完全禁止开新仓\n\n", opts.Sharpe.Halt))
 	sb.WriteString("**规则优先级（从强到弱）**:\n")
-	sb.WriteString("1. 硬性禁止/停用（禁止事项、Sharpe < -0.5、逆势规则等）\n")
+	sb.WriteString(fmt.Sprintf("1. 硬性禁止/停用（禁止事项、Sharpe < %.2g、逆势规则等）\n", opts.Sharpe.Halt))
 	sb.WriteString("2. 连续亏损保护与冷静期\n")
 	sb.WriteString("3. 市场状态（震荡/趋势）的阈值与仓位限制\n")
 	sb.WriteString("4. Credibility Mode（质量分驱动的仓位/杠杆限制）\n")
-	sb.WriteString("5. 基线阈值（Confidence ≥ 75、R:R ≥ 1:2）\n\n")
+	sb.WriteString(fmt.Sprintf("5. 基线阈值（Confidence ≥ 75、R:R ≥ 1:%s）\n\n", formatRatio(opts.MinRiskReward)))
 	sb.WriteString("当同时命中多条限制时，取最严格限制（仓位/杠杆取最小值，阈值取最大值）。\n\n")
 
 	sb.WriteString("**决策流程**:\n\n")
@@ -616,302 +1390,150 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage, s
 	sb.WriteString("---\n\n")
 	sb.WriteString("现在，分析下方提供的市场数据并做出你的交易决策。\n\n")
 
+	if opts.PlainText {
+		return toPlainText(sb.String())
+	}
 	return sb.String()
 }
 
-// buildUserPrompt 构建 User Prompt（动态数据）
-func buildUserPrompt(ctx *Context) string {
-	var sb strings.Builder
-
-	// === 时间上下文 ===
-	sb.WriteString(fmt.Sprintf("交易已运行 **%d 分钟** | 当前周期: **#%d** (每 %d 分钟决策一次) | 时间: %s\n\n",
-		ctx.RuntimeMinutes, ctx.CallCount, ctx.ScanIntervalMinutes, ctx.CurrentTime))
-
-	sb.WriteString("⚠️ **重要提醒**: 下方所有价格和指标数据的顺序为: **最旧 → 最新**\n")
-	sb.WriteString("**数组的最后一个元素是最新数据，第一个元素是最旧数据。**\n\n")
-	sb.WriteString("**时间框架说明**: 日内序列数据（IntradaySeries）为 **3分钟K线间隔**（固定），长期数据（LongerTermContext）为 **4小时K线间隔**。\n\n")
-	sb.WriteString("---\n\n")
-
-	// === 性能反馈与历史复盘（前置，重要！）===
-	if ctx.Performance != nil {
-		// 完整的性能分析数据结构
-		type TradeOutcome struct {
-			Symbol     string  `json:"symbol"`
-			Side       string  `json:"side"`
-			OpenPrice  float64 `json:"open_price"`
-			ClosePrice float64 `json:"close_price"`
-			PnL        float64 `json:"pn_l"`
-			PnLPct     float64 `json:"pn_l_pct"`
-			Duration   string  `json:"duration"`
-		}
-		type SymbolPerformance struct {
-			Symbol        string  `json:"symbol"`
-			TotalTrades   int     `json:"total_trades"`
-			WinningTrades int     `json:"winning_trades"`
-			LosingTrades  int     `json:"losing_trades"`
-			WinRate       float64 `json:"win_rate"`
-			TotalPnL      float64 `json:"total_pn_l"`
-			AvgPnL        float64 `json:"avg_pn_l"`
-		}
-		type PerformanceData struct {
-			TotalTrades   int                           `json:"total_trades"`
-			WinningTrades int                           `json:"winning_trades"`
-			LosingTrades  int                           `json:"losing_trades"`
-			WinRate       float64                       `json:"win_rate"`
-			AvgWin        float64                       `json:"avg_win"`
-			AvgLoss       float64                       `json:"avg_loss"`
-			ProfitFactor  float64                       `json:"profit_factor"`
-			SharpeRatio   float64                       `json:"sharpe_ratio"`
-			RecentTrades  []TradeOutcome                `json:"recent_trades"`
-			SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`
-			BestSymbol    string                        `json:"best_symbol"`
-			WorstSymbol   string                        `json:"worst_symbol"`
-		}
-
-		var perfData PerformanceData
-		if jsonData, err := json.Marshal(ctx.Performance); err == nil {
-			if err := json.Unmarshal(jsonData, &perfData); err == nil {
-				// === 优化 2: 自我评估与可信度机制 ===
-				sb.WriteString("## 🧠 SELF-ASSESSMENT & CREDIBILITY MECHANISM (CRITICAL)\n\n")
-				sb.WriteString("**优化 2: 基于历史表现的自我评估**\n\n")
-
-				// 计算决策质量评分（0-100）
-				qualityScore := 0.0
-				if perfData.TotalTrades > 0 {
-					// 维度 1: 胜率（权重 30%）
-					winRateScore := (perfData.WinRate / 100.0) * 20.0
-					if winRateScore > 20 {
-						winRateScore = 20
-					}
-
-					// 维度 2: 盈亏比（权重 30%）
-					profitFactorScore := 0.0
-					if perfData.ProfitFactor > 0 {
-						profitFactorScore = (perfData.ProfitFactor / 2.0) * 20.0
-						if profitFactorScore > 20 {
-							profitFactorScore = 20
-						}
-					}
-
-					// 维度 3: 夏普比率（权重 20%）
-					sharpeScore := 0.0
-					if perfData.SharpeRatio > 0 {
-						sharpeScore = (perfData.SharpeRatio / 2.0) * 20.0
-						if sharpeScore > 20 {
-							sharpeScore = 20
-						}
-					}
-
-					// 维度 4: 平均盈亏（权重 20%）
-					avgPnLScore := 0.0
-					if perfData.AvgWin > 0 {
-						avgPnLScore = 20.0 // 如果平均盈利为正，满分
-					} else if perfData.AvgWin < 0 {
-						avgPnLScore = 0.0 // 如果平均盈利为负，0分
-					}
-
-					qualityScore = (winRateScore * 0.3) + (profitFactorScore * 0.3) + (sharpeScore * 0.2) + (avgPnLScore * 0.2)
-				}
+// user prompt 中可调整顺序的主要段落
+const (
+	SectionPerformance = "performance"
+	SectionAccount     = "account"
+	SectionPositions   = "positions"
+	SectionCandidates  = "candidates"
+)
 
-				sb.WriteString(fmt.Sprintf("### 📊 Decision Quality Score: %.1f/100\n\n", qualityScore))
-				sb.WriteString("**评分维度**:\n")
-				sb.WriteString(fmt.Sprintf("- 胜率 (30%%): %.1f%%\n", perfData.WinRate))
-				sb.WriteString(fmt.Sprintf("- 盈亏比 (30%%): %.2f\n", perfData.ProfitFactor))
-				sb.WriteString(fmt.Sprintf("- 夏普比率 (20%%): %.2f\n", perfData.SharpeRatio))
-				sb.WriteString(fmt.Sprintf("- 平均盈亏 (20%%): $%.2f\n\n", perfData.AvgWin))
-
-				// 基于评分的可信度调整
-				sb.WriteString("### 🎯 Credibility Mode (MANDATORY)\n\n")
-				if qualityScore >= 70 {
-					sb.WriteString("✅ **正常模式**: Confidence ≥ 75 可开仓，使用标准仓位\n\n")
-				} else if qualityScore >= 50 {
-					sb.WriteString("⚠️ **谨慎模式**: Confidence ≥ 85 可开仓，仓位限制为正常的 50%\n\n")
-				} else {
-					sb.WriteString("🛑 **防守模式**: Confidence ≥ 90 可开仓，仓位限制为正常的 30%\n\n")
-				}
+// defaultSectionOrder 默认段落顺序（表现反馈前置）
+var defaultSectionOrder = []string{SectionPerformance, SectionAccount, SectionPositions, SectionCandidates}
 
-				sb.WriteString("---\n\n")
-
-				sb.WriteString("## 📋 HISTORICAL PERFORMANCE REVIEW (Last 100 Cycles)\n\n")
-				sb.WriteString("**⚠️ 重要：以下是你过去的交易表现，请从中学习并避免重复错误。**\n\n")
-
-				// 1. 整体统计
-				sb.WriteString("### 📊 Overall Statistics\n\n")
-				if perfData.TotalTrades > 0 {
-					sb.WriteString(fmt.Sprintf("- **总交易数**: %d (盈利 %d, 亏损 %d)\n",
-						perfData.TotalTrades, perfData.WinningTrades, perfData.LosingTrades))
-					sb.WriteString(fmt.Sprintf("- **胜率**: %.1f%%\n", perfData.WinRate))
-					sb.WriteString(fmt.Sprintf("- **平均盈利**: $%.2f | **平均亏损**: $%.2f\n",
-						perfData.AvgWin, perfData.AvgLoss))
-					sb.WriteString(fmt.Sprintf("- **盈亏比 (Profit Factor)**: %.2f\n", perfData.ProfitFactor))
-					sb.WriteString(fmt.Sprintf("- **夏普比率 (Sharpe Ratio)**: %.2f\n\n", perfData.SharpeRatio))
-				} else {
-					sb.WriteString("- **总交易数**: 0（暂无历史交易数据）\n\n")
-				}
+// userPromptSections 段落名 → 写入函数
+var userPromptSections = map[string]func(sb *strings.Builder, ctx *Context){
+	SectionPerformance: writeUserPerformanceSection,
+	SectionAccount:     writeAccountSection,
+	SectionPositions:   writePositionsSection,
+	SectionCandidates:  writeCandidatesSection,
+}
 
-				// 2. 状态提示（基于夏普比率）- 强制执行
-				sb.WriteString("### 🎯 Current Trading Mode (MANDATORY)\n\n")
-				if perfData.SharpeRatio < -0.5 {
-					sb.WriteString("🚨 **状态**: 持续亏损 - **完全禁止开新仓**（只能 close/hold/wait）\n")
-					sb.WriteString("**强制规则**: 任何 open_long/open_short 决策都将被拒绝\n\n")
-				} else if perfData.SharpeRatio < 0 {
-					sb.WriteString("⚠️ **状态**: 轻微亏损 - 收缩模式\n")
-					sb.WriteString("**强制规则**: 仓位限制为正常的 50%，杠杆限制为正常的 50%，confidence ≥ 85\n\n")
-				} else if perfData.SharpeRatio < 0.7 {
-					sb.WriteString("✅ **状态**: 稳健正收益 - 保持当前节奏\n\n")
-				} else {
-					sb.WriteString("🚀 **状态**: 优异表现 - 可适当扩大仓位（仍需遵守风控）\n\n")
-				}
-
-				// 3. 各币种表现（最佳/最差）
-				if len(perfData.SymbolStats) > 0 {
-					sb.WriteString("### 🏆 Symbol Performance Analysis\n\n")
-
-					if perfData.BestSymbol != "" {
-						bestStats := perfData.SymbolStats[perfData.BestSymbol]
-						sb.WriteString(fmt.Sprintf("**表现最佳**: %s\n", perfData.BestSymbol))
-						sb.WriteString(fmt.Sprintf("  - 交易次数: %d (盈利 %d, 亏损 %d)\n",
-							bestStats.TotalTrades, bestStats.WinningTrades, bestStats.LosingTrades))
-						sb.WriteString(fmt.Sprintf("  - 胜率: %.1f%% | 总盈亏: $%.2f | 平均盈亏: $%.2f\n\n",
-							bestStats.WinRate, bestStats.TotalPnL, bestStats.AvgPnL))
-					}
-
-					if perfData.WorstSymbol != "" {
-						worstStats := perfData.SymbolStats[perfData.WorstSymbol]
-						sb.WriteString(fmt.Sprintf("**表现最差**: %s\n", perfData.WorstSymbol))
-						sb.WriteString(fmt.Sprintf("  - 交易次数: %d (盈利 %d, 亏损 %d)\n",
-							worstStats.TotalTrades, worstStats.WinningTrades, worstStats.LosingTrades))
-						sb.WriteString(fmt.Sprintf("  - 胜率: %.1f%% | 总盈亏: $%.2f | 平均盈亏: $%.2f\n\n",
-							worstStats.WinRate, worstStats.TotalPnL, worstStats.AvgPnL))
-					}
-				}
-
-				// 4. 最近交易记录（最多显示 10 笔）
-				if len(perfData.RecentTrades) > 0 {
-					sb.WriteString("### 📋 Recent Trades (Last 10)\n\n")
-					recentCount := 10
-					if len(perfData.RecentTrades) < recentCount {
-						recentCount = len(perfData.RecentTrades)
-					}
-
-					// 从最新的开始显示
-					startIdx := len(perfData.RecentTrades) - recentCount
-					for i := startIdx; i < len(perfData.RecentTrades); i++ {
-						trade := perfData.RecentTrades[i]
-						profitEmoji := "✅"
-						if trade.PnL < 0 {
-							profitEmoji = "❌"
-						} else if trade.PnL == 0 {
-							profitEmoji = "➖"
-						}
-
-						sb.WriteString(fmt.Sprintf("%s **%s %s**: %.4f → %.4f | PnL: %+.2f%% ($%.2f) | 持仓: %s\n",
-							profitEmoji, trade.Symbol, strings.ToUpper(trade.Side),
-							trade.OpenPrice, trade.ClosePrice,
-							trade.PnLPct, trade.PnL, trade.Duration))
-					}
-					sb.WriteString("\n")
-
-					// 5. 连续亏损警告（强制执行）
-					consecutiveLosses := 0
-					for i := len(perfData.RecentTrades) - 1; i >= 0; i-- {
-						if perfData.RecentTrades[i].PnL < 0 {
-							consecutiveLosses++
-						} else {
-							break
-						}
-					}
-
-					if consecutiveLosses >= 3 {
-						sb.WriteString(fmt.Sprintf("🚨 **强制警告**: 连续 %d 笔亏损！\n", consecutiveLosses))
-						sb.WriteString("**强制规则**: 暂停开新仓 1 个周期，仓位限制为正常的 30%%\n\n")
-					}
-
-					// 检查最近 5 笔交易的胜率
-					if len(perfData.RecentTrades) >= 5 {
-						recentLosses := 0
-						for i := len(perfData.RecentTrades) - 5; i < len(perfData.RecentTrades); i++ {
-							if perfData.RecentTrades[i].PnL < 0 {
-								recentLosses++
-							}
-						}
-						if recentLosses >= 3 {
-							sb.WriteString(fmt.Sprintf("⚠️ **警告**: 最近 5 笔中有 %d 笔亏损（胜率 %.0f%%）\n", recentLosses, float64(5-recentLosses)/5*100))
-							sb.WriteString("**强制规则**: 仓位限制为正常的 50%%，confidence 门槛提高至 ≥ 85\n\n")
-						}
-					}
-				}
+// validateSectionOrder 检查段落顺序配置：必须恰好包含全部段落各一次
+func validateSectionOrder(order []string) error {
+	if len(order) != len(defaultSectionOrder) {
+		return fmt.Errorf("section_order 必须包含全部%d个段落 %v，实际: %v", len(defaultSectionOrder), defaultSectionOrder, order)
+	}
+	seen := make(map[string]bool, len(order))
+	for _, section := range order {
+		if _, ok := userPromptSections[section]; !ok {
+			return fmt.Errorf("section_order 包含未知段落: %s", section)
+		}
+		if seen[section] {
+			return fmt.Errorf("section_order 中段落重复: %s", section)
+		}
+		seen[section] = true
+	}
+	return nil
+}
 
-				// 6. 学习要点（强制执行）
-				sb.WriteString("### 💡 Key Learnings (MANDATORY)\n\n")
-				sb.WriteString("**基于历史表现，你必须**:\n")
-				if perfData.WorstSymbol != "" {
-					sb.WriteString(fmt.Sprintf("- ❌ **避免**: %s 表现最差，除非有极强信号（confidence ≥ 90）\n", perfData.WorstSymbol))
-				}
-				if perfData.BestSymbol != "" {
-					sb.WriteString(fmt.Sprintf("- ✅ **优先**: %s 表现最佳，可优先考虑该币种的机会\n", perfData.BestSymbol))
-				}
-				if perfData.WinRate < 50 && perfData.TotalTrades >= 5 {
-					sb.WriteString("- ⚠️ **胜率偏低**: 提高开仓门槛（confidence ≥ 85），减少交易频率\n")
-				}
-				if perfData.ProfitFactor < 1.5 && perfData.TotalTrades >= 5 {
-					sb.WriteString("- ⚠️ **盈亏比不佳**: 扩大止盈目标，收紧止损，提高风险回报比\n")
-				}
-				if len(perfData.RecentTrades) > 0 {
-					// 检查最近是否有连续盈利
-					consecutiveWins := 0
-					for i := len(perfData.RecentTrades) - 1; i >= 0; i-- {
-						if perfData.RecentTrades[i].PnL > 0 {
-							consecutiveWins++
-						} else {
-							break
-						}
-					}
-					if consecutiveWins >= 3 {
-						sb.WriteString(fmt.Sprintf("- 🎉 **连续 %d 笔盈利**: 保持当前策略，但不要过度自信\n", consecutiveWins))
-					}
-				}
-				sb.WriteString("\n")
+// sectionOrder 返回 user prompt 段落顺序（未配置或配置无效时使用默认顺序）
+func (ctx *Context) sectionOrder() []string {
+	if len(ctx.SectionOrder) == 0 {
+		return defaultSectionOrder
+	}
+	if err := validateSectionOrder(ctx.SectionOrder); err != nil {
+		log.Printf("⚠️  段落顺序配置无效，使用默认顺序: %v", err)
+		return defaultSectionOrder
+	}
+	return ctx.SectionOrder
+}
 
-				// === 优化 5: 历史决策修正机制 ===
-				sb.WriteString("### 🔄 Historical Decision Correction Guidelines (CRITICAL)\n\n")
-				sb.WriteString("**优化 5: 避免机械纠错，区分\"策略失败\"和\"市场变化\"**\n\n")
-				sb.WriteString("**重要提醒**: 不要因为单次亏损就否定整体策略！\n\n")
-				sb.WriteString("**区分两种情况**:\n\n")
-				sb.WriteString("1. **❌ 策略失败**（需要修正）:\n")
-				sb.WriteString("   - 逆 4h 主趋势开仓（例如：4h 下跌趋势中做多）\n")
-				sb.WriteString("   - 在极端超买/超卖时开仓（RSI > 90 或 < 10）\n")
-				sb.WriteString("   - 忽视 BTC 相关性（BTC 下跌时做多山寨币）\n")
-				sb.WriteString("   - 手续费侵蚀（预期收益 < 0.5%）\n")
-				sb.WriteString("   → **必须修正**: 提高开仓门槛，避免重复错误\n\n")
-				sb.WriteString("2. **✅ 市场变化**（不需要修正）:\n")
-				sb.WriteString("   - 做多 BTC，4h 仍在上涨趋势，但因短期回调止损\n")
-				sb.WriteString("   - 做空 ETH，4h 仍在下跌趋势，但因反弹止损\n")
-				sb.WriteString("   - 方向判断正确，但止损被触发（正常风险管理）\n")
-				sb.WriteString("   → **不需要修正**: 这是正常的风险管理，继续执行策略\n\n")
-				sb.WriteString("**基于市场状态的决策连续性**:\n\n")
-				sb.WriteString("- 如果 4h 主趋势未改变，允许在同一方向上多次尝试\n")
-				sb.WriteString("  - 例如：4h 上升趋势中，可以多次做多（每次都要重新评估入场点）\n")
-				sb.WriteString("- 如果 4h 主趋势已反转（EMA20 下穿 EMA50），则必须调整策略方向\n")
-				sb.WriteString("  - 例如：从做多切换到做空\n\n")
-				sb.WriteString("**关注长期趋势，不要过度反应短期波动**:\n\n")
-				sb.WriteString("- 胜率和盈亏比的长期趋势比单次交易更重要\n")
-				sb.WriteString("- 如果最近 10 笔交易中有 6 笔盈利，说明策略有效\n")
-				sb.WriteString("- 如果最近 10 笔交易中只有 2 笔盈利，说明需要调整\n\n")
-				sb.WriteString("---\n\n")
+// writeUserPerformanceSection 写入历史表现反馈（Context.Performance 为空或无法解析时跳过）
+func writeUserPerformanceSection(sb *strings.Builder, ctx *Context) {
+	// === 性能反馈与历史复盘（前置，重要！）===
+	if ctx.Performance == nil {
+		ctx.PromptReport.omit(SectionPerformance, "无历史表现数据")
+	} else {
+		perfData, failedFields, err := parsePerformance(ctx.Performance)
+		if err != nil {
+			log.Printf("⚠️  历史表现数据无法解析，跳过表现反馈: %v", err)
+			ctx.PromptReport.omit(SectionPerformance, "历史表现数据无法解析")
+		} else {
+			ctx.PromptReport.include(SectionPerformance)
+			if len(failedFields) > 0 {
+				ctx.PromptReport.truncate("历史表现部分字段解析失败: %s", strings.Join(failedFields, ", "))
 			}
+			if sharpe, ok := ctx.computedSharpe(); ok {
+				perfData.SharpeRatio = sharpe
+			}
+			writePerformanceSection(sb, perfData, failedFields, ctx.RiskConfig.sharpeThresholds(), ctx.PerformanceHalfLife)
+			writeCalibrationNote(sb, perfData, ctx.calibrationMinGap())
 		}
 	}
+}
+
+// calibrationMinGap 返回信心度校准的最小差值（未配置时默认5分）
+func (ctx *Context) calibrationMinGap() float64 {
+	if ctx.CalibrationMinGap <= 0 {
+		return 5
+	}
+	return ctx.CalibrationMinGap
+}
+
+// writeCalibrationNote 盈利和亏损交易的平均信心度几乎相同时，提示模型信心度没有区分度
+func writeCalibrationNote(sb *strings.Builder, perfData *PerformanceData, minGap float64) {
+	avgWin, avgLoss, ok := confidenceCalibration(perfData.RecentTrades)
+	if !ok || avgWin-avgLoss >= minGap {
+		return
+	}
+	sb.WriteString("### 🎚️ Confidence Calibration\n\n")
+	sb.WriteString(fmt.Sprintf("⚠️ 最近盈利交易的平均信心度 %.1f，亏损交易 %.1f（差值 < %.0f）— **你的信心度没有区分度**。\n", avgWin, avgLoss, minGap))
+	sb.WriteString("请如实评估：信号不够强时给出更低的 confidence，或直接选择 wait。\n\n")
+}
 
+// writeAccountSection 写入账户状态、多账户明细、杠杆上限和BTC市场概览
+func writeAccountSection(sb *strings.Builder, ctx *Context) {
 	// === 账户状态 ===
+	ctx.PromptReport.include(SectionAccount)
 	sb.WriteString("## 💰 ACCOUNT STATUS\n\n")
 	sb.WriteString(fmt.Sprintf("- **账户净值**: $%.2f USDT\n", ctx.Account.TotalEquity))
 	sb.WriteString(fmt.Sprintf("- **可用余额**: $%.2f USDT (%.1f%% of equity)\n",
 		ctx.Account.AvailableBalance,
 		(ctx.Account.AvailableBalance/ctx.Account.TotalEquity)*100))
 	sb.WriteString(fmt.Sprintf("- **总盈亏**: %+.2f%%\n", ctx.Account.TotalPnLPct))
-	sb.WriteString(fmt.Sprintf("- **保证金使用率**: %.1f%% (上限 80%%)\n", ctx.Account.MarginUsedPct))
-	sb.WriteString(fmt.Sprintf("- **持仓数量**: %d/3\n\n", ctx.Account.PositionCount))
+	sb.WriteString(fmt.Sprintf("- **保证金使用率**: %.1f%% (上限 %.0f%%)\n", ctx.Account.MarginUsedPct, ctx.RiskConfig.marginCapPct()))
+	sb.WriteString(fmt.Sprintf("- **持仓数量**: %d/%d\n", ctx.Account.PositionCount, ctx.RiskConfig.maxPositions()))
+	availableSlots, freeMargin := calculateAvailableSlots(ctx)
+	sb.WriteString(fmt.Sprintf("- **本周期可开新仓**: 最多 %d 个，可用保证金 $%.2f USDT\n", availableSlots, freeMargin))
+	if active, lastPnLPct := ctx.bigWinCooldown(); active {
+		sb.WriteString(fmt.Sprintf("- 🧊 **大赢后冷却**: 上一笔盈利 %+.2f%%，本周期单币种仓位上限降至正常的 %.0f%%（避免过度自信加大仓位）\n",
+			lastPnLPct, ctx.RiskConfig.bigWinSizeFactor()*100))
+	}
+	if len(ctx.Positions) > 0 {
+		heat := calculatePortfolioHeat(ctx.Positions, ctx.Account.TotalEquity)
+		sb.WriteString(fmt.Sprintf("- 🔥 **组合热度**: %.1f%%（所有持仓同时止损的亏损占净值比例，阈值 %.0f%%，过高时不要再加风险）\n",
+			heat, ctx.RiskConfig.maxPortfolioHeatPct()))
+	}
+	if beta, ok := calculatePortfolioBTCBeta(ctx); ok {
+		// beta 为名义敞口加权：1.0 ≈ 相当于持有1倍净值的BTC多单
+		sb.WriteString(fmt.Sprintf("- **组合BTC Beta**: %.2f（BTC每波动1%%，组合约波动 %.2f%% 净值；绝对值越大集中度风险越高）\n", beta, beta))
+	}
+	sb.WriteString("\n")
+
+	// 多子账户：展示合并敞口及各子账户明细（仓位上限按子账户单独计算）
+	if ctx.isMultiAccount() {
+		sb.WriteString(fmt.Sprintf("**合并名义敞口**: $%.2f USDT（%d 个子账户）\n\n", positionNotional(ctx.Positions), len(ctx.Accounts)))
+		var ids []string
+		for _, acct := range ctx.Accounts {
+			accountPositions := ctx.positionsFor(acct.ID)
+			sb.WriteString(fmt.Sprintf("- **子账户 %s**: 净值 $%.2f | 可用 $%.2f | 保证金使用率 %.1f%% | 持仓 %d 个 | 名义敞口 $%.2f\n",
+				acct.ID, acct.TotalEquity, acct.AvailableBalance, acct.MarginUsedPct, len(accountPositions), positionNotional(accountPositions)))
+			ids = append(ids, acct.ID)
+		}
+		sb.WriteString(fmt.Sprintf("\n⚠️ **多账户模式**: 开仓决策必须填写 `account` 字段（可选: %s），仓位大小按该子账户净值计算\n\n", strings.Join(ids, ", ")))
+	}
+
+	// 币种专属杠杆上限（仅列出本次出现的币种）
+	if caps := relevantLeverageCaps(ctx); len(caps) > 0 {
+		sb.WriteString(fmt.Sprintf("⚠️ **币种专属杠杆上限**（优先于通用杠杆限制）: %s\n\n", strings.Join(caps, ", ")))
+	}
 
 	// === BTC 市场概览（领先指标）===
 	if btcData, hasBTC := ctx.MarketDataMap["BTCUSDT"]; hasBTC {
@@ -923,49 +1545,65 @@ func buildUserPrompt(ctx *Context) string {
 		sb.WriteString(fmt.Sprintf("- **RSI(7)**: %.2f\n\n", btcData.CurrentRSI7))
 
 		// 简单的趋势判断
-		if btcData.CurrentPrice > btcData.CurrentEMA20 && btcData.CurrentMACD > 0 {
-			sb.WriteString("📈 **BTC趋势**: 看涨（价格 > EMA20, MACD > 0）\n\n")
-		} else if btcData.CurrentPrice < btcData.CurrentEMA20 && btcData.CurrentMACD < 0 {
-			sb.WriteString("📉 **BTC趋势**: 看跌（价格 < EMA20, MACD < 0）\n\n")
-		} else {
-			sb.WriteString("➡️ **BTC趋势**: 震荡/不明确\n\n")
-		}
+		trendEmoji, trendLabel := TrendLabel(btcData)
+		sb.WriteString(fmt.Sprintf("%s **BTC趋势**: %s%s\n\n", trendEmoji, trendLabel, trendCriteria[trendLabel]))
 	}
 
 	sb.WriteString("---\n\n")
+}
 
+// writePositionsSection 写入当前持仓及其市场数据
+func writePositionsSection(sb *strings.Builder, ctx *Context) {
 	// === 当前持仓（如果有）===
+	ctx.PromptReport.include(SectionPositions)
 	if len(ctx.Positions) > 0 {
 		sb.WriteString("## 📊 CURRENT POSITIONS & PERFORMANCE\n\n")
 		for i, pos := range ctx.Positions {
 			// 计算持仓时长
 			holdingDuration := ""
 			if pos.UpdateTime > 0 {
-				durationMs := time.Now().UnixMilli() - pos.UpdateTime
-				durationMin := durationMs / (1000 * 60)
-				if durationMin < 60 {
-					holdingDuration = fmt.Sprintf("%d分钟", durationMin)
-				} else {
-					durationHour := durationMin / 60
-					durationMinRemainder := durationMin % 60
-					holdingDuration = fmt.Sprintf("%d小时%d分钟", durationHour, durationMinRemainder)
-				}
+				holdingDuration = formatHoldingDuration(pos.HoldingDuration())
 			}
 
-			sb.WriteString(fmt.Sprintf("### Position %d: %s %s\n\n", i+1, pos.Symbol, strings.ToUpper(pos.Side)))
+			if ctx.isMultiAccount() {
+				sb.WriteString(fmt.Sprintf("### Position %d: %s %s [账户 %s]\n\n", i+1, pos.Symbol, strings.ToUpper(pos.Side), pos.Account))
+			} else {
+				sb.WriteString(fmt.Sprintf("### Position %d: %s %s\n\n", i+1, pos.Symbol, strings.ToUpper(pos.Side)))
+			}
 			sb.WriteString(fmt.Sprintf("- **入场价**: %.4f | **当前价**: %.4f\n", pos.EntryPrice, pos.MarkPrice))
 			sb.WriteString(fmt.Sprintf("- **未实现盈亏**: %+.2f%%\n", pos.UnrealizedPnLPct))
 			sb.WriteString(fmt.Sprintf("- **杠杆**: %dx | **保证金占用**: $%.0f\n", pos.Leverage, pos.MarginUsed))
 			sb.WriteString(fmt.Sprintf("- **强平价**: %.4f\n", pos.LiquidationPrice))
+			if ctx.RoundTripFeePct > 0 {
+				sb.WriteString(fmt.Sprintf("- **保本价(含手续费%.3f%%)**: %.4f — 价格越过该值平仓才真正盈利\n",
+					ctx.RoundTripFeePct, pos.BreakEvenPrice(ctx.RoundTripFeePct)))
+			}
+			// 部分成交：实际敞口与计划不同，后续决策应以实际数量为准
+			if ratio, ok := pos.FillRatio(); ok && math.Abs(ratio-1) > 0.01 {
+				sb.WriteString(fmt.Sprintf("- ⚠️ **部分成交**: 实际数量 %.4f / 计划 %.4f（成交率 %.0f%%），实际仓位价值 $%.2f\n",
+					pos.Quantity, pos.IntendedQuantity, ratio*100, pos.Quantity*pos.MarkPrice))
+			}
 			if holdingDuration != "" {
 				sb.WriteString(fmt.Sprintf("- **持仓时长**: %s\n", holdingDuration))
 			}
+			if minHold := ctx.effectiveMinHoldMinutes(ctx.MarketDataMap[pos.Symbol]); minHold < ctx.minHoldMinutes() {
+				sb.WriteString(fmt.Sprintf("- **最小持仓时间**: %d 分钟（高波动币种，已从 %d 分钟缩短）\n", minHold, ctx.minHoldMinutes()))
+			}
+			// 信心衰减提示：持仓很久仍未接近目标，原始交易逻辑可能已失效
+			if ctx.StaleHoldMinutes > 0 && pos.UpdateTime > 0 &&
+				pos.HoldingDuration() >= time.Duration(ctx.StaleHoldMinutes)*time.Minute && !pos.isNearTarget() {
+				sb.WriteString(fmt.Sprintf("- ⏳ **已持仓 %s 仍未接近目标** — 入场时的信心已衰减，请重新评估交易逻辑是否仍然成立\n", holdingDuration))
+			}
+			// 规则调整前开的仓位：超出当前上限，优先考虑减仓/平仓
+			if violations := ctx.positionLimitViolations(pos); len(violations) > 0 {
+				sb.WriteString(fmt.Sprintf("- ⚠️ **超出当前风控上限**（%s）— 建议优先减仓或平仓\n", strings.Join(violations, "，")))
+			}
 			sb.WriteString("\n")
 
 			// 完整市场数据
 			if marketData, ok := ctx.MarketDataMap[pos.Symbol]; ok {
 				sb.WriteString("**市场数据 (用于评估是否继续持有/平仓)**:\n\n")
-				sb.WriteString(market.Format(marketData))
+				sb.WriteString(ctx.formatMarketData(marketData))
 				sb.WriteString("\n")
 			}
 		}
@@ -975,44 +1613,139 @@ func buildUserPrompt(ctx *Context) string {
 	}
 
 	sb.WriteString("---\n\n")
+}
 
+// writeCandidatesSection 写入候选币种市场数据（持仓已满且开启 OnlyManagePositionsWhenFull 时只写提示）
+func writeCandidatesSection(sb *strings.Builder, ctx *Context) {
 	// === 候选币种市场数据 ===
-	sb.WriteString(fmt.Sprintf("## 🎯 CANDIDATE COINS MARKET DATA (%d coins)\n\n", len(ctx.MarketDataMap)))
-	sb.WriteString("**以下是所有候选币种的完整市场数据，用于寻找新交易机会。**\n\n")
-	sb.WriteString("⚠️ **记住**: 所有序列数据顺序为 **最旧 → 最新**（数组最后一个元素是最新数据）\n\n")
-
-	displayedCount := 0
-	for _, coin := range ctx.CandidateCoins {
-		marketData, hasData := ctx.MarketDataMap[coin.Symbol]
-		if !hasData {
-			continue
+	if ctx.skipCandidates() {
+		ctx.PromptReport.omit(SectionCandidates, "持仓已满（OnlyManagePositionsWhenFull）")
+		sb.WriteString("## 🎯 CANDIDATE COINS\n\n")
+		sb.WriteString(fmt.Sprintf("**持仓已满（%d/%d）** - 本周期不分析新币种，请专注于现有持仓管理（持有/平仓）\n\n",
+			len(ctx.Positions), ctx.RiskConfig.maxPositions()))
+	} else {
+		sb.WriteString(fmt.Sprintf("## 🎯 CANDIDATE COINS MARKET DATA (%d coins)\n\n", len(ctx.MarketDataMap)))
+		sb.WriteString("**以下是所有候选币种的完整市场数据，用于寻找新交易机会。**\n\n")
+		sb.WriteString("⚠️ **记住**: 所有序列数据顺序为 **最旧 → 最新**（数组最后一个元素是最新数据）\n\n")
+
+		displayedCount := 0
+		for _, coin := range ctx.CandidateCoins {
+			marketData, hasData := ctx.MarketDataMap[coin.Symbol]
+			if !hasData {
+				continue
+			}
+			displayedCount++
+
+			// 来源标签
+			// sourceTags := ""
+			// if len(coin.Sources) > 1 {
+			// 	sourceTags = " 🔥 (AI500 + OI_Top 双重信号)"
+			// } else if len(coin.Sources) == 1 && coin.Sources[0] == "oi_top" {
+			// 	sourceTags = " 📈 (OI_Top 持仓增长)"
+			// } else if len(coin.Sources) == 1 && coin.Sources[0] == "ai500" {
+			// 	sourceTags = " 🤖 (AI500 评分)"
+			// }
+
+			// sb.WriteString(fmt.Sprintf("### %d. %s%s\n\n", displayedCount, coin.Symbol, sourceTags))
+			trendEmoji, trendLabel := TrendLabel(marketData)
+			sb.WriteString(fmt.Sprintf("### %d. %s %s %s\n\n", displayedCount, coin.Symbol, trendEmoji, trendLabel))
+			if conflict, fourH, threeMin := TrendConflict(marketData); conflict {
+				sb.WriteString(fmt.Sprintf("**趋势(4h / 3m)**: %s / %s — ⚠️ 冲突，按规则应选择 wait\n\n", fourH, threeMin))
+			} else {
+				sb.WriteString(fmt.Sprintf("**趋势(4h / 3m)**: %s / %s\n\n", fourH, threeMin))
+			}
+			if missing := ctx.IncompleteData[coin.Symbol]; len(missing) > 0 {
+				sb.WriteString(fmt.Sprintf("**⚠️ 数据不完整**: 缺少 %s，相关指标无法参考，信号可靠性较低\n\n", strings.Join(missing, ", ")))
+			}
+			if divergence, ok := DetectDivergence(marketData); ok {
+				sb.WriteString(fmt.Sprintf("**RSI背离**: %s — 潜在反转信号\n\n", divergence))
+			}
+			sb.WriteString(ctx.formatMarketData(marketData))
+			sb.WriteString("\n")
+		}
+
+		ctx.PromptReport.include(SectionCandidates)
+		if hidden := len(ctx.CandidateCoins) - displayedCount; hidden > 0 {
+			ctx.PromptReport.truncate("候选币种 %d 个中 %d 个未展示（超出数量上限、被过滤或无市场数据）", len(ctx.CandidateCoins), hidden)
 		}
-		displayedCount++
-
-		// 来源标签
-		// sourceTags := ""
-		// if len(coin.Sources) > 1 {
-		// 	sourceTags = " 🔥 (AI500 + OI_Top 双重信号)"
-		// } else if len(coin.Sources) == 1 && coin.Sources[0] == "oi_top" {
-		// 	sourceTags = " 📈 (OI_Top 持仓增长)"
-		// } else if len(coin.Sources) == 1 && coin.Sources[0] == "ai500" {
-		// 	sourceTags = " 🤖 (AI500 评分)"
-		// }
-
-		// sb.WriteString(fmt.Sprintf("### %d. %s%s\n\n", displayedCount, coin.Symbol, sourceTags))
-		sb.WriteString(fmt.Sprintf("### %d. %s\n\n", displayedCount, coin.Symbol))
-		sb.WriteString(market.Format(marketData))
-		sb.WriteString("\n")
 	}
 
 	sb.WriteString("---\n\n")
+}
+
+// liquidationWarnPct 返回强平风险警报阈值（未配置时默认5%）
+func (ctx *Context) liquidationWarnPct() float64 {
+	if ctx.LiquidationWarnPct <= 0 {
+		return 5
+	}
+	return ctx.LiquidationWarnPct
+}
+
+// liquidationDistancePct 当前价距强平价的百分比（强平价或当前价未知时返回 false）
+func (p PositionInfo) liquidationDistancePct() (float64, bool) {
+	if p.LiquidationPrice <= 0 || p.MarkPrice <= 0 {
+		return 0, false
+	}
+	return math.Abs(p.MarkPrice-p.LiquidationPrice) / p.MarkPrice * 100, true
+}
+
+// writeLiquidationAlert 有持仓接近强平价时写入强平风险警报，要求模型优先降低风险
+func writeLiquidationAlert(sb *strings.Builder, ctx *Context) {
+	threshold := ctx.liquidationWarnPct()
+	var lines []string
+	for _, pos := range ctx.Positions {
+		if distance, ok := pos.liquidationDistancePct(); ok && distance < threshold {
+			lines = append(lines, fmt.Sprintf("- **%s %s**: 当前价 %.4f，强平价 %.4f，距离仅 **%.2f%%**（%dx杠杆）\n",
+				pos.Symbol, strings.ToUpper(pos.Side), pos.MarkPrice, pos.LiquidationPrice, distance, pos.Leverage))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	ctx.PromptReport.include("liquidation_risk")
+	sb.WriteString("# 🚨 LIQUIDATION RISK\n\n")
+	sb.WriteString(fmt.Sprintf("**以下持仓距强平价不足 %.1f%%，本周期必须优先处理（平仓/减仓），其次才考虑其他决策**:\n\n", threshold))
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+	sb.WriteString("\n---\n\n")
+}
+
+// buildUserPrompt 构建 User Prompt（动态数据）
+func buildUserPrompt(ctx *Context) string {
+	var sb strings.Builder
+	ctx.PromptReport = &PromptReport{}
+	if ctx.LiteMarketData {
+		ctx.PromptReport.truncate("精简模式: 市场数据只输出单行摘要，不含序列数据")
+	}
+
+	// === 强平风险警报（触发时放在最前面）===
+	writeLiquidationAlert(&sb, ctx)
+
+	// === 本周期生效的风控状态 ===
+	writeRegimeBanner(&sb, ctx)
+
+	// === 时间上下文 ===
+	sb.WriteString(fmt.Sprintf("交易已运行 **%d 分钟** | 当前周期: **#%d** (每 %d 分钟决策一次) | 时间: %s\n\n",
+		ctx.RuntimeMinutes, ctx.CallCount, ctx.ScanIntervalMinutes, ctx.CurrentTime))
+
+	sb.WriteString("⚠️ **重要提醒**: 下方所有价格和指标数据的顺序为: **最旧 → 最新**\n")
+	sb.WriteString("**数组的最后一个元素是最新数据，第一个元素是最旧数据。**\n\n")
+	sb.WriteString("**时间框架说明**: 日内序列数据（IntradaySeries）为 **3分钟K线间隔**（固定），长期数据（LongerTermContext）为 **4小时K线间隔**。\n\n")
+	sb.WriteString("---\n\n")
+
+	// === 主要段落（顺序可通过 SectionOrder 调整）===
+	for _, section := range ctx.sectionOrder() {
+		userPromptSections[section](&sb, ctx)
+	}
 
 	// === 最终指令 ===
 	sb.WriteString("## 📋 YOUR TASK\n\n")
 	sb.WriteString("⚠️ **CRITICAL REMINDER**: You are trading with REAL MONEY. Every decision has REAL consequences.\n\n")
 	sb.WriteString("**决策流程（按顺序执行）**:\n\n")
 	sb.WriteString("1. **检查历史表现**: 连续亏损？夏普比率？是否被禁止开新仓？\n")
-	sb.WriteString("2. **评估现有持仓**（如果有）: 是否需要平仓/继续持有？持仓时长是否 < 30 分钟？\n")
+	sb.WriteString("2. **评估现有持仓**（如果有）: 是否需要平仓/继续持有？持仓时长是否 < 最小持仓时间？\n")
 	sb.WriteString("3. **判断 4h 主趋势**: 上升/下降/震荡？BTC 趋势如何？\n")
 	sb.WriteString("4. **扫描新机会**（如果有可用资金）: 哪些币种有强信号？是否与 4h 趋势一致？\n")
 	sb.WriteString("5. **计算手续费影响**: 每笔交易预期收益是否 > 手续费的 5 倍？\n")
@@ -1020,95 +1753,589 @@ func buildUserPrompt(ctx *Context) string {
 	sb.WriteString("7. **验证强制规则**: 是否违反趋势优先级？是否在冷静期？是否连续亏损？\n")
 	sb.WriteString("8. **输出决策**: 先简洁的思维链分析（2-5句话），然后输出JSON决策数组\n\n")
 	sb.WriteString("**强制检查清单（违反将导致交易失败）**:\n")
-	sb.WriteString("- 🚨 **夏普比率约束**: Sharpe < -0.5 时，完全禁止开新仓\n")
+	sb.WriteString(fmt.Sprintf("- 🚨 **夏普比率约束**: Sharpe < %.2g 时，完全禁止开新仓\n", ctx.RiskConfig.sharpeThresholds().Halt))
 	sb.WriteString("- 🚨 **连续亏损保护**: 连续 3 笔亏损时，暂停开新仓 1 个周期\n")
 	sb.WriteString("- 🚨 **趋势优先级**: 禁止使用 3min 信号对抗 4h 主趋势\n")
-	sb.WriteString("- 🚨 **最小持仓时间**: 开仓后必须持有至少 30 分钟（除非触发止损/止盈）\n")
+	if ctx.ScaleMinHoldByVolatility {
+		sb.WriteString(fmt.Sprintf("- 🚨 **最小持仓时间**: 开仓后必须持有至少 %d 分钟（高波动币种见持仓中的实际要求，除非触发止损/止盈）\n", ctx.minHoldMinutes()))
+	} else {
+		sb.WriteString(fmt.Sprintf("- 🚨 **最小持仓时间**: 开仓后必须持有至少 %d 分钟（除非触发止损/止盈）\n", ctx.minHoldMinutes()))
+	}
 	sb.WriteString("- 🚨 **BTC 相关性**: BTC 4h 下跌时，禁止做多山寨币\n\n")
 	sb.WriteString("**标准检查清单**:\n")
 	sb.WriteString("- ✅ 数据顺序: 最旧 → 最新（数组最后一个元素是最新）\n")
-	sb.WriteString("- ✅ 风险回报比: ≥ 1:2（强制要求）\n")
+	sb.WriteString(fmt.Sprintf("- ✅ 风险回报比: ≥ 1:%s（强制要求）\n", formatRatio(ctx.RiskConfig.minRiskReward())))
 	sb.WriteString("- ✅ 预期收益: > 0.5%（手续费 0.09% 的 5 倍以上）\n")
 	sb.WriteString("- ✅ Confidence: ≥ 75（基于量化评分，不能凭感觉）\n")
 	sb.WriteString("- ✅ Reasoning: 必须说明 4h 趋势、预期收益、手续费占比、Confidence 计算过程\n\n")
+	if len(ctx.AllowedStrategies) > 0 {
+		sb.WriteString(fmt.Sprintf("**策略标签**: 可在决策中填写 `strategy` 字段标明交易类型，只能使用: %s\n\n", strings.Join(ctx.AllowedStrategies, ", ")))
+	}
 	sb.WriteString("**不确定时选择 wait，不要强行交易。保护资本比追逐收益更重要。**\n\n")
 
+	if ctx.PlainText {
+		return toPlainText(sb.String())
+	}
 	return sb.String()
 }
 
+// writePerformanceSection 写入历史表现反馈部分
+// failedFields 为解析失败的字段，非空时提示数据不完整；decayHalfLife > 0 时同时显示时间衰减加权的统计
+func writePerformanceSection(sb *strings.Builder, perfData *PerformanceData, failedFields []string, sharpe SharpeThresholds, decayHalfLife float64) {
+	if len(failedFields) > 0 {
+		sb.WriteString(fmt.Sprintf("⚠️ **注意**: 部分历史表现数据解析失败（%s），以下统计可能不完整\n\n", strings.Join(failedFields, ", ")))
+	}
+
+	// === 优化 2: 自我评估与可信度机制 ===
+	sb.WriteString("## 🧠 SELF-ASSESSMENT & CREDIBILITY MECHANISM (CRITICAL)\n\n")
+	sb.WriteString("**优化 2: 基于历史表现的自我评估**\n\n")
+
+	// 计算决策质量评分（0-100）
+	qualityScore := 0.0
+	if perfData.TotalTrades > 0 {
+		// 维度 1: 胜率（权重 30%）
+		winRateScore := (perfData.WinRate / 100.0) * 20.0
+		if winRateScore > 20 {
+			winRateScore = 20
+		}
+
+		// 维度 2: 盈亏比（权重 30%）
+		profitFactorScore := 0.0
+		if perfData.ProfitFactor > 0 {
+			profitFactorScore = (perfData.ProfitFactor / 2.0) * 20.0
+			if profitFactorScore > 20 {
+				profitFactorScore = 20
+			}
+		}
+
+		// 维度 3: 夏普比率（权重 20%）
+		sharpeScore := 0.0
+		if perfData.SharpeRatio > 0 {
+			sharpeScore = (perfData.SharpeRatio / 2.0) * 20.0
+			if sharpeScore > 20 {
+				sharpeScore = 20
+			}
+		}
+
+		// 维度 4: 平均盈亏（权重 20%）
+		avgPnLScore := 0.0
+		if perfData.AvgWin > 0 {
+			avgPnLScore = 20.0 // 如果平均盈利为正，满分
+		} else if perfData.AvgWin < 0 {
+			avgPnLScore = 0.0 // 如果平均盈利为负，0分
+		}
+
+		qualityScore = (winRateScore * 0.3) + (profitFactorScore * 0.3) + (sharpeScore * 0.2) + (avgPnLScore * 0.2)
+	}
+
+	sb.WriteString(fmt.Sprintf("### 📊 Decision Quality Score: %.1f/100\n\n", qualityScore))
+	sb.WriteString("**评分维度**:\n")
+	sb.WriteString(fmt.Sprintf("- 胜率 (30%%): %.1f%%\n", perfData.WinRate))
+	sb.WriteString(fmt.Sprintf("- 盈亏比 (30%%): %.2f\n", perfData.ProfitFactor))
+	sb.WriteString(fmt.Sprintf("- 夏普比率 (20%%): %.2f\n", perfData.SharpeRatio))
+	sb.WriteString(fmt.Sprintf("- 平均盈亏 (20%%): $%.2f\n\n", perfData.AvgWin))
+
+	// 基于评分的可信度调整
+	sb.WriteString("### 🎯 Credibility Mode (MANDATORY)\n\n")
+	if qualityScore >= 70 {
+		sb.WriteString("✅ **正常模式**: Confidence ≥ 75 可开仓，使用标准仓位\n\n")
+	} else if qualityScore >= 50 {
+		sb.WriteString("⚠️ **谨慎模式**: Confidence ≥ 85 可开仓，仓位限制为正常的 50%\n\n")
+	} else {
+		sb.WriteString("🛑 **防守模式**: Confidence ≥ 90 可开仓，仓位限制为正常的 30%\n\n")
+	}
+
+	sb.WriteString("---\n\n")
+
+	sb.WriteString("## 📋 HISTORICAL PERFORMANCE REVIEW (Last 100 Cycles)\n\n")
+	sb.WriteString("**⚠️ 重要：以下是你过去的交易表现，请从中学习并避免重复错误。**\n\n")
+
+	// 1. 整体统计
+	sb.WriteString("### 📊 Overall Statistics\n\n")
+	if perfData.TotalTrades > 0 {
+		sb.WriteString(fmt.Sprintf("- **总交易数**: %d (盈利 %d, 亏损 %d)\n",
+			perfData.TotalTrades, perfData.WinningTrades, perfData.LosingTrades))
+		sb.WriteString(fmt.Sprintf("- **胜率**: %.1f%%\n", perfData.WinRate))
+		sb.WriteString(fmt.Sprintf("- **平均盈利**: $%.2f | **平均亏损**: $%.2f\n",
+			perfData.AvgWin, perfData.AvgLoss))
+		sb.WriteString(fmt.Sprintf("- **盈亏比 (Profit Factor)**: %.2f\n", perfData.ProfitFactor))
+		sb.WriteString(fmt.Sprintf("- **夏普比率 (Sharpe Ratio)**: %.2f\n", perfData.SharpeRatio))
+		if decayed, ok := ComputeDecayedStats(perfData.RecentTrades, decayHalfLife); ok {
+			sb.WriteString(fmt.Sprintf("- **近期加权胜率**: %.1f%% | **近期加权盈亏比**: %.2f（半衰期 %.0f 笔，越近的交易权重越高）\n",
+				decayed.WinRate, decayed.ProfitFactor, decayHalfLife))
+			if decayed.WinRate < perfData.WinRate-15 {
+				sb.WriteString("  ⚠️ 近期表现明显差于整体统计，请以近期数据为准\n")
+			}
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("- **总交易数**: 0（暂无历史交易数据）\n\n")
+	}
+
+	// 2. 状态提示（基于夏普比率）- 强制执行
+	sb.WriteString("### 🎯 Current Trading Mode (MANDATORY)\n\n")
+	switch sharpe.regime(perfData.SharpeRatio) {
+	case sharpeRegimeHalt:
+		sb.WriteString("🚨 **状态**: 持续亏损 - **完全禁止开新仓**（只能 close/hold/wait）\n")
+		sb.WriteString("**强制规则**: 任何 open_long/open_short 决策都将被拒绝\n\n")
+	case sharpeRegimeCaution:
+		sb.WriteString("⚠️ **状态**: 轻微亏损 - 收缩模式\n")
+		sb.WriteString("**强制规则**: 仓位限制为正常的 50%，杠杆限制为正常的 50%，confidence ≥ 85\n\n")
+	case sharpeRegimeSteady:
+		sb.WriteString("✅ **状态**: 稳健正收益 - 保持当前节奏\n\n")
+	default:
+		sb.WriteString("🚀 **状态**: 优异表现 - 可适当扩大仓位（仍需遵守风控）\n\n")
+	}
+
+	// 3. 各币种表现（最佳/最差）
+	if len(perfData.SymbolStats) > 0 {
+		sb.WriteString("### 🏆 Symbol Performance Analysis\n\n")
+
+		if bestStats := perfData.SymbolStats[perfData.BestSymbol]; perfData.BestSymbol != "" && bestStats != nil {
+			sb.WriteString(fmt.Sprintf("**表现最佳**: %s\n", perfData.BestSymbol))
+			sb.WriteString(fmt.Sprintf("  - 交易次数: %d (盈利 %d, 亏损 %d)\n",
+				bestStats.TotalTrades, bestStats.WinningTrades, bestStats.LosingTrades))
+			sb.WriteString(fmt.Sprintf("  - 胜率: %.1f%% | 总盈亏: $%.2f | 平均盈亏: $%.2f\n\n",
+				bestStats.WinRate, bestStats.TotalPnL, bestStats.AvgPnL))
+		}
+
+		if worstStats := perfData.SymbolStats[perfData.WorstSymbol]; perfData.WorstSymbol != "" && worstStats != nil {
+			sb.WriteString(fmt.Sprintf("**表现最差**: %s\n", perfData.WorstSymbol))
+			sb.WriteString(fmt.Sprintf("  - 交易次数: %d (盈利 %d, 亏损 %d)\n",
+				worstStats.TotalTrades, worstStats.WinningTrades, worstStats.LosingTrades))
+			sb.WriteString(fmt.Sprintf("  - 胜率: %.1f%% | 总盈亏: $%.2f | 平均盈亏: $%.2f\n\n",
+				worstStats.WinRate, worstStats.TotalPnL, worstStats.AvgPnL))
+		}
+	}
+
+	// 4. 最近交易记录（最多显示 10 笔）
+	if len(perfData.RecentTrades) > 0 {
+		sb.WriteString("### 📋 Recent Trades (Last 10)\n\n")
+		recentCount := 10
+		if len(perfData.RecentTrades) < recentCount {
+			recentCount = len(perfData.RecentTrades)
+		}
+
+		// 从最新的开始显示
+		startIdx := len(perfData.RecentTrades) - recentCount
+		for i := startIdx; i < len(perfData.RecentTrades); i++ {
+			trade := perfData.RecentTrades[i]
+			profitEmoji := "✅"
+			if trade.PnL < 0 {
+				profitEmoji = "❌"
+			} else if trade.PnL == 0 {
+				profitEmoji = "➖"
+			}
+
+			sb.WriteString(fmt.Sprintf("%s **%s %s**: %.4f → %.4f | PnL: %+.2f%% ($%.2f) | 持仓: %s\n",
+				profitEmoji, trade.Symbol, strings.ToUpper(trade.Side),
+				trade.OpenPrice, trade.ClosePrice,
+				trade.PnLPct, trade.PnL, trade.Duration))
+		}
+		sb.WriteString("\n")
+
+		// 5. 连续亏损警告（强制执行）
+		consecutiveLosses := 0
+		for i := len(perfData.RecentTrades) - 1; i >= 0; i-- {
+			if perfData.RecentTrades[i].PnL < 0 {
+				consecutiveLosses++
+			} else {
+				break
+			}
+		}
+
+		if consecutiveLosses >= 3 {
+			sb.WriteString(fmt.Sprintf("🚨 **强制警告**: 连续 %d 笔亏损！\n", consecutiveLosses))
+			sb.WriteString("**强制规则**: 暂停开新仓 1 个周期，仓位限制为正常的 30%%\n\n")
+		}
+
+		// 检查最近 5 笔交易的胜率
+		if len(perfData.RecentTrades) >= 5 {
+			recentLosses := 0
+			for i := len(perfData.RecentTrades) - 5; i < len(perfData.RecentTrades); i++ {
+				if perfData.RecentTrades[i].PnL < 0 {
+					recentLosses++
+				}
+			}
+			if recentLosses >= 3 {
+				sb.WriteString(fmt.Sprintf("⚠️ **警告**: 最近 5 笔中有 %d 笔亏损（胜率 %.0f%%）\n", recentLosses, float64(5-recentLosses)/5*100))
+				sb.WriteString("**强制规则**: 仓位限制为正常的 50%%，confidence 门槛提高至 ≥ 85\n\n")
+			}
+		}
+	}
+
+	// 6. 学习要点（强制执行）
+	sb.WriteString("### 💡 Key Learnings (MANDATORY)\n\n")
+	sb.WriteString("**基于历史表现，你必须**:\n")
+	if perfData.WorstSymbol != "" {
+		sb.WriteString(fmt.Sprintf("- ❌ **避免**: %s 表现最差，除非有极强信号（confidence ≥ 90）\n", perfData.WorstSymbol))
+	}
+	if perfData.BestSymbol != "" {
+		sb.WriteString(fmt.Sprintf("- ✅ **优先**: %s 表现最佳，可优先考虑该币种的机会\n", perfData.BestSymbol))
+	}
+	if perfData.WinRate < 50 && perfData.TotalTrades >= 5 {
+		sb.WriteString("- ⚠️ **胜率偏低**: 提高开仓门槛（confidence ≥ 85），减少交易频率\n")
+	}
+	if perfData.ProfitFactor < 1.5 && perfData.TotalTrades >= 5 {
+		sb.WriteString("- ⚠️ **盈亏比不佳**: 扩大止盈目标，收紧止损，提高风险回报比\n")
+	}
+	if len(perfData.RecentTrades) > 0 {
+		// 检查最近是否有连续盈利
+		consecutiveWins := 0
+		for i := len(perfData.RecentTrades) - 1; i >= 0; i-- {
+			if perfData.RecentTrades[i].PnL > 0 {
+				consecutiveWins++
+			} else {
+				break
+			}
+		}
+		if consecutiveWins >= 3 {
+			sb.WriteString(fmt.Sprintf("- 🎉 **连续 %d 笔盈利**: 保持当前策略，但不要过度自信\n", consecutiveWins))
+		}
+	}
+	sb.WriteString("\n")
+
+	// === 优化 5: 历史决策修正机制 ===
+	sb.WriteString("### 🔄 Historical Decision Correction Guidelines (CRITICAL)\n\n")
+	sb.WriteString("**优化 5: 避免机械纠错，区分\"策略失败\"和\"市场变化\"**\n\n")
+	sb.WriteString("**重要提醒**: 不要因为单次亏损就否定整体策略！\n\n")
+	sb.WriteString("**区分两种情况**:\n\n")
+	sb.WriteString("1. **❌ 策略失败**（需要修正）:\n")
+	sb.WriteString("   - 逆 4h 主趋势开仓（例如：4h 下跌趋势中做多）\n")
+	sb.WriteString("   - 在极端超买/超卖时开仓（RSI > 90 或 < 10）\n")
+	sb.WriteString("   - 忽视 BTC 相关性（BTC 下跌时做多山寨币）\n")
+	sb.WriteString("   - 手续费侵蚀（预期收益 < 0.5%）\n")
+	sb.WriteString("   → **必须修正**: 提高开仓门槛，避免重复错误\n\n")
+	sb.WriteString("2. **✅ 市场变化**（不需要修正）:\n")
+	sb.WriteString("   - 做多 BTC，4h 仍在上涨趋势，但因短期回调止损\n")
+	sb.WriteString("   - 做空 ETH，4h 仍在下跌趋势，但因反弹止损\n")
+	sb.WriteString("   - 方向判断正确，但止损被触发（正常风险管理）\n")
+	sb.WriteString("   → **不需要修正**: 这是正常的风险管理，继续执行策略\n\n")
+	sb.WriteString("**基于市场状态的决策连续性**:\n\n")
+	sb.WriteString("- 如果 4h 主趋势未改变，允许在同一方向上多次尝试\n")
+	sb.WriteString("  - 例如：4h 上升趋势中，可以多次做多（每次都要重新评估入场点）\n")
+	sb.WriteString("- 如果 4h 主趋势已反转（EMA20 下穿 EMA50），则必须调整策略方向\n")
+	sb.WriteString("  - 例如：从做多切换到做空\n\n")
+	sb.WriteString("**关注长期趋势，不要过度反应短期波动**:\n\n")
+	sb.WriteString("- 胜率和盈亏比的长期趋势比单次交易更重要\n")
+	sb.WriteString("- 如果最近 10 笔交易中有 6 笔盈利，说明策略有效\n")
+	sb.WriteString("- 如果最近 10 笔交易中只有 2 笔盈利，说明需要调整\n\n")
+	sb.WriteString("---\n\n")
+}
+
+// relevantLeverageCaps 返回本次prompt中出现的币种的专属杠杆上限（按币种排序，如 "SOLUSDT 8x"）
+func relevantLeverageCaps(ctx *Context) []string {
+	var caps []string
+	for symbol, leverageCap := range ctx.RiskConfig.SymbolLeverageCaps {
+		if _, ok := ctx.MarketDataMap[symbol]; ok && leverageCap > 0 {
+			caps = append(caps, fmt.Sprintf("%s %dx", symbol, leverageCap))
+		}
+	}
+	sort.Strings(caps)
+	return caps
+}
+
+// formatHoldingDuration 格式化持仓时长（X分钟 / X小时Y分钟）
+func formatHoldingDuration(d time.Duration) string {
+	durationMin := int64(d / time.Minute)
+	if durationMin < 60 {
+		return fmt.Sprintf("%d分钟", durationMin)
+	}
+	return fmt.Sprintf("%d小时%d分钟", durationMin/60, durationMin%60)
+}
+
 // parseFullDecisionResponse 解析AI的完整决策响应
-func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int) (*FullDecision, error) {
+func parseFullDecisionResponse(aiResponse string, ctx *Context) (*FullDecision, error) {
 	// 1. 提取思维链
-	cotTrace := extractCoTTrace(aiResponse)
+	cotTrace, cotMissing := extractCoTTrace(aiResponse)
+	if cotMissing {
+		log.Printf("⚠️  AI响应缺少思维链分析（只有JSON）")
+	}
 
 	// 2. 提取JSON决策列表
-	decisions, err := extractDecisions(aiResponse)
+	decisions, err := extractDecisions(aiResponse, ctx.StrictFields)
 	if err != nil {
 		return &FullDecision{
-			CoTTrace:  cotTrace,
-			Decisions: []Decision{},
+			CoTTrace:   cotTrace,
+			Decisions:  []Decision{},
+			CoTMissing: cotMissing,
+			RiskConfig: ctx.RiskConfig.clone(),
 		}, fmt.Errorf("提取决策失败: %w\n\n=== AI思维链分析 ===\n%s", err, cotTrace)
 	}
 
-	// 3. 验证决策
-	if err := validateDecisions(decisions, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+	// 空数组表示"什么都不做"，可按配置补充显式的 wait，便于下游日志识别
+	emptyResponse := len(decisions) == 0
+	if emptyResponse {
+		log.Printf("ℹ️  AI返回空决策数组")
+		if ctx.EmptyArrayAsWait {
+			decisions = []Decision{{Action: ActionWait, Reasoning: "AI返回空决策数组，本周期不操作"}}
+		}
+	}
+
+	// 影子模式：只记录验证结果，原样返回模型的决策
+	if ctx.DryRun {
 		return &FullDecision{
-			CoTTrace:  cotTrace,
-			Decisions: decisions,
-		}, fmt.Errorf("决策验证失败: %w\n\n=== AI思维链分析 ===\n%s", err, cotTrace)
+			CoTTrace:          cotTrace,
+			Decisions:         decisions,
+			CoTMissing:        cotMissing,
+			EmptyResponse:     emptyResponse,
+			DryRun:            true,
+			ValidationResults: dryRunValidate(decisions, ctx),
+			RiskConfig:        ctx.RiskConfig.clone(),
+		}, nil
+	}
+
+	// 3. 夏普比率停用区间：开仓/加仓转为wait（不依赖模型遵守prompt）
+	sharpeFiltered := enforceSharpeHalt(decisions, ctx)
+	// 未满最小持仓时间的平仓转为hold
+	minHoldOverridden := enforceMinHold(decisions, ctx)
+
+	// 4. 验证决策
+	if err := validateDecisions(decisions, ctx); err != nil {
+		return &FullDecision{
+			CoTTrace:          cotTrace,
+			Decisions:         decisions,
+			CoTMissing:        cotMissing,
+			EmptyResponse:     emptyResponse,
+			SharpeFiltered:    sharpeFiltered,
+			MinHoldOverridden: minHoldOverridden,
+			RiskConfig:        ctx.RiskConfig.clone(),
+		}, fmt.Errorf("%w: %w\n\n=== AI思维链分析 ===\n%s", ErrDecisionValidation, err, cotTrace)
 	}
 
 	return &FullDecision{
-		CoTTrace:  cotTrace,
-		Decisions: decisions,
+		CoTTrace:          cotTrace,
+		Decisions:         decisions,
+		CoTMissing:        cotMissing,
+		EmptyResponse:     emptyResponse,
+		SharpeFiltered:    sharpeFiltered,
+		MinHoldOverridden: minHoldOverridden,
+		RiskConfig:        ctx.RiskConfig.clone(),
 	}, nil
 }
 
 // extractCoTTrace 提取思维链分析
-func extractCoTTrace(response string) string {
+// 区分两种情况：找不到JSON时整个响应都是思维链；JSON之前没有文字时思维链为空并返回 missing=true
+func extractCoTTrace(response string) (cot string, missing bool) {
+	// JSON数组包在 ```json 代码块中时，代码块之前的内容都是思维链
+	if fenceStart, _, ok := findFencedJSON(response); ok {
+		cot = strings.TrimSpace(response[:fenceStart])
+		return cot, cot == ""
+	}
+
 	// 查找JSON数组的开始位置
 	jsonStart := strings.Index(response, "[")
 
-	if jsonStart > 0 {
+	if jsonStart >= 0 {
 		// 思维链是JSON数组之前的内容
-		return strings.TrimSpace(response[:jsonStart])
+		// 去掉内联代码包裹JSON时残留的反引号（如 "决策如下: `[...]`"）
+		cot = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(response[:jsonStart]), "`"))
+		return cot, cot == ""
 	}
 
 	// 如果找不到JSON，整个响应都是思维链
-	return strings.TrimSpace(response)
+	return strings.TrimSpace(response), false
+}
+
+// findFencedJSON 查找第一个内容为JSON数组的markdown代码块（```json ... ``` 或 ``` ... ```）
+// 返回代码块起始位置和块内内容；没有这样的代码块时返回 false
+func findFencedJSON(response string) (fenceStart int, content string, ok bool) {
+	blocks := fencedJSONBlocks(response)
+	if len(blocks) == 0 {
+		return 0, "", false
+	}
+	return blocks[0].start, blocks[0].body, true
+}
+
+// fencedBlock 内容为JSON数组的代码块
+type fencedBlock struct {
+	start int    // 代码块起始位置（``` 所在位置）
+	body  string // 块内内容（不含代码块标记和语言标记）
+}
+
+// fencedJSONBlocks 按顺序返回所有内容为JSON数组的markdown代码块
+func fencedJSONBlocks(response string) []fencedBlock {
+	const fence = "```"
+	var blocks []fencedBlock
+	offset := 0
+	for {
+		open := strings.Index(response[offset:], fence)
+		if open == -1 {
+			return blocks
+		}
+		open += offset
+
+		// 跳过语言标记（如 json）
+		bodyStart := open + len(fence)
+		for bodyStart < len(response) && isASCIILetter(response[bodyStart]) {
+			bodyStart++
+		}
+
+		close := strings.Index(response[bodyStart:], fence)
+		if close == -1 {
+			return blocks
+		}
+		close += bodyStart
+
+		if body := strings.TrimSpace(response[bodyStart:close]); strings.HasPrefix(body, "[") {
+			blocks = append(blocks, fencedBlock{start: open, body: body})
+		}
+		offset = close + len(fence)
+	}
+}
+
+// stripCodeFences 把所有 ``` 代码块标记（含语言标记，如 ```json）替换为换行，保留其余内容和顺序
+func stripCodeFences(response string) string {
+	const fence = "```"
+	var sb strings.Builder
+	for {
+		open := strings.Index(response, fence)
+		if open == -1 {
+			sb.WriteString(response)
+			return sb.String()
+		}
+		sb.WriteString(response[:open])
+		sb.WriteString("\n")
+		rest := open + len(fence)
+		for rest < len(response) && isASCIILetter(response[rest]) {
+			rest++
+		}
+		response = response[rest:]
+	}
+}
+
+// isASCIILetter 判断字节是否为ASCII字母
+func isASCIILetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
 }
 
 // extractDecisions 提取JSON决策列表
-func extractDecisions(response string) ([]Decision, error) {
-	// 直接查找JSON数组 - 找第一个完整的JSON数组
+// 响应中有多个决策数组时（如先给出预览、再给出修正后的最终决策）全部解析并合并，
+// 同一币种以最后出现的数组为准；strictFields 为 true 时，出现未知字段（如模型自行发明的 "urgency"）将直接报错
+func extractDecisions(response string, strictFields bool) ([]Decision, error) {
+	// 去掉 ```json 代码块标记，代码块内外的数组按原顺序参与解析（如代码块中的预览 + 之后修正的数组）
+	response = stripCodeFences(response)
+
+	arrays, err := findDecisionArrays(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged []Decision
+	for _, jsonContent := range arrays {
+		decisions, err := parseDecisionArray(jsonContent, strictFields)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeDecisionArrays(merged, decisions)
+	}
+	if len(arrays) > 1 {
+		log.Printf("⚠️  AI响应包含 %d 个决策数组，已合并（同一币种以最后出现的为准）", len(arrays))
+	}
+	return merged, nil
+}
+
+// findDecisionArrays 按顺序返回响应中所有顶层的决策数组（内容为对象或空数组的 [...]）
+// 思维链中类似 "[强势]" 的方括号文字会被跳过；找不到决策数组时退化为第一个 [ 开始的内容，以便给出解析错误
+func findDecisionArrays(response string) ([]string, error) {
+	var arrays []string
+	offset := 0
+	for {
+		arrayStart := strings.Index(response[offset:], "[")
+		if arrayStart == -1 {
+			break
+		}
+		arrayStart += offset
+
+		if !looksLikeDecisionArray(response[arrayStart+1:]) {
+			offset = arrayStart + 1
+			continue
+		}
+
+		// 从 [ 开始，匹配括号找到对应的 ]
+		arrayEnd := findMatchingBracket(response, arrayStart)
+		if arrayEnd == -1 {
+			return nil, fmt.Errorf("无法找到JSON数组结束")
+		}
+		arrays = append(arrays, strings.TrimSpace(response[arrayStart:arrayEnd+1]))
+		offset = arrayEnd + 1
+	}
+	if len(arrays) > 0 {
+		return arrays, nil
+	}
+
+	// 没有识别出决策数组：按原逻辑取第一个完整的JSON数组
 	arrayStart := strings.Index(response, "[")
 	if arrayStart == -1 {
 		return nil, fmt.Errorf("无法找到JSON数组起始")
 	}
-
-	// 从 [ 开始，匹配括号找到对应的 ]
 	arrayEnd := findMatchingBracket(response, arrayStart)
 	if arrayEnd == -1 {
 		return nil, fmt.Errorf("无法找到JSON数组结束")
 	}
+	return []string{strings.TrimSpace(response[arrayStart : arrayEnd+1])}, nil
+}
 
-	jsonContent := strings.TrimSpace(response[arrayStart : arrayEnd+1])
+// looksLikeDecisionArray 判断 [ 之后的内容是否像决策数组（第一个非空白字符为 { 或 ]）
+func looksLikeDecisionArray(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return strings.HasPrefix(rest, "{") || strings.HasPrefix(rest, "]")
+}
 
+// parseDecisionArray 修复常见格式问题后解析单个决策数组
+func parseDecisionArray(jsonContent string, strictFields bool) ([]Decision, error) {
 	// 🔧 修复常见的JSON格式错误：缺少引号的字段值
 	// 匹配: "reasoning": 内容"}  或  "reasoning": 内容}  (没有引号)
 	// 修复为: "reasoning": "内容"}
 	// 使用简单的字符串扫描而不是正则表达式
 	jsonContent = fixMissingQuotes(jsonContent)
+	jsonContent = fixStrayBackticks(jsonContent)
+
+	// 解析JSON（先按严格模式检查未知字段）
+	var strict []strictDecision
+	err := json.Unmarshal([]byte(jsonContent), &strict)
+	if err == nil {
+		decisions := make([]Decision, len(strict))
+		for i := range strict {
+			decisions[i] = Decision(strict[i])
+		}
+		return decisions, nil
+	}
+	if !errors.Is(err, errUnknownDecisionField) {
+		return nil, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
+	}
+	if strictFields {
+		return nil, fmt.Errorf("严格模式: %w\nJSON内容: %s", err, jsonContent)
+	}
 
-	// 解析JSON
+	// 非严格模式：记录日志后忽略未知字段重新解析（Decision 默认忽略未知字段）
+	log.Printf("⚠️  %v，已忽略", err)
 	var decisions []Decision
 	if err := json.Unmarshal([]byte(jsonContent), &decisions); err != nil {
 		return nil, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
 	}
-
 	return decisions, nil
 }
 
+// mergeDecisionArrays 合并两个决策数组：later 中出现的 账户+币种 覆盖 earlier 中的全部同名决策
+// （同一数组内同一币种的多个决策，如 note + hold，全部保留）
+func mergeDecisionArrays(earlier, later []Decision) []Decision {
+	if len(earlier) == 0 {
+		return later
+	}
+	overridden := make(map[string]bool, len(later))
+	for _, d := range later {
+		overridden[d.Account+"/"+d.Symbol] = true
+	}
+	merged := make([]Decision, 0, len(earlier)+len(later))
+	for _, d := range earlier {
+		if !overridden[d.Account+"/"+d.Symbol] {
+			merged = append(merged, d)
+		}
+	}
+	return append(merged, later...)
+}
+
 // fixMissingQuotes 替换中文引号为英文引号（避免输入法自动转换）
 func fixMissingQuotes(jsonStr string) string {
 	jsonStr = strings.ReplaceAll(jsonStr, "\u201c", "\"") // "
@@ -1118,16 +2345,253 @@ func fixMissingQuotes(jsonStr string) string {
 	return jsonStr
 }
 
+// fixStrayBackticks 处理模型用内联代码（单反引号）包裹JSON或字段值的情况
+// 字符串之外的反引号按双引号处理（如 "action": `open_long` → "action": "open_long"），双引号字符串内的保持不变
+func fixStrayBackticks(jsonStr string) string {
+	if !strings.Contains(jsonStr, "`") {
+		return jsonStr
+	}
+	var sb strings.Builder
+	var quote rune // 当前字符串的起始引号（0 表示不在字符串内）
+	escaped := false
+	for _, r := range jsonStr {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote == 0 && (r == '"' || r == '`'):
+			quote = r
+			r = '"'
+		case quote != 0 && r == quote:
+			quote = 0
+			r = '"'
+		case quote == '`' && r == '"':
+			sb.WriteRune('\\') // 反引号字符串内的双引号需要转义
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
 // validateDecisions 验证所有决策（需要账户信息和杠杆配置）
-func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
-	for i, decision := range decisions {
-		if err := validateDecision(&decision, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+func validateDecisions(decisions []Decision, ctx *Context) error {
+	// 单周期开仓数量限制（防止一次性过度建仓）
+	if err := enforceMaxNewOpens(decisions, ctx); err != nil {
+		return err
+	}
+	// 全局开仓节流（防止波动剧烈时连续开仓）
+	enforceOpenThrottle(decisions, ctx)
+
+	for i := range decisions {
+		// 按索引取指针，验证过程中的换算（如百分比仓位→USD）需要写回决策
+		decision := &decisions[i]
+
+		// 仓位上限按决策所属子账户的净值计算
+		accountEquity := ctx.Account.TotalEquity
+		if decision.Action.IsOpen() {
+			acct, err := ctx.accountFor(decision.Account)
+			if err != nil {
+				return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
+			}
+			accountEquity = acct.TotalEquity
+		}
+
+		if err := validateDecisionCached(decision, accountEquity, ctx); err != nil {
 			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
 		}
 	}
+
+	// 组合热度：本批次开仓后全部止损的合计亏损过高时警告（不拦截）
+	if heat, limit := projectedPortfolioHeat(decisions, ctx), ctx.RiskConfig.maxPortfolioHeatPct(); heat > limit {
+		log.Printf("⚠️  预计组合热度 %.1f%% 超过阈值 %.1f%%（全部止损时亏损占净值比例）", heat, limit)
+	}
+
+	// 组合层面：总名义仓位不能超过账户净值的配置倍数
+	if err := validateGrossNotional(decisions, ctx); err != nil {
+		return err
+	}
+
+	// 组合层面：同方向持仓数量不能超过上限
+	if err := validateSameDirectionPositions(decisions, ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// enforceMaxNewOpens 限制单个周期的新开仓数量
+// 超出上限时按配置拒绝整批，或只保留信心度最高的开仓（同信心度按原顺序），其余转为wait
+func enforceMaxNewOpens(decisions []Decision, ctx *Context) error {
+	limit := ctx.RiskConfig.maxNewOpensPerCycle()
+
+	var openIdx []int
+	for i := range decisions {
+		if decisions[i].Action.IsOpen() {
+			openIdx = append(openIdx, i)
+		}
+	}
+	if len(openIdx) <= limit {
+		return nil
+	}
+	if ctx.RiskConfig.RejectExcessOpens {
+		return fmt.Errorf("本周期开仓%d个，超过上限%d个", len(openIdx), limit)
+	}
+
+	sort.SliceStable(openIdx, func(a, b int) bool {
+		return decisions[openIdx[a]].Confidence > decisions[openIdx[b]].Confidence
+	})
+	for _, i := range openIdx[limit:] {
+		log.Printf("⚠️  本周期开仓数超过上限%d个，%s %s 已转为wait", limit, decisions[i].Symbol, decisions[i].Action)
+		convertToWait(&decisions[i], fmt.Sprintf("超过单周期开仓上限(%d)", limit))
+	}
+	return nil
+}
+
+// enforceOpenThrottle 任意两次开仓之间至少间隔 MinSecondsBetweenOpens 秒
+// 距离上次开仓不足间隔时本批开仓全部转为wait；否则只保留第一个开仓（同一批次的开仓几乎同时执行）
+func enforceOpenThrottle(decisions []Decision, ctx *Context) {
+	minInterval := time.Duration(ctx.RiskConfig.MinSecondsBetweenOpens) * time.Second
+	if minInterval <= 0 {
+		return
+	}
+
+	allowed := true
+	if !ctx.LastOpenTime.IsZero() {
+		if elapsed := time.Since(ctx.LastOpenTime); elapsed < minInterval {
+			allowed = false
+			log.Printf("⚠️  距上次开仓仅 %s，不足最小间隔 %s，本周期开仓转为wait", elapsed.Round(time.Second), minInterval)
+		}
+	}
+
+	for i := range decisions {
+		if !decisions[i].Action.IsOpen() {
+			continue
+		}
+		if allowed {
+			allowed = false // 之后的开仓与本次开仓的间隔为0
+			continue
+		}
+		convertToWait(&decisions[i], fmt.Sprintf("距上次开仓不足%d秒", ctx.RiskConfig.MinSecondsBetweenOpens))
+	}
+}
+
+// convertToWait 将开仓决策转为wait（清空开仓参数，保留原始理由）
+func convertToWait(d *Decision, reason string) {
+	original := d.Action
+	*d = Decision{
+		Symbol:    d.Symbol,
+		Account:   d.Account,
+		Action:    ActionWait,
+		Reasoning: fmt.Sprintf("[%s 已转为wait: %s] %s", original, reason, d.Reasoning),
+	}
+}
+
+// validateGrossNotional 验证执行本批决策后的总名义仓位（现有持仓 + 新开仓）是否超过上限
+// 单个决策可能都在单币上限内，但合计后仍会造成过度暴露；多账户模式下按子账户分别检查
+func validateGrossNotional(decisions []Decision, ctx *Context) error {
+	multiple := ctx.RiskConfig.MaxGrossNotionalMultiple
+	if multiple <= 0 {
+		return nil
+	}
+
+	for _, acct := range ctx.accountList() {
+		if acct.TotalEquity <= 0 {
+			continue
+		}
+		accountDecisions := ctx.decisionsFor(acct.ID, decisions)
+
+		// 本批决策中会被平掉的持仓（symbol_side）
+		closing := make(map[string]bool)
+		for _, d := range accountDecisions {
+			switch d.Action {
+			case ActionCloseLong:
+				closing[d.Symbol+"_long"] = true
+			case ActionCloseShort:
+				closing[d.Symbol+"_short"] = true
+			}
+		}
+
+		var remaining []PositionInfo
+		for _, pos := range ctx.positionsFor(acct.ID) {
+			if !closing[pos.Symbol+"_"+pos.Side] {
+				remaining = append(remaining, pos)
+			}
+		}
+		existingNotional := positionNotional(remaining)
+
+		newNotional := 0.0
+		for _, d := range accountDecisions {
+			if d.Action.IsOpen() || d.Action == ActionScaleIn {
+				newNotional += d.PositionSizeUSD
+			}
+		}
+
+		maxNotional := acct.TotalEquity * multiple
+		if existingNotional+newNotional > maxNotional {
+			return fmt.Errorf("%s总名义仓位超限: 现有 %.0f + 新开 %.0f = %.0f USDT，上限 %.0f USDT（%.1f倍账户净值）",
+				accountLabel(acct), existingNotional, newNotional, existingNotional+newNotional, maxNotional, multiple)
+		}
+	}
+
 	return nil
 }
 
+// validateSameDirectionPositions 验证执行本批决策后同方向的持仓数量（现有 - 本批平仓 + 新开仓）是否超过上限
+// 震荡行情中同时持有多个同向仓位相当于放大同一个方向的赌注；多账户模式下按子账户分别检查
+func validateSameDirectionPositions(decisions []Decision, ctx *Context) error {
+	limit := ctx.RiskConfig.MaxSameDirectionPositions
+	if limit <= 0 {
+		return nil
+	}
+
+	for _, acct := range ctx.accountList() {
+		accountDecisions := ctx.decisionsFor(acct.ID, decisions)
+
+		// 本批决策执行后仍持有的仓位（symbol_side）
+		held := make(map[string]bool)
+		for _, pos := range ctx.positionsFor(acct.ID) {
+			held[pos.Symbol+"_"+pos.Side] = true
+		}
+		for _, d := range accountDecisions {
+			if d.Action.IsClose() {
+				delete(held, d.Symbol+"_"+positionSide(d.Action))
+			}
+		}
+		counts := make(map[string]int)
+		for key := range held {
+			counts[key[strings.LastIndex(key, "_")+1:]]++
+		}
+
+		for _, d := range accountDecisions {
+			if !d.Action.IsOpen() {
+				continue
+			}
+			side := positionSide(d.Action)
+			key := d.Symbol + "_" + side
+			if held[key] {
+				continue // 已有同向持仓，不增加持仓数量
+			}
+			if counts[side]+1 > limit {
+				return fmt.Errorf("%s%s %s 被拒绝: 已有%d个%s仓（含本批新开），同方向最多%d个",
+					accountLabel(acct), d.Symbol, d.Action, counts[side], side, limit)
+			}
+			held[key] = true
+			counts[side]++
+		}
+	}
+
+	return nil
+}
+
+// accountLabel 错误信息中的账户前缀（单账户时为空）
+func accountLabel(acct AccountInfo) string {
+	if acct.ID == "" {
+		return ""
+	}
+	return fmt.Sprintf("[账户 %s] ", acct.ID)
+}
+
 // findMatchingBracket 查找匹配的右括号
 func findMatchingBracket(s string, start int) int {
 	if start >= len(s) || s[start] != '[' {
@@ -1151,44 +2615,147 @@ func findMatchingBracket(s string, start int) int {
 }
 
 // validateDecision 验证单个决策的有效性
-func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+// accountEquity 为决策所属账户的净值（多账户模式下为子账户净值）
+func validateDecision(d *Decision, accountEquity float64, ctx *Context) error {
 	// 验证action
-	validActions := map[string]bool{
-		"open_long":   true,
-		"open_short":  true,
-		"close_long":  true,
-		"close_short": true,
-		"hold":        true,
-		"wait":        true,
+	if _, err := ParseAction(string(d.Action)); err != nil {
+		return err
+	}
+
+	// 策略标签必须在允许的名单内
+	if d.Strategy != "" {
+		if err := validateStrategy(d, ctx); err != nil {
+			return err
+		}
 	}
 
-	if !validActions[d.Action] {
-		return fmt.Errorf("无效的action: %s", d.Action)
+	// note 仅记录观点，只需要币种和理由
+	if d.Action == ActionNote {
+		if d.Symbol == "" || strings.TrimSpace(d.Reasoning) == "" {
+			return fmt.Errorf("note 必须包含 symbol 和 reasoning")
+		}
+		return nil
+	}
+
+	// request_data 只需要币种和数据参数，由调用方在下个周期提供
+	if d.Action == ActionRequestData {
+		return validateDataRequest(d)
+	}
+
+	// reduce_all 作用于所有持仓，只需要减仓比例
+	if d.Action == ActionReduceAll {
+		return validateReduceAll(d)
+	}
+
+	// 平仓方向必须与实际持仓一致
+	if d.Action.IsClose() {
+		if err := validateCloseSide(d, ctx); err != nil {
+			return err
+		}
+	}
+
+	// 白名单外的币种只允许管理现有持仓（平仓/持有），不允许开仓或加仓
+	if (d.Action.IsOpen() || d.Action == ActionScaleIn) && !ctx.isSymbolAllowed(d.Symbol) {
+		return fmt.Errorf("%s 不在交易白名单中，禁止%s", d.Symbol, d.Action)
+	}
+
+	// 持有时调整止损：只能收紧，不能放宽
+	if d.NewStopLoss != 0 {
+		if err := validateNewStopLoss(d, ctx); err != nil {
+			return err
+		}
+	}
+
+	// scale_in 沿用现有持仓的方向和杠杆，单独验证
+	if d.Action == ActionScaleIn {
+		return validateScaleIn(d, ctx)
+	}
+
+	// 平仓/持有/等待不应携带开仓参数（说明模型对动作理解混乱）
+	if !d.Action.IsOpen() {
+		if fields := openOnlyFields(d); len(fields) > 0 {
+			if ctx.StrictNonOpenFields {
+				return fmt.Errorf("%s 决策不应包含开仓参数: %s", d.Action, strings.Join(fields, ", "))
+			}
+			log.Printf("⚠️  %s %s 决策包含开仓参数（已忽略）: %s", d.Symbol, d.Action, strings.Join(fields, ", "))
+		}
 	}
 
 	// 开仓操作必须提供完整参数
-	if d.Action == "open_long" || d.Action == "open_short" {
+	if d.Action.IsOpen() {
+		// 百分比仓位换算为USD（两者同时提供时必须一致）
+		if err := resolvePositionSizePct(d, accountEquity); err != nil {
+			return err
+		}
+
 		// 根据币种使用配置的杠杆上限
-		maxLeverage := altcoinLeverage          // 山寨币使用配置的杠杆
-		maxPositionValue := accountEquity * 1.5 // 山寨币最多1.5倍账户净值
-		if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
-			maxLeverage = btcEthLeverage          // BTC和ETH使用配置的杠杆
-			maxPositionValue = accountEquity * 10 // BTC/ETH最多10倍账户净值
+		maxLeverage, maxPositionValue := ctx.symbolLimits(d.Symbol, accountEquity)
+		// 大赢后冷却：防止过度自信导致下一笔仓位过大
+		if active, lastPnLPct := ctx.bigWinCooldown(); active {
+			factor := ctx.RiskConfig.bigWinSizeFactor()
+			maxPositionValue *= factor
+			if d.PositionSizeUSD > maxPositionValue*1.01 {
+				return fmt.Errorf("大赢后冷却期（上一笔 %+.2f%%）：单币种仓位上限降至 %.0f%%，即 %.0f USDT，实际: %.0f",
+					lastPnLPct, factor*100, maxPositionValue, d.PositionSizeUSD)
+			}
 		}
 
 		if d.Leverage <= 0 || d.Leverage > maxLeverage {
-			return fmt.Errorf("杠杆必须在1-%d之间（%s，当前配置上限%d倍）: %d", maxLeverage, d.Symbol, maxLeverage, d.Leverage)
+			return ruleErrorf(RuleLeverage, fmt.Sprintf("%s 杠杆为%dx，允许范围是1-%dx，请在范围内重新选择杠杆。", d.Symbol, d.Leverage, maxLeverage),
+				"杠杆必须在1-%d之间（%s，当前配置上限%d倍）: %d", maxLeverage, d.Symbol, maxLeverage, d.Leverage)
+		}
+		// 收缩模式（夏普比率轻微亏损）：杠杆不超过配置的上限
+		if reducedCap := ctx.RiskConfig.ReducedRegimeMaxLeverage; reducedCap > 0 && d.Leverage > reducedCap {
+			if regime, ok := ctx.sharpeRegime(); ok && regime == sharpeRegimeCaution {
+				log.Printf("⚠️  %s 处于收缩模式，杠杆 %dx 已降至 %dx", d.Symbol, d.Leverage, reducedCap)
+				d.clampLeverage(reducedCap)
+			}
+		}
+		// 交易所只提供离散杠杆档位时，向下对齐到可下单的档位
+		if tiers, ok := ctx.RiskConfig.SymbolLeverageTiers[d.Symbol]; ok && len(tiers) > 0 {
+			snapped, ok := snapLeverage(d.Leverage, tiers)
+			if !ok {
+				return fmt.Errorf("%s 杠杆 %dx 低于最小可用档位（可用: %v）", d.Symbol, d.Leverage, tiers)
+			}
+			if snapped != d.Leverage {
+				if ctx.RiskConfig.RejectOffTierLeverage {
+					return fmt.Errorf("%s 杠杆 %dx 不在可用档位上（可用: %v）", d.Symbol, d.Leverage, tiers)
+				}
+				log.Printf("⚠️  %s 杠杆 %dx 不在可用档位上，已调整为 %dx", d.Symbol, d.Leverage, snapped)
+				d.clampLeverage(snapped)
+			}
+		}
+		// OI/价格关系：不与资金推动的趋势对着干
+		// （penalize 模式扣减信心度，必须在所有按信心度判断的规则之前执行才会生效）
+		if err := checkOITrend(d, ctx); err != nil {
+			return err
+		}
+		// 高杠杆需要更高的信心度（防止低信心的高杠杆赌博）
+		if required := ctx.RiskConfig.requiredConfidenceForLeverage(d.Leverage); d.Confidence < required {
+			return fmt.Errorf("%s %dx 杠杆要求信心度≥%d，当前 %d，请降低杠杆或放弃开仓", d.Symbol, d.Leverage, required, d.Confidence)
 		}
 		if d.PositionSizeUSD <= 0 {
 			return fmt.Errorf("仓位大小必须大于0: %.2f", d.PositionSizeUSD)
 		}
+		// 接近保证金使用率上限时缩小仓位，使开仓后仍在上限之内
+		if ctx.RiskConfig.ScaleSizeByMarginHeadroom {
+			if err := scaleSizeToMarginHeadroom(d, accountEquity, ctx); err != nil {
+				return err
+			}
+		}
+		// 交易所最小下单名义价值：低于该值的订单会被交易所拒绝
+		if minNotional := ctx.RiskConfig.SymbolMinNotional[d.Symbol]; d.PositionSizeUSD < minNotional {
+			return ruleErrorf(RuleMinNotional, "", "%s 仓位 %.2f USDT 低于交易所最小下单金额 %.2f USDT", d.Symbol, d.PositionSizeUSD, minNotional)
+		}
 		// 验证仓位价值上限（加1%容差以避免浮点数精度问题）
 		tolerance := maxPositionValue * 0.01 // 1%容差
 		if d.PositionSizeUSD > maxPositionValue+tolerance {
 			if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
-				return fmt.Errorf("BTC/ETH单币种仓位价值不能超过%.0f USDT（10倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
+				return ruleErrorf(RulePositionValue, fmt.Sprintf("%s 仓位为%.0f USDT，上限为%.0f USDT，请缩小 position_size_usd。", d.Symbol, d.PositionSizeUSD, maxPositionValue),
+					"BTC/ETH单币种仓位价值不能超过%.0f USDT（10倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
 			} else {
-				return fmt.Errorf("山寨币单币种仓位价值不能超过%.0f USDT（1.5倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
+				return ruleErrorf(RulePositionValue, fmt.Sprintf("%s 仓位为%.0f USDT，上限为%.0f USDT，请缩小 position_size_usd。", d.Symbol, d.PositionSizeUSD, maxPositionValue),
+					"山寨币单币种仓位价值不能超过%.0f USDT（1.5倍账户净值），实际: %.0f", maxPositionValue, d.PositionSizeUSD)
 			}
 		}
 		if d.StopLoss <= 0 || d.TakeProfit <= 0 {
@@ -1196,48 +2763,415 @@ func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoi
 		}
 
 		// 验证止损止盈的合理性
-		if d.Action == "open_long" {
+		if d.Action == ActionOpenLong {
 			if d.StopLoss >= d.TakeProfit {
-				return fmt.Errorf("做多时止损价必须小于止盈价")
+				return ruleErrorf(RuleStopTakeOrder, "", "做多时止损价必须小于止盈价")
 			}
 		} else {
 			if d.StopLoss <= d.TakeProfit {
-				return fmt.Errorf("做空时止损价必须大于止盈价")
+				return ruleErrorf(RuleStopTakeOrder, "", "做空时止损价必须大于止盈价")
 			}
 		}
+		// 给出入场价时必须位于止损和止盈之间
+		if d.EntryPrice < 0 {
+			return fmt.Errorf("entry_price 不能为负数: %.4f", d.EntryPrice)
+		}
+		if d.EntryPrice > 0 && (d.EntryPrice <= math.Min(d.StopLoss, d.TakeProfit) || d.EntryPrice >= math.Max(d.StopLoss, d.TakeProfit)) {
+			return ruleErrorf(RuleStopTakeOrder, "", "入场价%.4f必须在止损%.4f和止盈%.4f之间", d.EntryPrice, d.StopLoss, d.TakeProfit)
+		}
 
-		// 验证风险回报比（必须≥1:2）
-		// 计算入场价（假设当前市价）
-		var entryPrice float64
-		if d.Action == "open_long" {
-			// 做多：入场价在止损和止盈之间
-			entryPrice = d.StopLoss + (d.TakeProfit-d.StopLoss)*0.2 // 假设在20%位置入场
-		} else {
-			// 做空：入场价在止损和止盈之间
-			entryPrice = d.StopLoss - (d.StopLoss-d.TakeProfit)*0.2 // 假设在20%位置入场
+		// BTC 4h明确下跌时禁止做多山寨币（除非信心度极高）
+		if d.Action == ActionOpenLong && d.Symbol != "BTCUSDT" && d.Symbol != "ETHUSDT" &&
+			isBTC4hBearish(ctx.MarketDataMap["BTCUSDT"]) {
+			if minConfidence := ctx.RiskConfig.btcBearishAltLongMinConfidence(); d.Confidence < minConfidence {
+				return fmt.Errorf("BTC 4h趋势下跌，禁止做多山寨币 %s（信心度%d < %d）%s",
+					d.Symbol, d.Confidence, minConfidence, ctx.trendDetail("BTCUSDT"))
+			}
 		}
 
-		var riskPercent, rewardPercent, riskRewardRatio float64
-		if d.Action == "open_long" {
-			riskPercent = (entryPrice - d.StopLoss) / entryPrice * 100
-			rewardPercent = (d.TakeProfit - entryPrice) / entryPrice * 100
-			if riskPercent > 0 {
-				riskRewardRatio = rewardPercent / riskPercent
+		// 趋势优先级：禁止逆4h主趋势开仓，RSI极端且信心度足够高时例外
+		if ctx.RiskConfig.EnforceTrendAlignment {
+			if err := validateTrendAlignment(d, ctx); err != nil {
+				return err
 			}
-		} else {
-			riskPercent = (d.StopLoss - entryPrice) / entryPrice * 100
-			rewardPercent = (entryPrice - d.TakeProfit) / entryPrice * 100
-			if riskPercent > 0 {
-				riskRewardRatio = rewardPercent / riskPercent
+		}
+
+		// 4h与3m趋势冲突时应观望
+		if ctx.RiskConfig.RejectTrendConflict {
+			if conflict, fourH, threeMin := TrendConflict(ctx.MarketDataMap[d.Symbol]); conflict {
+				return fmt.Errorf("%s 4h趋势(%s)与3m趋势(%s)冲突，禁止%s，应选择wait%s",
+					d.Symbol, fourH, threeMin, d.Action, ctx.trendDetail(d.Symbol))
+			}
+		}
+
+		// 指标共振：至少 N 个指标确认开仓方向（无市场数据时跳过）
+		if marketData, ok := ctx.MarketDataMap[d.Symbol]; ok {
+			confirmed := confirmingIndicators(d.Action, marketData)
+			if required := ctx.RiskConfig.minConfirmingIndicators(); len(confirmed) < required {
+				return fmt.Errorf("%s %s 仅%d个指标确认方向(%s)，至少需要%d个（EMA20/MACD/RSI）%s",
+					d.Symbol, d.Action, len(confirmed), strings.Join(confirmed, ","), required, ctx.trendDetail(d.Symbol))
+			}
+		}
+
+		// 验证止损在强平价之前：止损如果比强平价更远，会先被强平，止损形同虚设
+		// （入场价优先取 entry_price，否则取当前市价；两者都没有时跳过）
+		if marketData, ok := ctx.MarketDataMap[d.Symbol]; d.EntryPrice > 0 || (ok && marketData.CurrentPrice > 0) {
+			entry := entryPriceFor(d, ctx)
+			liqPrice := estimateLiquidationPrice(d.Action, entry, d.Leverage)
+			if (d.Action == ActionOpenLong && d.StopLoss <= liqPrice) ||
+				(d.Action == ActionOpenShort && d.StopLoss >= liqPrice) {
+				return ruleErrorf(RuleLiquidation, "", "止损价%.4f超出预估强平价%.4f（%dx杠杆，入场约%.4f），会先被强平，请降低杠杆或收紧止损",
+					d.StopLoss, liqPrice, d.Leverage, entry)
+			}
+		}
+
+		// 核对 risk_usd 与仓位、止损距离是否一致（捕捉模型的计算错误）
+		if err := checkRiskUSD(d, ctx); err != nil {
+			return err
+		}
+
+		// 验证止损距离明显大于预估滑点：薄币种上过近的止损一触发就会被滑点吃掉，形同虚设
+		if multiple := ctx.RiskConfig.MinStopSlippageMultiple; multiple > 0 {
+			slippagePct := ctx.RiskConfig.SymbolSlippagePct[d.Symbol]
+			if marketData, ok := ctx.MarketDataMap[d.Symbol]; ok && marketData.CurrentPrice > 0 && slippagePct > 0 {
+				stopDistance := math.Abs(marketData.CurrentPrice - d.StopLoss)
+				slippage := marketData.CurrentPrice * slippagePct / 100
+				if stopDistance < slippage*multiple {
+					return fmt.Errorf("止损距离过近(%.4f)，仅为预估滑点(%.4f，%.3f%%)的%.1f倍，要求≥%.1f倍，请放宽止损或放弃该币种",
+						stopDistance, slippage, slippagePct, stopDistance/slippage, multiple)
+				}
 			}
 		}
 
-		// 硬约束：风险回报比必须≥2.0
-		if riskRewardRatio < 2.0 {
-			return fmt.Errorf("风险回报比过低(%.2f:1)，必须≥2.0:1 [风险:%.2f%% 收益:%.2f%%] [止损:%.2f 止盈:%.2f]",
-				riskRewardRatio, riskPercent, rewardPercent, d.StopLoss, d.TakeProfit)
+		// 验证止盈距离不会在几根K线内就结束（这类交易多半是噪音，且手续费占比过高）
+		if multiple := ctx.RiskConfig.MinTargetCandleMultiple; multiple > 0 {
+			marketData := ctx.MarketDataMap[d.Symbol]
+			if candleMove, ok := typicalCandleMove(marketData); ok && candleMove > 0 {
+				targetDistance := math.Abs(d.TakeProfit - marketData.CurrentPrice)
+				if targetDistance < candleMove*multiple {
+					return fmt.Errorf("止盈距离过近(%.4f)，仅为3分钟K线典型波动(%.4f)的%.1f倍，要求≥%.1f倍，预计很快结束，不值得开仓",
+						targetDistance, candleMove, targetDistance/candleMove, multiple)
+				}
+			}
+		}
+
+		// 验证风险回报比（不低于配置的最低值，默认1:2）
+		// 入场价优先使用模型给出的 entry_price，其次为当前市价
+		entryPrice := entryPriceFor(d, ctx)
+
+		riskPercent, rewardPercent, riskRewardRatio := calculateRiskReward(d.Action, entryPrice, d.StopLoss, d.TakeProfit)
+
+		// 硬约束：风险回报比必须≥配置的最低值
+		if minRR := ctx.RiskConfig.minRiskReward(); riskRewardRatio < minRR {
+			return ruleErrorf(RuleRiskReward, fmt.Sprintf("你的风险回报比为%.1f:1，最低要求%s:1。请扩大止盈目标或收紧止损。", riskRewardRatio, formatRatio(minRR)),
+				"风险回报比过低(%.2f:1)，必须≥%s:1 [风险:%.2f%% 收益:%.2f%%] [入场:%.2f 止损:%.2f 止盈:%.2f]",
+				riskRewardRatio, formatRatio(minRR), riskPercent, rewardPercent, entryPrice, d.StopLoss, d.TakeProfit)
+		}
+	}
+
+	return nil
+}
+
+// scaleSizeToMarginHeadroom 按剩余保证金额度（上限 − 已用）缩小开仓仓位，额度用尽时拒绝
+func scaleSizeToMarginHeadroom(d *Decision, accountEquity float64, ctx *Context) error {
+	acct, err := ctx.accountFor(d.Account)
+	if err != nil || accountEquity <= 0 || d.Leverage <= 0 {
+		return nil
+	}
+	capPct := ctx.RiskConfig.marginCapPct()
+	headroom := accountEquity*capPct/100 - acct.MarginUsed
+	if headroom <= 0 {
+		return fmt.Errorf("保证金使用率已达上限%.0f%%（已用 %.2f / 净值 %.2f），不能再开仓", capPct, acct.MarginUsed, accountEquity)
+	}
+	if maxSize := headroom * float64(d.Leverage); d.PositionSizeUSD > maxSize {
+		log.Printf("⚠️  %s 剩余保证金额度 %.2f USDT（上限%.0f%%），仓位从 %.2f 缩小至 %.2f", d.Symbol, headroom, capPct, d.PositionSizeUSD, maxSize)
+		d.clampSize(maxSize)
+	}
+	return nil
+}
+
+// symbolLimits 返回币种的杠杆上限和单币种仓位价值上限
+func (ctx *Context) symbolLimits(symbol string, accountEquity float64) (maxLeverage int, maxPositionValue float64) {
+	maxLeverage = ctx.AltcoinLeverage      // 山寨币使用配置的杠杆
+	maxPositionValue = accountEquity * 1.5 // 山寨币最多1.5倍账户净值
+	if symbol == "BTCUSDT" || symbol == "ETHUSDT" {
+		maxLeverage = ctx.BTCETHLeverage      // BTC和ETH使用配置的杠杆
+		maxPositionValue = accountEquity * 10 // BTC/ETH最多10倍账户净值
+	}
+	if symbolCap, ok := ctx.RiskConfig.SymbolLeverageCaps[symbol]; ok && symbolCap > 0 {
+		maxLeverage = symbolCap // 币种专属上限优先
+	}
+	return maxLeverage, maxPositionValue
+}
+
+// trendDetail 开启 VerboseValidation 时返回趋势判断所用的指标数值，否则返回空串
+func (ctx *Context) trendDetail(symbols ...string) string {
+	if !ctx.VerboseValidation {
+		return ""
+	}
+	values := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		values = append(values, indicatorValues(symbol, ctx.MarketDataMap[symbol]))
+	}
+	return " [指标: " + strings.Join(values, "; ") + "]"
+}
+
+// validateTrendAlignment 检查开仓方向是否与4h主趋势一致
+// 上升趋势中做空需 RSI > 极端超买阈值，下跌趋势中做多需 RSI < 极端超卖阈值，且信心度达到逆势门槛
+func validateTrendAlignment(d *Decision, ctx *Context) error {
+	data := ctx.MarketDataMap[d.Symbol]
+	trend := trend4h(data)
+	counterTrend := (trend == trendBullish && d.Action == ActionOpenShort) ||
+		(trend == trendBearish && d.Action == ActionOpenLong)
+	if !counterTrend {
+		return nil
+	}
+
+	low, high := ctx.RiskConfig.rsiExtremes()
+	rsiExtreme := (d.Action == ActionOpenShort && data.CurrentRSI7 > high) ||
+		(d.Action == ActionOpenLong && data.CurrentRSI7 < low)
+	minConfidence := ctx.RiskConfig.counterTrendMinConfidence()
+	if rsiExtreme && d.Confidence >= minConfidence {
+		log.Printf("⚠️  %s 逆4h趋势(%s)开仓，RSI极端(%.1f)且信心度%d，按例外放行", d.Symbol, trend, data.CurrentRSI7, d.Confidence)
+		return nil
+	}
+
+	return fmt.Errorf("%s 4h趋势为%s，禁止%s（逆势例外要求RSI超出%.0f/%.0f且信心度≥%d，当前RSI %.1f、信心度%d）%s",
+		d.Symbol, trend, d.Action, low, high, minConfidence, data.CurrentRSI7, d.Confidence, ctx.trendDetail(d.Symbol))
+}
+
+// requestDataTimeframes request_data 支持的K线周期
+var requestDataTimeframes = map[string]bool{
+	"1m": true, "3m": true, "5m": true, "15m": true, "30m": true,
+	"1h": true, "2h": true, "4h": true, "1d": true,
+}
+
+// maxRequestDataLookback request_data 单次最多请求的K线数量
+const maxRequestDataLookback = 500
+
+// validateDataRequest 验证 request_data 决策的参数
+func validateDataRequest(d *Decision) error {
+	if d.Symbol == "" {
+		return fmt.Errorf("request_data 必须包含 symbol")
+	}
+	if !requestDataTimeframes[d.Timeframe] {
+		return fmt.Errorf("request_data 的 timeframe 无效: %q", d.Timeframe)
+	}
+	if d.Lookback <= 0 || d.Lookback > maxRequestDataLookback {
+		return fmt.Errorf("request_data 的 lookback 必须在1-%d之间: %d", maxRequestDataLookback, d.Lookback)
+	}
+	return nil
+}
+
+// validateReduceAll 验证 reduce_all 决策：只允许减仓比例，不接受任何单币种参数
+func validateReduceAll(d *Decision) error {
+	if d.ReduceByPct <= 0 || d.ReduceByPct > 100 {
+		return fmt.Errorf("reduce_all 的 reduce_by_pct 必须在(0, 100]之间: %.2f", d.ReduceByPct)
+	}
+	if fields := openOnlyFields(d); len(fields) > 0 {
+		return fmt.Errorf("reduce_all 作用于所有持仓，不能包含参数: %s", strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// NetDirectionalConfidence 本批决策的净方向信心：开多的信心度之和减去开空的信心度之和（正数表示整体偏多）
+func (fd *FullDecision) NetDirectionalConfidence() int {
+	net := 0
+	for _, d := range fd.Decisions {
+		switch d.Action {
+		case ActionOpenLong:
+			net += d.Confidence
+		case ActionOpenShort:
+			net -= d.Confidence
+		}
+	}
+	return net
+}
+
+// DataRequests 返回AI请求下个周期补充的数据（request_data 决策）
+func (fd *FullDecision) DataRequests() []Decision {
+	var requests []Decision
+	for _, d := range fd.Decisions {
+		if d.Action == ActionRequestData {
+			requests = append(requests, d)
+		}
+	}
+	return requests
+}
+
+// OI趋势检查策略
+const (
+	oiTrendPenalize = "penalize"
+	oiTrendReject   = "reject"
+)
+
+// checkOITrend 检查开仓方向是否与OI/价格关系相反（OI上升+价格上涨时做空，或OI上升+价格下跌时做多）
+// reject 模式直接拒绝；penalize 模式扣减信心度并记录日志
+func checkOITrend(d *Decision, ctx *Context) error {
+	policy := ctx.RiskConfig.OITrendPolicy
+	if policy == "" {
+		return nil
+	}
+	relation := oiPriceRelation(ctx.MarketDataMap[d.Symbol])
+	against := (relation == oiPriceStrongUp && d.Action == ActionOpenShort) ||
+		(relation == oiPriceStrongDown && d.Action == ActionOpenLong)
+	if !against {
+		return nil
+	}
+
+	if policy == oiTrendReject {
+		return fmt.Errorf("%s %s 与OI/价格关系相反：%s%s", d.Symbol, d.Action, relation, ctx.trendDetail(d.Symbol))
+	}
+
+	penalty := ctx.RiskConfig.OITrendPenalty
+	if penalty <= 0 {
+		penalty = 10
+	}
+	original := d.Confidence
+	d.Confidence = max(d.Confidence-penalty, 0)
+	log.Printf("⚠️  %s %s 与OI/价格关系相反（%s），信心度 %d → %d", d.Symbol, d.Action, relation, original, d.Confidence)
+	return nil
+}
+
+// snapLeverage 将杠杆向下对齐到不超过它的最大档位
+// 低于所有档位时返回 false
+func snapLeverage(leverage int, tiers []int) (int, bool) {
+	snapped := 0
+	for _, tier := range tiers {
+		if tier <= leverage && tier > snapped {
+			snapped = tier
+		}
+	}
+	return snapped, snapped > 0
+}
+
+// positionLimitViolations 检查现有持仓是否超出当前风控上限
+// 规则调整（如下调杠杆上限）后，之前开的仓位可能已不符合新规则
+func (ctx *Context) positionLimitViolations(pos PositionInfo) []string {
+	acct, err := ctx.accountFor(pos.Account)
+	if err != nil {
+		return nil
+	}
+	maxLeverage, maxPositionValue := ctx.symbolLimits(pos.Symbol, acct.TotalEquity)
+
+	var violations []string
+	if maxLeverage > 0 && pos.Leverage > maxLeverage {
+		violations = append(violations, fmt.Sprintf("杠杆 %dx > 上限 %dx", pos.Leverage, maxLeverage))
+	}
+	if value := pos.Quantity * pos.MarkPrice; maxPositionValue > 0 && value > maxPositionValue*1.01 {
+		violations = append(violations, fmt.Sprintf("仓位价值 $%.0f > 上限 $%.0f", value, maxPositionValue))
+	}
+	return violations
+}
+
+// resolvePositionSizePct 将 PositionSizePct（占净值百分比）换算为 PositionSizeUSD
+// 同时给出两者且相差超过1%时视为冲突
+func resolvePositionSizePct(d *Decision, accountEquity float64) error {
+	if d.PositionSizePct == 0 {
+		return nil
+	}
+	if d.PositionSizePct < 0 {
+		return fmt.Errorf("仓位百分比必须大于0: %.2f", d.PositionSizePct)
+	}
+
+	sizeFromPct := accountEquity * d.PositionSizePct / 100
+	if d.PositionSizeUSD > 0 {
+		if math.Abs(d.PositionSizeUSD-sizeFromPct) > sizeFromPct*0.01 {
+			return fmt.Errorf("position_size_usd(%.2f) 与 position_size_pct(%.2f%% = %.2f USDT) 不一致",
+				d.PositionSizeUSD, d.PositionSizePct, sizeFromPct)
 		}
+		return nil
 	}
 
+	d.PositionSizeUSD = sizeFromPct
 	return nil
 }
+
+// entryPriceFor 返回计算风险回报比使用的入场价: 模型给出的 entry_price > 当前市价 > 按止损止盈估算
+func entryPriceFor(d *Decision, ctx *Context) float64 {
+	if d.EntryPrice > 0 {
+		return d.EntryPrice
+	}
+	if marketData, ok := ctx.MarketDataMap[d.Symbol]; ok && marketData.CurrentPrice > 0 {
+		return marketData.CurrentPrice
+	}
+	return estimateEntryPrice(d)
+}
+
+// estimateEntryPrice 估算开仓决策的入场价（给出 entry_price 时直接使用，否则假设位于止损和止盈之间20%的位置）
+// 仅作为 entryPriceFor 在没有市场数据时的最后兜底
+func estimateEntryPrice(d *Decision) float64 {
+	if d.EntryPrice > 0 {
+		return d.EntryPrice
+	}
+	if d.Action == ActionOpenLong {
+		// 做多：入场价在止损和止盈之间
+		return d.StopLoss + (d.TakeProfit-d.StopLoss)*0.2 // 假设在20%位置入场
+	}
+	// 做空：入场价在止损和止盈之间
+	return d.StopLoss - (d.StopLoss-d.TakeProfit)*0.2 // 假设在20%位置入场
+}
+
+// maintenanceMarginRate 估算强平价时使用的维持保证金率
+const maintenanceMarginRate = 0.005
+
+// estimateLiquidationPrice 根据杠杆估算逐仓强平价
+// 做多: 入场价 × (1 - 1/杠杆 + 维持保证金率)；做空: 入场价 × (1 + 1/杠杆 - 维持保证金率)
+func estimateLiquidationPrice(action Action, entryPrice float64, leverage int) float64 {
+	if leverage <= 0 {
+		return 0
+	}
+	distance := 1/float64(leverage) - maintenanceMarginRate
+	if action == ActionOpenShort {
+		return entryPrice * (1 + distance)
+	}
+	return entryPrice * (1 - distance)
+}
+
+// openOnlyFields 返回决策中填写了的开仓专用字段名
+func openOnlyFields(d *Decision) []string {
+	var fields []string
+	if d.Leverage != 0 {
+		fields = append(fields, "leverage")
+	}
+	if d.PositionSizeUSD != 0 {
+		fields = append(fields, "position_size_usd")
+	}
+	if d.PositionSizePct != 0 {
+		fields = append(fields, "position_size_pct")
+	}
+	if d.StopLoss != 0 {
+		fields = append(fields, "stop_loss")
+	}
+	if d.TakeProfit != 0 {
+		fields = append(fields, "take_profit")
+	}
+	if d.RiskUSD != 0 {
+		fields = append(fields, "risk_usd")
+	}
+	if d.EntryPrice != 0 {
+		fields = append(fields, "entry_price")
+	}
+	return fields
+}
+
+// calculateRiskReward 根据入场价计算风险百分比、收益百分比和风险回报比
+// 风险为0（止损在入场价错误一侧）时回报比返回0
+func calculateRiskReward(action Action, entryPrice, stopLoss, takeProfit float64) (riskPercent, rewardPercent, ratio float64) {
+	if entryPrice <= 0 {
+		return 0, 0, 0
+	}
+
+	if action == ActionOpenLong {
+		riskPercent = (entryPrice - stopLoss) / entryPrice * 100
+		rewardPercent = (takeProfit - entryPrice) / entryPrice * 100
+	} else {
+		riskPercent = (stopLoss - entryPrice) / entryPrice * 100
+		rewardPercent = (entryPrice - takeProfit) / entryPrice * 100
+	}
+
+	if riskPercent > 0 {
+		ratio = rewardPercent / riskPercent
+	}
+	return riskPercent, rewardPercent, ratio
+}
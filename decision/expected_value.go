@@ -0,0 +1,25 @@
+package decision
+
+import "math"
+
+// ExpectedValue 估算一批决策的期望收益（USDT），用于对比不同周期的决策质量
+// 对每个开仓决策：EV = 胜率 × 止盈收益 − (1 − 胜率) × 止损亏损，胜率粗略取 confidence/100；
+// 入场价取 currentPrices 中的当前价，缺少价格或止盈止损的决策不计入。结果仅供参考
+func ExpectedValue(decisions []Decision, currentPrices map[string]float64) float64 {
+	total := 0.0
+	for _, d := range decisions {
+		if !d.Action.IsOpen() || d.PositionSizeUSD <= 0 || d.StopLoss <= 0 || d.TakeProfit <= 0 {
+			continue
+		}
+		entry := currentPrices[d.Symbol]
+		if entry <= 0 {
+			continue
+		}
+
+		winProb := math.Min(math.Max(float64(d.Confidence)/100, 0), 1)
+		reward := math.Abs(d.TakeProfit-entry) / entry * d.PositionSizeUSD
+		risk := math.Abs(entry-d.StopLoss) / entry * d.PositionSizeUSD
+		total += winProb*reward - (1-winProb)*risk
+	}
+	return total
+}
@@ -0,0 +1,24 @@
+package decision
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpectedValueKnownBatch(t *testing.T) {
+	long := longDecision("SOLUSDT") // 入场100：收益 150，风险 50，信心度80 → 0.8×150 − 0.2×50 = 110
+	short := Decision{
+		Symbol: "ETHUSDT", Action: ActionOpenShort, PositionSizeUSD: 2000,
+		StopLoss: 55, TakeProfit: 40, Confidence: 60, // 入场50：收益 400，风险 200 → 0.6×400 − 0.4×200 = 160
+	}
+	noPrice := longDecision("DOGEUSDT")
+	decisions := []Decision{long, short, noPrice, {Symbol: "BTCUSDT", Action: ActionHold}}
+	prices := map[string]float64{"SOLUSDT": 100, "ETHUSDT": 50, "BTCUSDT": 100}
+
+	if ev := ExpectedValue(decisions, prices); math.Abs(ev-270) > 1e-9 {
+		t.Fatalf("ExpectedValue = %.4f, want 270", ev)
+	}
+	if ev := ExpectedValue(nil, prices); ev != 0 {
+		t.Fatalf("空批次的期望值应为0, got %.4f", ev)
+	}
+}
@@ -0,0 +1,102 @@
+package decision
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Explain 生成便于人工审核的决策说明（非JSON格式）
+// entry 为预期入场价，用于计算风险回报比；手续费按默认费率估算，需要按配置费率时使用 Context.ExplainDecision
+func (d Decision) Explain(entry float64) string {
+	return d.explain(entry, defaultRoundTripFeePct)
+}
+
+// ExplainDecision 按上下文生成决策说明：入场价同 entryPriceFor，手续费按配置的 RoundTripFeePct 估算
+func (ctx *Context) ExplainDecision(d Decision) string {
+	return d.explain(entryPriceFor(&d, ctx), ctx.roundTripFeePct())
+}
+
+// explain 生成决策说明，roundTripFeePct 为估算手续费使用的往返费率（%）
+func (d Decision) explain(entry, roundTripFeePct float64) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("【%s】%s\n", d.Symbol, actionLabel(d.Action)))
+
+	if d.Action.IsOpen() {
+		sb.WriteString(fmt.Sprintf("  仓位: %.2f USDT | 杠杆: %dx\n", d.PositionSizeUSD, d.Leverage))
+		if d.OriginalSizeUSD > 0 {
+			sb.WriteString(fmt.Sprintf("  （仓位已自动调整，模型原始: %.2f USDT）\n", d.OriginalSizeUSD))
+		}
+		if d.OriginalLeverage > 0 {
+			sb.WriteString(fmt.Sprintf("  （杠杆已自动调整，模型原始: %dx）\n", d.OriginalLeverage))
+		}
+		sb.WriteString(fmt.Sprintf("  入场: %.4f | 止损: %.4f | 止盈: %.4f\n", entry, d.StopLoss, d.TakeProfit))
+
+		riskPct, rewardPct, ratio := calculateRiskReward(d.Action, entry, d.StopLoss, d.TakeProfit)
+		if ratio > 0 {
+			sb.WriteString(fmt.Sprintf("  风险回报比: %.2f:1（风险 %.2f%% / 收益 %.2f%%）\n", ratio, riskPct, rewardPct))
+		} else {
+			sb.WriteString("  风险回报比: 无法计算（止损/止盈与入场价方向不符）\n")
+		}
+
+		if d.RiskUSD > 0 {
+			sb.WriteString(fmt.Sprintf("  最大风险: $%.2f\n", d.RiskUSD))
+		}
+
+		// 5倍手续费规则的衡量基准：止盈收益 vs 开平仓手续费
+		fees := EstimateFees(d.PositionSizeUSD, roundTripFeePct)
+		if fees > 0 && entry > 0 && d.TakeProfit > 0 {
+			reward := math.Abs(d.TakeProfit-entry) / entry * d.PositionSizeUSD
+			sb.WriteString(fmt.Sprintf("  预估手续费: $%.2f（往返%.2f%%）| 止盈收益/手续费: %.1fx（要求≥%.0fx）\n",
+				fees, roundTripFeePct, reward/fees, minRewardFeeMultiple))
+		}
+	}
+	if d.Action == ActionScaleIn {
+		sb.WriteString(fmt.Sprintf("  加仓: %.2f USDT | 新止损: %.4f | 新止盈: %.4f\n", d.PositionSizeUSD, d.StopLoss, d.TakeProfit))
+	}
+
+	if d.NewStopLoss > 0 {
+		sb.WriteString(fmt.Sprintf("  调整止损: %.4f\n", d.NewStopLoss))
+	}
+	if d.Action == ActionReduceAll {
+		sb.WriteString(fmt.Sprintf("  所有持仓减仓: %.0f%%\n", d.ReduceByPct))
+	}
+
+	if d.Confidence > 0 {
+		sb.WriteString(fmt.Sprintf("  信心度: %d/100\n", d.Confidence))
+	}
+	if d.Reasoning != "" {
+		sb.WriteString(fmt.Sprintf("  理由: %s\n", d.Reasoning))
+	}
+
+	return sb.String()
+}
+
+// actionLabel 动作的中文说明
+func actionLabel(action Action) string {
+	switch action {
+	case ActionOpenLong:
+		return "开多 (open_long)"
+	case ActionOpenShort:
+		return "开空 (open_short)"
+	case ActionCloseLong:
+		return "平多 (close_long)"
+	case ActionCloseShort:
+		return "平空 (close_short)"
+	case ActionScaleIn:
+		return "加仓 (scale_in)"
+	case ActionReduceAll:
+		return "整体减仓 (reduce_all)"
+	case ActionHold:
+		return "持有 (hold)"
+	case ActionWait:
+		return "观望 (wait)"
+	case ActionNote:
+		return "观点记录 (note)"
+	case ActionRequestData:
+		return "请求数据 (request_data)"
+	default:
+		return string(action)
+	}
+}
@@ -0,0 +1,27 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplainRendersComputedRiskReward(t *testing.T) {
+	d := longDecision("SOLUSDT")
+	d.Reasoning = "突破前高，放量确认"
+
+	// 入场100、止损95、止盈115 → 风险5% / 收益15% → 3:1
+	text := d.Explain(100)
+	for _, want := range []string{"SOLUSDT", "风险回报比: 3.00:1", "信心度", "突破前高，放量确认"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Explain 输出缺少 %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestExplainReportsInvalidRiskReward(t *testing.T) {
+	d := longDecision("SOLUSDT")
+	// 入场价低于止损，方向不符
+	if text := d.Explain(90); !strings.Contains(text, "无法计算") {
+		t.Fatalf("方向不符时应提示无法计算风险回报比:\n%s", text)
+	}
+}
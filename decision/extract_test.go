@@ -0,0 +1,125 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractDecisionsUnknownFields(t *testing.T) {
+	response := `分析完成。
+[{"symbol":"BTCUSDT","action":"wait","reasoning":"观望","urgency":"high"}]`
+
+	if _, err := extractDecisions(response, true); err == nil || !strings.Contains(err.Error(), "urgency") {
+		t.Fatalf("严格模式应拒绝未知字段 urgency, err=%v", err)
+	}
+
+	decisions, err := extractDecisions(response, false)
+	if err != nil {
+		t.Fatalf("非严格模式应忽略未知字段: %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Symbol != "BTCUSDT" || decisions[0].Action != ActionWait {
+		t.Fatalf("非严格模式解析结果错误: %+v", decisions)
+	}
+}
+
+func TestExtractDecisionsKnownFieldsStrict(t *testing.T) {
+	response := `[{"symbol":"BTCUSDT","action":"open_long","leverage":5,"position_size_usd":1000,"stop_loss":90,"take_profit":120,"confidence":"high","risk_usd":50,"reasoning":"突破"}]`
+	decisions, err := extractDecisions(response, true)
+	if err != nil {
+		t.Fatalf("只有已知字段时严格模式不应报错: %v", err)
+	}
+	if decisions[0].Confidence != 85 {
+		t.Fatalf("confidence=%d, want 85", decisions[0].Confidence)
+	}
+}
+
+func TestExtractDecisionsMergesMultipleArrays(t *testing.T) {
+	response := `先给出预览:
+[{"symbol":"BTCUSDT","action":"open_long","reasoning":"预览"},{"symbol":"ETHUSDT","action":"hold","reasoning":"继续持有"}]
+重新检查后修正:
+[{"symbol":"BTCUSDT","action":"wait","reasoning":"修正为观望"}]`
+
+	decisions, err := extractDecisions(response, false)
+	if err != nil {
+		t.Fatalf("extractDecisions: %v", err)
+	}
+	if len(decisions) != 2 {
+		t.Fatalf("应合并为2个决策, got %+v", decisions)
+	}
+	bySymbol := map[string]Action{}
+	for _, d := range decisions {
+		bySymbol[d.Symbol] = d.Action
+	}
+	if bySymbol["BTCUSDT"] != ActionWait {
+		t.Errorf("BTCUSDT 应以第二个数组为准(wait), got %s", bySymbol["BTCUSDT"])
+	}
+	if bySymbol["ETHUSDT"] != ActionHold {
+		t.Errorf("ETHUSDT 应保留第一个数组的 hold, got %s", bySymbol["ETHUSDT"])
+	}
+}
+
+func TestExtractDecisionsFencedPreviewThenBareCorrection(t *testing.T) {
+	response := "预览:\n```json\n[{\"symbol\":\"BTCUSDT\",\"action\":\"open_long\",\"reasoning\":\"预览\"}]\n```\n修正:\n[{\"symbol\":\"BTCUSDT\",\"action\":\"wait\",\"reasoning\":\"修正\"}]"
+
+	decisions, err := extractDecisions(response, false)
+	if err != nil {
+		t.Fatalf("extractDecisions: %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Action != ActionWait {
+		t.Fatalf("代码块外修正后的数组应覆盖代码块中的预览, got %+v", decisions)
+	}
+}
+
+func TestExtractDecisionsSkipsBracketedProse(t *testing.T) {
+	response := `BTC [强势] 突破，ETH [震荡]。
+[{"symbol":"BTCUSDT","action":"wait","reasoning":"等待回踩"}]`
+
+	decisions, err := extractDecisions(response, false)
+	if err != nil {
+		t.Fatalf("思维链中的方括号文字不应影响解析: %v", err)
+	}
+	if len(decisions) != 1 || decisions[0].Symbol != "BTCUSDT" {
+		t.Fatalf("unexpected decisions: %+v", decisions)
+	}
+}
+
+func TestExtractDecisionsInlineBacktickJSON(t *testing.T) {
+	for _, response := range []string{
+		"Here is my decision: `[{\"symbol\":\"BTCUSDT\",\"action\":\"wait\",\"reasoning\":\"观望\"}]`",
+		"决策如下: ``[{\"symbol\":\"BTCUSDT\",\"action\":\"wait\",\"reasoning\":\"观望\"}]``",
+	} {
+		decisions, err := extractDecisions(response, true)
+		if err != nil {
+			t.Fatalf("内联反引号包裹的JSON应能解析: %v\n%s", err, response)
+		}
+		if len(decisions) != 1 || decisions[0].Symbol != "BTCUSDT" || decisions[0].Action != ActionWait {
+			t.Fatalf("解析结果错误: %+v", decisions)
+		}
+	}
+
+	cot, missing := extractCoTTrace("Here is my decision: `[{\"symbol\":\"BTCUSDT\",\"action\":\"wait\",\"reasoning\":\"观望\"}]`")
+	if cot != "Here is my decision:" || missing {
+		t.Fatalf("思维链不应残留反引号: %q missing=%v", cot, missing)
+	}
+}
+
+func TestExtractDecisionsFencedBlockWithTrailingProse(t *testing.T) {
+	for _, fence := range []string{"```json", "```"} {
+		response := "BTC 4小时趋势向上，但3分钟级别超买，先观望。\n\n" + fence + "\n" +
+			`[{"symbol":"BTCUSDT","action":"wait","reasoning":"等待回踩"}]` +
+			"\n```\nThat's my analysis"
+
+		decisions, err := extractDecisions(response, false)
+		if err != nil {
+			t.Fatalf("代码块中的JSON应能解析(%s): %v", fence, err)
+		}
+		if len(decisions) != 1 || decisions[0].Symbol != "BTCUSDT" || decisions[0].Action != ActionWait {
+			t.Fatalf("解析结果错误(%s): %+v", fence, decisions)
+		}
+
+		cot, missing := extractCoTTrace(response)
+		if cot != "BTC 4小时趋势向上，但3分钟级别超买，先观望。" || missing {
+			t.Fatalf("思维链应为代码块之前的全部内容(%s): %q missing=%v", fence, cot, missing)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package decision
+
+// defaultRoundTripFeePct 默认开仓+平仓手续费合计（占名义价值%，taker 0.045% × 2）
+const defaultRoundTripFeePct = 0.09
+
+// minRewardFeeMultiple 预期收益至少为手续费的倍数（prompt中的"5倍手续费"规则）
+const minRewardFeeMultiple = 5.0
+
+// roundTripFeePct 返回估算手续费使用的往返费率（未配置 RoundTripFeePct 时默认0.09%）
+func (ctx *Context) roundTripFeePct() float64 {
+	if ctx.RoundTripFeePct <= 0 {
+		return defaultRoundTripFeePct
+	}
+	return ctx.RoundTripFeePct
+}
+
+// EstimateFees 估算仓位开平仓的手续费合计（USDT）
+// roundTripFeePct 为开仓+平仓手续费合计占名义价值的百分比（如 0.09 表示 0.09%）
+func EstimateFees(sizeUSD, roundTripFeePct float64) float64 {
+	return sizeUSD * roundTripFeePct / 100
+}
@@ -0,0 +1,54 @@
+package decision
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEstimateFees(t *testing.T) {
+	if fees := EstimateFees(3000, 0.09); math.Abs(fees-2.7) > 1e-9 {
+		t.Fatalf("EstimateFees(3000, 0.09) = %.4f, want 2.70", fees)
+	}
+	if fees := EstimateFees(0, 0.09); fees != 0 {
+		t.Fatalf("仓位为0时手续费应为0，得到 %.4f", fees)
+	}
+}
+
+func TestExplainRendersEstimatedFees(t *testing.T) {
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 3000
+
+	// 止盈收益 15% × 3000 = 450，手续费 2.70 → 166.7x
+	text := d.Explain(100)
+	for _, want := range []string{"预估手续费: $2.70", "往返0.09%", "166.7x"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Explain 输出缺少 %q:\n%s", want, text)
+		}
+	}
+
+	if text := (Decision{Symbol: "SOLUSDT", Action: ActionHold}).Explain(100); strings.Contains(text, "预估手续费") {
+		t.Fatal("非开仓决策不显示手续费")
+	}
+}
+
+func TestExplainDecisionUsesConfiguredFeeRate(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 3000
+
+	// 未配置时按默认 0.09%：3000 × 0.09% = 2.70
+	if text := ctx.ExplainDecision(d); !strings.Contains(text, "预估手续费: $2.70") {
+		t.Fatalf("未配置费率时应按默认值估算:\n%s", text)
+	}
+
+	// 配置 0.05%：3000 × 0.05% = 1.50
+	ctx.RoundTripFeePct = 0.05
+	text := ctx.ExplainDecision(d)
+	for _, want := range []string{"预估手续费: $1.50", "往返0.05%", "风险回报比: 3.00:1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("ExplainDecision 输出缺少 %q:\n%s", want, text)
+		}
+	}
+}
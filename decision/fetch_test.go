@@ -0,0 +1,116 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+func TestFetchMarketDataFailsWhenAllFetchesFail(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{})
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}, {Symbol: "BNBUSDT", Sources: []string{"ai500"}}}
+
+	err := fetchMarketDataForContext(ctx)
+	if err == nil || !strings.Contains(err.Error(), "均获取失败") {
+		t.Fatalf("所有市场数据获取失败时应返回错误，得到 %v", err)
+	}
+}
+
+func TestFetchMarketDataToleratesPartialFailure(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"SOLUSDT": 100})
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}, {Symbol: "BNBUSDT", Sources: []string{"ai500"}}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("部分币种获取失败不应中止决策: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["SOLUSDT"]; !ok {
+		t.Fatal("成功获取的币种应出现在 MarketDataMap 中")
+	}
+}
+
+func TestFetchMarketDataWithNothingToFetch(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{})
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("没有持仓和候选币种时不应报错: %v", err)
+	}
+}
+
+func TestFetchMarketDataSkipsFlatCandidates(t *testing.T) {
+	flatProvider := MarketDataProviderFunc(func(symbol string) (*market.Data, error) {
+		data := testMarketData(symbol, 100)
+		if symbol != "SOLUSDT" {
+			data.PriceChange4h = 0.2
+		}
+		return data, nil
+	})
+
+	ctx := testContext()
+	ctx.MarketDataProvider = flatProvider
+	ctx.MinAbs4hChangePct = 1
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}, {Symbol: "FLATUSDT", Sources: []string{"ai500"}}}
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["FLATUSDT"]; ok {
+		t.Fatal("4小时波动低于1%的候选币种应被跳过")
+	}
+	if _, ok := ctx.MarketDataMap["SOLUSDT"]; !ok {
+		t.Fatal("波动足够的候选币种应保留")
+	}
+	if _, ok := ctx.MarketDataMap["BTCUSDT"]; !ok {
+		t.Fatal("现有持仓不受波动过滤影响")
+	}
+
+	// 默认关闭
+	ctx = testContext()
+	ctx.MarketDataProvider = flatProvider
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "FLATUSDT", Sources: []string{"ai500"}}}
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["FLATUSDT"]; !ok {
+		t.Fatal("MinAbs4hChangePct 为0时不应过滤")
+	}
+}
+
+func TestFetchMarketDataDedupesCandidates(t *testing.T) {
+	stubOITop(t, nil, nil)
+
+	provider := newCountingProvider(map[string]float64{"SOLUSDT": 100, "ETHUSDT": 50})
+	ctx := testContext()
+	ctx.MarketDataProvider = provider
+	ctx.CandidateCoins = []CandidateCoin{
+		{Symbol: "SOLUSDT", Sources: []string{"ai500"}},
+		{Symbol: "ETHUSDT", Sources: []string{"ai500"}},
+		{Symbol: "SOLUSDT", Sources: []string{"oi_top"}},
+	}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if len(ctx.CandidateCoins) != 2 {
+		t.Fatalf("重复币种应合并为一项: %+v", ctx.CandidateCoins)
+	}
+	sol := ctx.CandidateCoins[0]
+	if sol.Symbol != "SOLUSDT" || len(sol.Sources) != 2 || sol.Sources[0] != "ai500" || sol.Sources[1] != "oi_top" {
+		t.Fatalf("合并后应保留首次出现的位置并包含两个来源: %+v", sol)
+	}
+	if n := provider.callCount("SOLUSDT"); n != 1 {
+		t.Fatalf("SOLUSDT 数据应只获取一次, got %d", n)
+	}
+	headers := 0
+	for _, line := range strings.Split(buildUserPrompt(ctx), "\n") {
+		if strings.HasPrefix(line, "### ") && strings.Contains(line, "SOLUSDT") {
+			headers++
+		}
+	}
+	if headers != 1 {
+		t.Fatalf("SOLUSDT 在 user prompt 中应只渲染一次, got %d", headers)
+	}
+}
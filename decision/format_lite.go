@@ -0,0 +1,61 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/market"
+	"strings"
+)
+
+// FormatLite 将市场数据压缩为一行最新指标摘要（不含任何序列数据）
+// 用于精简模式，大幅减少prompt的token消耗
+func FormatLite(data *market.Data) string {
+	if data == nil {
+		return ""
+	}
+
+	parts := []string{
+		fmt.Sprintf("price=%.4f", data.CurrentPrice),
+		fmt.Sprintf("1h=%+.2f%%", data.PriceChange1h),
+		fmt.Sprintf("4h=%+.2f%%", data.PriceChange4h),
+		fmt.Sprintf("ema20=%.4f", data.CurrentEMA20),
+	}
+	if data.LongerTermContext != nil {
+		parts = append(parts, fmt.Sprintf("ema20_4h=%.4f", data.LongerTermContext.EMA20),
+			fmt.Sprintf("ema50_4h=%.4f", data.LongerTermContext.EMA50))
+	}
+	parts = append(parts,
+		fmt.Sprintf("macd=%.4f", data.CurrentMACD),
+		fmt.Sprintf("rsi7=%.1f", data.CurrentRSI7))
+	if data.LongerTermContext != nil && len(data.LongerTermContext.RSI14Values) > 0 {
+		rsi14 := data.LongerTermContext.RSI14Values
+		parts = append(parts, fmt.Sprintf("rsi14_4h=%.1f", rsi14[len(rsi14)-1]))
+	}
+	parts = append(parts, fmt.Sprintf("funding=%.2e", data.FundingRate))
+	parts = append(parts, "oi="+oiTrend(data.OpenInterest))
+
+	return strings.Join(parts, " | ") + "\n"
+}
+
+// oiTrend 根据最新持仓量与均值的比较给出持仓量趋势
+func oiTrend(oi *market.OIData) string {
+	if oi == nil || oi.Average <= 0 {
+		return "n/a"
+	}
+	changePct := (oi.Latest - oi.Average) / oi.Average * 100
+	switch {
+	case changePct > 1:
+		return fmt.Sprintf("rising(%+.1f%% vs avg)", changePct)
+	case changePct < -1:
+		return fmt.Sprintf("falling(%+.1f%% vs avg)", changePct)
+	default:
+		return fmt.Sprintf("flat(%+.1f%% vs avg)", changePct)
+	}
+}
+
+// formatMarketData 按上下文配置选择完整或精简的市场数据格式
+func (ctx *Context) formatMarketData(data *market.Data) string {
+	if ctx.LiteMarketData {
+		return FormatLite(data)
+	}
+	return market.Format(data)
+}
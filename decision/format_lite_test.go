@@ -0,0 +1,40 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatLiteContainsLatestValuesOnly(t *testing.T) {
+	data := testMarketData("SOLUSDT", 100)
+	data.OpenInterest.Latest = data.OpenInterest.Average * 1.05
+
+	line := FormatLite(data)
+	for _, want := range []string{"price=100.0000", "ema20=99.0000", "ema50_4h=95.0000", "macd=0.1000", "rsi7=55.0", "rsi14_4h=58.0", "funding=1.00e-04", "oi=rising(+5.0% vs avg)"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("FormatLite 缺少 %q: %s", want, line)
+		}
+	}
+	if strings.Count(line, "\n") != 1 {
+		t.Fatalf("FormatLite 应只输出一行: %q", line)
+	}
+}
+
+func TestLiteMarketDataOmitsSeries(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+
+	if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, "Mid prices") {
+		t.Fatal("默认模式应输出完整序列数据")
+	}
+
+	ctx.LiteMarketData = true
+	prompt := buildUserPrompt(ctx)
+	if strings.Contains(prompt, "Mid prices") || strings.Contains(prompt, "Intraday series") {
+		t.Fatal("精简模式不应输出序列数据")
+	}
+	if !strings.Contains(prompt, "price=100.0000") {
+		t.Fatal("精简模式应输出一行指标摘要")
+	}
+}
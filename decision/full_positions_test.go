@@ -0,0 +1,47 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+// fullBookContext 持仓数量达到上限（默认3个）的上下文
+func fullBookContext() *Context {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{
+		"BTCUSDT": 100, "ETHUSDT": 100, "BNBUSDT": 100, "SOLUSDT": 100,
+	})
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "BNBUSDT"} {
+		ctx.Positions = append(ctx.Positions, PositionInfo{Symbol: symbol, Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 3})
+	}
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+	return ctx
+}
+
+func TestCandidatesOmittedWhenFull(t *testing.T) {
+	ctx := fullBookContext()
+	ctx.OnlyManagePositionsWhenFull = true
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["SOLUSDT"]; ok {
+		t.Fatal("持仓已满时不应获取候选币种数据")
+	}
+	if _, ok := ctx.MarketDataMap["BTCUSDT"]; !ok {
+		t.Fatal("持仓币种的数据仍需获取")
+	}
+	if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, "本周期不分析新币种") {
+		t.Fatal("user prompt 应提示专注于持仓管理")
+	}
+}
+
+func TestCandidatesKeptWhenFullByDefault(t *testing.T) {
+	ctx := fullBookContext()
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["SOLUSDT"]; !ok {
+		t.Fatal("未开启 OnlyManagePositionsWhenFull 时应保留候选币种")
+	}
+}
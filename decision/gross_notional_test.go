@@ -0,0 +1,30 @@
+package decision
+
+import "testing"
+
+func TestValidateGrossNotionalRejectsCollectiveBreach(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MaxGrossNotionalMultiple = 2
+	// 现有持仓名义价值 10000，上限 2 × 10000 = 20000
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 100, Leverage: 5}}
+
+	sol, eth := longDecision("SOLUSDT"), longDecision("ETHUSDT")
+	sol.PositionSizeUSD, eth.PositionSizeUSD = 6000, 6000
+
+	// 单独任一开仓都在上限内
+	for _, d := range []Decision{sol, eth} {
+		if err := validateGrossNotional([]Decision{d}, ctx); err != nil {
+			t.Fatalf("%s 单独开仓不应超限: %v", d.Symbol, err)
+		}
+	}
+	// 合计 10000 + 12000 = 22000 超过上限
+	if err := validateGrossNotional([]Decision{sol, eth}, ctx); err == nil {
+		t.Fatal("两笔开仓合计超过总名义仓位上限，应被拒绝")
+	}
+
+	// 同批平掉现有持仓后腾出额度
+	closeBTC := Decision{Symbol: "BTCUSDT", Action: ActionCloseLong}
+	if err := validateGrossNotional([]Decision{closeBTC, sol, eth}, ctx); err != nil {
+		t.Fatalf("平仓后总名义仓位应在上限内: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// defaultMaxPortfolioHeatPct 未配置时的组合热度警告阈值（占净值百分比）
+const defaultMaxPortfolioHeatPct = 10.0
+
+// positionRiskUSD 估算持仓触发止损时的亏损（止损距离 × 数量）
+// 未记录止损价时以强平价估算（最坏情况）；两者都未知时返回 false
+func positionRiskUSD(pos PositionInfo) (float64, bool) {
+	stop := pos.StopLoss
+	if stop <= 0 {
+		stop = pos.LiquidationPrice
+	}
+	if stop <= 0 || pos.MarkPrice <= 0 {
+		return 0, false
+	}
+
+	var distance float64
+	if pos.Side == "long" {
+		distance = pos.MarkPrice - stop
+	} else {
+		distance = stop - pos.MarkPrice
+	}
+	// 价格已越过止损（尚未成交）时风险按0计，不抵消其他持仓
+	return math.Max(distance, 0) * pos.Quantity, true
+}
+
+// calculatePortfolioHeat 组合热度：所有持仓触发止损时的合计亏损占净值的百分比
+func calculatePortfolioHeat(positions []PositionInfo, equity float64) float64 {
+	if equity <= 0 {
+		return 0
+	}
+	total := 0.0
+	for _, pos := range positions {
+		if risk, ok := positionRiskUSD(pos); ok {
+			total += risk
+		}
+	}
+	return total / equity * 100
+}
+
+// decisionRiskUSD 估算开仓决策触发止损时的亏损（止损距离占入场价比例 × 仓位价值）
+// 入场价同 entryPriceFor: entry_price > 当前价 > 按止损止盈估算
+func decisionRiskUSD(d *Decision, ctx *Context) float64 {
+	entry := entryPriceFor(d, ctx)
+	if entry <= 0 || d.StopLoss <= 0 {
+		return 0
+	}
+	return math.Abs(entry-d.StopLoss) / entry * d.PositionSizeUSD
+}
+
+// defaultRiskUSDTolerancePct 模型给出的 risk_usd 与按仓位/止损重新计算的风险之间允许的默认偏差（%）
+const defaultRiskUSDTolerancePct = 20.0
+
+// riskUSDTolerancePct 返回 risk_usd 允许的偏差百分比（未配置时默认20）
+func (c RiskConfig) riskUSDTolerancePct() float64 {
+	if c.RiskUSDTolerancePct <= 0 {
+		return defaultRiskUSDTolerancePct
+	}
+	return c.RiskUSDTolerancePct
+}
+
+// checkRiskUSD 核对模型给出的 risk_usd 与 |入场 − 止损| / 入场 × 仓位 是否一致
+// 偏差超出容忍范围时按配置拒绝，或改写为重新计算的值（入场价同 entryPriceFor: entry_price > 当前价 > 按止损止盈估算）
+func checkRiskUSD(d *Decision, ctx *Context) error {
+	if d.RiskUSD <= 0 || d.StopLoss <= 0 || d.PositionSizeUSD <= 0 {
+		return nil
+	}
+
+	entry := entryPriceFor(d, ctx)
+	if entry <= 0 {
+		return nil
+	}
+	computed := math.Abs(entry-d.StopLoss) / entry * d.PositionSizeUSD
+	if computed <= 0 {
+		return nil
+	}
+
+	deviationPct := math.Abs(d.RiskUSD-computed) / computed * 100
+	tolerance := ctx.RiskConfig.riskUSDTolerancePct()
+	if deviationPct <= tolerance {
+		return nil
+	}
+	if ctx.RiskConfig.RejectRiskUSDMismatch {
+		return fmt.Errorf("risk_usd(%.2f) 与仓位和止损不符：按仓位%.2f、止损%.4f计算应为 %.2f，偏差%.0f%%超过%.0f%%",
+			d.RiskUSD, d.PositionSizeUSD, d.StopLoss, computed, deviationPct, tolerance)
+	}
+	log.Printf("⚠️  %s risk_usd(%.2f) 与计算值(%.2f)偏差%.0f%%，已更正", d.Symbol, d.RiskUSD, computed, deviationPct)
+	d.RiskUSD = computed
+	return nil
+}
+
+// projectedPortfolioHeat 现有持仓热度加上本批次开仓的风险
+func projectedPortfolioHeat(decisions []Decision, ctx *Context) float64 {
+	equity := ctx.Account.TotalEquity
+	if equity <= 0 {
+		return 0
+	}
+	heat := calculatePortfolioHeat(ctx.Positions, equity)
+	for i := range decisions {
+		if decisions[i].Action.IsOpen() {
+			heat += decisionRiskUSD(&decisions[i], ctx) / equity * 100
+		}
+	}
+	return heat
+}
@@ -0,0 +1,89 @@
+package decision
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCheckRiskUSDCorrectsInconsistentValue(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	// 入场100、止损95、仓位1000 → 风险应为 50，模型声称 500
+	d := longDecision("SOLUSDT")
+	d.RiskUSD = 500
+	if err := checkRiskUSD(&d, ctx); err != nil {
+		t.Fatalf("默认模式应更正而不是拒绝: %v", err)
+	}
+	if d.RiskUSD < 49.99 || d.RiskUSD > 50.01 {
+		t.Fatalf("risk_usd 应被更正为 50，得到 %.2f", d.RiskUSD)
+	}
+
+	ctx.RiskConfig.RejectRiskUSDMismatch = true
+	d.RiskUSD = 500
+	if err := checkRiskUSD(&d, ctx); err == nil {
+		t.Fatal("RejectRiskUSDMismatch 时不一致的 risk_usd 应被拒绝")
+	}
+}
+
+func TestCheckRiskUSDUsesEntryPrice(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.RejectRiskUSDMismatch = true
+
+	// 限价入场 97：|97 − 95| / 97 × 1000 ≈ 20.6（按当前价100计算会是50）
+	d := longDecision("SOLUSDT")
+	d.EntryPrice = 97
+	d.RiskUSD = 20.6
+	if err := checkRiskUSD(&d, ctx); err != nil {
+		t.Fatalf("按 entry_price 计算的 risk_usd 应通过: %v", err)
+	}
+}
+
+func TestValidateDecisionLiquidationUsesEntryPrice(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.BTCETHLeverage = 20
+
+	// 按当前价100估算强平价约95.5，止损95.6在其之前；
+	// 但限价入场 101 时强平价约96.5，止损已在强平价之外
+	d := longDecision("BTCUSDT")
+	d.Leverage = 20
+	d.EntryPrice = 101
+	d.StopLoss = 95.6
+	d.TakeProfit = 125
+	d.RiskUSD = 53.5
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); ruleCodeOf(err) != RuleLiquidation {
+		t.Fatalf("期望按 entry_price 估算强平价并拒绝，得到 %v", err)
+	}
+}
+
+func TestCalculatePortfolioHeatTwoPositions(t *testing.T) {
+	positions := []PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", MarkPrice: 100, Quantity: 10, StopLoss: 95},         // 风险 50
+		{Symbol: "ETHUSDT", Side: "short", MarkPrice: 50, Quantity: 20, LiquidationPrice: 60}, // 未记录止损，按强平价估算风险 200
+	}
+	if heat := calculatePortfolioHeat(positions, 10000); math.Abs(heat-2.5) > 1e-9 {
+		t.Fatalf("heat=%.4f, want 2.5", heat)
+	}
+
+	// 本批次开仓的风险计入预估热度：仓位1000，止损距离5% → 50
+	ctx := testContext()
+	ctx.Positions = positions
+	d := longDecision("SOLUSDT")
+	d.EntryPrice = 100
+	if heat := projectedPortfolioHeat([]Decision{d}, ctx); math.Abs(heat-3.0) > 1e-9 {
+		t.Fatalf("projected heat=%.4f, want 3.0", heat)
+	}
+}
+
+func TestProjectedPortfolioHeatUsesCurrentPrice(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	// 未给出 entry_price：按当前价100计算，止损95 → 风险 5% × 1000 = 50 → 0.5%
+	d := longDecision("SOLUSDT")
+	if heat := projectedPortfolioHeat([]Decision{d}, ctx); math.Abs(heat-0.5) > 1e-9 {
+		t.Fatalf("projected heat=%.4f, want 0.5", heat)
+	}
+}
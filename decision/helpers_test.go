@@ -0,0 +1,100 @@
+package decision
+
+import (
+	"fmt"
+	"sync"
+
+	"nofx/market"
+)
+
+// testMarketData 构造一份指标完整、流动性充足的市场数据（OI价值约 price × 1e6 USD）
+func testMarketData(symbol string, price float64) *market.Data {
+	return &market.Data{
+		Symbol:        symbol,
+		CurrentPrice:  price,
+		PriceChange1h: 0.5,
+		PriceChange4h: 1.5,
+		CurrentEMA20:  price * 0.99,
+		CurrentMACD:   0.1,
+		CurrentRSI7:   55,
+		OpenInterest:  &market.OIData{Latest: 1e9 / price, Average: 1e9 / price},
+		FundingRate:   0.0001,
+		IntradaySeries: &market.IntradayData{
+			MidPrices:   []float64{price * 0.99, price},
+			EMA20Values: []float64{price * 0.98, price * 0.99},
+			MACDValues:  []float64{0.05, 0.1},
+			RSI7Values:  []float64{50, 55},
+			RSI14Values: []float64{50, 54},
+		},
+		LongerTermContext: &market.LongerTermData{
+			EMA20:         price * 0.97,
+			EMA50:         price * 0.95,
+			ATR3:          price * 0.01,
+			ATR14:         price * 0.01,
+			CurrentVolume: 1000,
+			AverageVolume: 1000,
+			MACDValues:    []float64{0.1, 0.2},
+			RSI14Values:   []float64{55, 58},
+		},
+	}
+}
+
+// countingProvider 记录调用次数的数据源，返回 prices 中的价格（每次调用可修改）
+type countingProvider struct {
+	mu     sync.Mutex
+	prices map[string]float64
+	calls  map[string]int
+}
+
+func newCountingProvider(prices map[string]float64) *countingProvider {
+	return &countingProvider{prices: prices, calls: make(map[string]int)}
+}
+
+func (p *countingProvider) Get(symbol string) (*market.Data, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls[symbol]++
+	price, ok := p.prices[symbol]
+	if !ok {
+		return nil, fmt.Errorf("no data for %s", symbol)
+	}
+	return testMarketData(symbol, price), nil
+}
+
+func (p *countingProvider) callCount(symbol string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[symbol]
+}
+
+// testContext 构造一个净值 10000 USDT、无持仓的决策上下文
+func testContext() *Context {
+	return &Context{
+		CurrentTime:         "2026-01-01 00:00:00",
+		RuntimeMinutes:      60,
+		CallCount:           1,
+		ScanIntervalMinutes: 3,
+		Account: AccountInfo{
+			TotalEquity:      10000,
+			AvailableBalance: 10000,
+		},
+		BTCETHLeverage:  10,
+		AltcoinLeverage: 5,
+		MarketDataMap:   make(map[string]*market.Data),
+	}
+}
+
+// longDecision 构造一个参数合理的开多决策（入场约100，止损95，止盈115）
+func longDecision(symbol string) Decision {
+	return Decision{
+		Symbol:          symbol,
+		Action:          ActionOpenLong,
+		Leverage:        3,
+		PositionSizeUSD: 1000,
+		StopLoss:        95,
+		TakeProfit:      115,
+		Confidence:      80,
+		RiskUSD:         50,
+		Reasoning:       "test",
+	}
+}
@@ -0,0 +1,103 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"nofx/market"
+	"time"
+)
+
+const (
+	defaultMinHoldMinutes = 30  // 默认最小持仓时间（分钟）
+	minHoldFloorMinutes   = 10  // 按波动率缩短后的下限（分钟）
+	minHoldBaselineVolPct = 2.0 // 基准波动率：4小时ATR14占价格的百分比，超过则按比例缩短最小持仓时间
+)
+
+// minHoldMinutes 返回配置的最小持仓时间（未配置时默认30分钟）
+func (ctx *Context) minHoldMinutes() int {
+	if ctx.MinHoldMinutes <= 0 {
+		return defaultMinHoldMinutes
+	}
+	return ctx.MinHoldMinutes
+}
+
+// effectiveMinHoldMinutes 返回某币种实际适用的最小持仓时间
+// 开启 ScaleMinHoldByVolatility 时，4小时ATR14/价格 超过基准波动率的币种按比例缩短
+// （波动是基准的2倍则持仓时间减半），但不低于10分钟，也不高于配置值
+func (ctx *Context) effectiveMinHoldMinutes(data *market.Data) int {
+	base := ctx.minHoldMinutes()
+	if !ctx.ScaleMinHoldByVolatility {
+		return base
+	}
+
+	volPct, ok := volatilityPct(data)
+	if !ok || volPct <= minHoldBaselineVolPct {
+		return base
+	}
+
+	scaled := int(math.Round(float64(base) * minHoldBaselineVolPct / volPct))
+	floor := minHoldFloorMinutes
+	if base < floor {
+		floor = base
+	}
+	if scaled < floor {
+		return floor
+	}
+	return scaled
+}
+
+// volatilityPct 以4小时ATR14占当前价格的百分比衡量波动率
+func volatilityPct(data *market.Data) (float64, bool) {
+	if data == nil || data.LongerTermContext == nil || data.CurrentPrice <= 0 || data.LongerTermContext.ATR14 <= 0 {
+		return 0, false
+	}
+	return data.LongerTermContext.ATR14 / data.CurrentPrice * 100, true
+}
+
+// stopOrTargetReached 当前价是否已触及止损或止盈（止损止盈未知时视为未触及）
+func (p PositionInfo) stopOrTargetReached() bool {
+	if p.MarkPrice <= 0 {
+		return false
+	}
+	if p.Side == "long" {
+		return (p.StopLoss > 0 && p.MarkPrice <= p.StopLoss) || (p.TakeProfit > 0 && p.MarkPrice >= p.TakeProfit)
+	}
+	return (p.StopLoss > 0 && p.MarkPrice >= p.StopLoss) || (p.TakeProfit > 0 && p.MarkPrice <= p.TakeProfit)
+}
+
+// enforceMinHold 持仓时间未满最小持仓时间的平仓决策转为hold，返回被转换的决策数量
+// 例外: 已触及止损/止盈、接近强平价，或持仓时间未知（UpdateTime 为0）；AllowEarlyClose 时不检查
+func enforceMinHold(decisions []Decision, ctx *Context) int {
+	if ctx.AllowEarlyClose {
+		return 0
+	}
+
+	overridden := 0
+	for i := range decisions {
+		d := &decisions[i]
+		if !d.Action.IsClose() {
+			continue
+		}
+		pos, ok := ctx.findPosition(d.Symbol, d.Account)
+		if !ok || pos.UpdateTime <= 0 || pos.stopOrTargetReached() {
+			continue
+		}
+		if distance, ok := pos.liquidationDistancePct(); ok && distance < ctx.liquidationWarnPct() {
+			continue
+		}
+
+		minHold := time.Duration(ctx.effectiveMinHoldMinutes(ctx.MarketDataMap[d.Symbol])) * time.Minute
+		if held := pos.HoldingDuration(); held < minHold {
+			log.Printf("⚠️  %s 仅持仓 %s，未满最小持仓时间 %s，%s 已转为hold", d.Symbol, formatHoldingDuration(held), formatHoldingDuration(minHold), d.Action)
+			*d = Decision{
+				Symbol:    d.Symbol,
+				Account:   d.Account,
+				Action:    ActionHold,
+				Reasoning: fmt.Sprintf("[%s 已转为hold: 持仓%s，未满最小持仓时间%s] %s", d.Action, formatHoldingDuration(held), formatHoldingDuration(minHold), d.Reasoning),
+			}
+			overridden++
+		}
+	}
+	return overridden
+}
@@ -0,0 +1,88 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEffectiveMinHoldScalesWithVolatility(t *testing.T) {
+	ctx := testContext()
+	data := testMarketData("SOLUSDT", 100)
+	data.LongerTermContext.ATR14 = 4 // 4% 波动，是基准的2倍
+
+	if got := ctx.effectiveMinHoldMinutes(data); got != defaultMinHoldMinutes {
+		t.Fatalf("未开启时应使用配置值 %d，得到 %d", defaultMinHoldMinutes, got)
+	}
+
+	ctx.ScaleMinHoldByVolatility = true
+	if got := ctx.effectiveMinHoldMinutes(data); got != 15 {
+		t.Fatalf("波动为基准2倍时最小持仓应减半为15分钟，得到 %d", got)
+	}
+
+	// 极端波动不低于10分钟下限
+	data.LongerTermContext.ATR14 = 20
+	if got := ctx.effectiveMinHoldMinutes(data); got != minHoldFloorMinutes {
+		t.Fatalf("最小持仓时间不应低于 %d 分钟，得到 %d", minHoldFloorMinutes, got)
+	}
+
+	// 低波动不延长
+	data.LongerTermContext.ATR14 = 1
+	if got := ctx.effectiveMinHoldMinutes(data); got != defaultMinHoldMinutes {
+		t.Fatalf("低波动时应使用配置值，得到 %d", got)
+	}
+}
+
+// heldPositionContext 持有 SOLUSDT 多仓 age 时长的上下文（止损90、止盈120，当前价未触及）
+func heldPositionContext(age time.Duration) *Context {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{
+		Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 101, Quantity: 10, Leverage: 3,
+		LiquidationPrice: 67, StopLoss: 90, TakeProfit: 120,
+		UpdateTime: time.Now().Add(-age).UnixMilli(),
+	}}
+	return ctx
+}
+
+func TestMinHoldBlocksPrematureClose(t *testing.T) {
+	ctx := heldPositionContext(10 * time.Minute)
+	decisions := []Decision{{Symbol: "SOLUSDT", Action: ActionCloseLong, Reasoning: "获利了结"}}
+
+	if overridden := enforceMinHold(decisions, ctx); overridden != 1 || decisions[0].Action != ActionHold {
+		t.Fatalf("持仓10分钟时平仓应转为hold，得到 overridden=%d action=%s", overridden, decisions[0].Action)
+	}
+
+	// 已触及止盈时允许提前平仓
+	ctx.Positions[0].MarkPrice = 121
+	decisions = []Decision{{Symbol: "SOLUSDT", Action: ActionCloseLong, Reasoning: "止盈"}}
+	if overridden := enforceMinHold(decisions, ctx); overridden != 0 {
+		t.Fatal("已触及止盈时不应阻止平仓")
+	}
+}
+
+func TestMinHoldAllowsCloseAfterMinimum(t *testing.T) {
+	ctx := heldPositionContext(40 * time.Minute)
+	decisions := []Decision{{Symbol: "SOLUSDT", Action: ActionCloseLong, Reasoning: "获利了结"}}
+
+	if overridden := enforceMinHold(decisions, ctx); overridden != 0 || decisions[0].Action != ActionCloseLong {
+		t.Fatalf("持仓40分钟时应允许平仓，得到 overridden=%d action=%s", overridden, decisions[0].Action)
+	}
+
+	// 最小持仓时间可配置
+	ctx.MinHoldMinutes = 60
+	if overridden := enforceMinHold(decisions, ctx); overridden != 1 {
+		t.Fatal("最小持仓时间配置为60分钟时，持仓40分钟的平仓应被阻止")
+	}
+}
+
+func TestSystemPromptUsesConfiguredMinHold(t *testing.T) {
+	ctx := testContext()
+	ctx.MinHoldMinutes = 45
+	system := buildSystemPrompt(ctx.systemPromptOptions(10, 5))
+	if strings.Contains(system, "30 分钟") {
+		t.Fatal("system prompt 不应再写死30分钟")
+	}
+	if strings.Count(system, "45 分钟") != 3 {
+		t.Fatal("system prompt 中的最小持仓时间应全部使用配置值45分钟")
+	}
+}
@@ -0,0 +1,295 @@
+package decision
+
+import (
+	"fmt"
+	"nofx/market"
+)
+
+// 趋势标签
+const (
+	trendBullish = "看涨"
+	trendBearish = "看跌"
+	trendChoppy  = "震荡/不明确"
+)
+
+// trendCriteria 趋势判断依据（用于prompt说明）
+var trendCriteria = map[string]string{
+	trendBullish: "（价格 > EMA20, MACD > 0）",
+	trendBearish: "（价格 < EMA20, MACD < 0）",
+}
+
+// TrendLabel 根据最新价格、EMA20和MACD给出简单的趋势判断
+// 价格 > EMA20 且 MACD > 0 为看涨，价格 < EMA20 且 MACD < 0 为看跌，其余为震荡
+func TrendLabel(data *market.Data) (emoji, label string) {
+	if data == nil {
+		return "➡️", trendChoppy
+	}
+
+	if data.CurrentPrice > data.CurrentEMA20 && data.CurrentMACD > 0 {
+		return "📈", trendBullish
+	}
+	if data.CurrentPrice < data.CurrentEMA20 && data.CurrentMACD < 0 {
+		return "📉", trendBearish
+	}
+	return "➡️", trendChoppy
+}
+
+// isBTC4hBearish 判断BTC 4小时趋势是否明确下跌
+// 4小时涨跌幅为负，且4h EMA20 < EMA50（缺少4h数据时退化为 TrendLabel 看跌）
+func isBTC4hBearish(data *market.Data) bool {
+	if data == nil || data.PriceChange4h >= 0 {
+		return false
+	}
+	if data.LongerTermContext != nil && data.LongerTermContext.EMA50 > 0 {
+		return data.LongerTermContext.EMA20 < data.LongerTermContext.EMA50
+	}
+	_, label := TrendLabel(data)
+	return label == trendBearish
+}
+
+// trend4h 根据4小时数据判断主趋势
+// EMA20 > EMA50 且最新4h MACD > 0 为上升，EMA20 < EMA50 且 MACD < 0 为下跌，其余（含缺数据）为震荡
+func trend4h(data *market.Data) string {
+	if data == nil || data.LongerTermContext == nil || len(data.LongerTermContext.MACDValues) == 0 {
+		return trendChoppy
+	}
+	lt := data.LongerTermContext
+	macd := lt.MACDValues[len(lt.MACDValues)-1]
+	if lt.EMA20 > lt.EMA50 && macd > 0 {
+		return trendBullish
+	}
+	if lt.EMA20 < lt.EMA50 && macd < 0 {
+		return trendBearish
+	}
+	return trendChoppy
+}
+
+// TrendConflict 比较4小时主趋势与3分钟短线趋势，两者方向相反（一涨一跌）时视为冲突
+// 任一周期为震荡/不明确时不算冲突
+func TrendConflict(data *market.Data) (conflict bool, fourH, threeMin string) {
+	fourH = trend4h(data)
+	_, threeMin = TrendLabel(data)
+	conflict = (fourH == trendBullish && threeMin == trendBearish) ||
+		(fourH == trendBearish && threeMin == trendBullish)
+	return conflict, fourH, threeMin
+}
+
+// rsiDirectionLookback 判断RSI方向时与多少个数据点之前比较
+const rsiDirectionLookback = 3
+
+// confirmingIndicators 统计 {EMA位置, MACD符号, RSI方向} 中支持开仓方向的指标（3分钟数据）
+// 做多: 价格 > EMA20、MACD > 0、RSI7 上行；做空相反
+func confirmingIndicators(action Action, data *market.Data) []string {
+	if data == nil {
+		return nil
+	}
+	long := action == ActionOpenLong
+
+	var confirmed []string
+	if (long && data.CurrentPrice > data.CurrentEMA20) || (!long && data.CurrentPrice < data.CurrentEMA20) {
+		confirmed = append(confirmed, "EMA20")
+	}
+	if (long && data.CurrentMACD > 0) || (!long && data.CurrentMACD < 0) {
+		confirmed = append(confirmed, "MACD")
+	}
+	if data.IntradaySeries != nil && len(data.IntradaySeries.RSI7Values) > rsiDirectionLookback {
+		rsis := data.IntradaySeries.RSI7Values
+		change := rsis[len(rsis)-1] - rsis[len(rsis)-1-rsiDirectionLookback]
+		if (long && change > 0) || (!long && change < 0) {
+			confirmed = append(confirmed, "RSI")
+		}
+	}
+	return confirmed
+}
+
+// 背离检测参数
+const (
+	divergenceWindow    = 10  // 检测最近多少个3分钟数据点
+	divergenceMinRSIGap = 3.0 // 两个高点/低点的RSI至少相差该值才算背离
+)
+
+// DetectDivergence 检测最近窗口内价格与RSI7的背离（3分钟序列）
+// 将窗口分为前后两半，比较两半的价格极值及对应位置的RSI：
+// 价格创新高而RSI走低为看跌背离，价格创新低而RSI走高为看涨背离
+func DetectDivergence(data *market.Data) (string, bool) {
+	if data == nil || data.IntradaySeries == nil {
+		return "", false
+	}
+	prices, rsis := data.IntradaySeries.MidPrices, data.IntradaySeries.RSI7Values
+	n := len(prices)
+	if len(rsis) < n {
+		n = len(rsis)
+	}
+	if n > divergenceWindow {
+		n = divergenceWindow
+	}
+	if n < 4 {
+		return "", false
+	}
+	prices = prices[len(prices)-n:]
+	rsis = rsis[len(rsis)-n:]
+	half := n / 2
+
+	extreme := func(from, to int, higher bool) int {
+		best := from
+		for i := from + 1; i < to; i++ {
+			if (higher && prices[i] > prices[best]) || (!higher && prices[i] < prices[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+
+	firstHigh, secondHigh := extreme(0, half, true), extreme(half, n, true)
+	if prices[secondHigh] > prices[firstHigh] && rsis[firstHigh]-rsis[secondHigh] >= divergenceMinRSIGap {
+		return fmt.Sprintf("看跌背离（价格新高 %.4f > %.4f，RSI7 走低 %.1f < %.1f）",
+			prices[secondHigh], prices[firstHigh], rsis[secondHigh], rsis[firstHigh]), true
+	}
+
+	firstLow, secondLow := extreme(0, half, false), extreme(half, n, false)
+	if prices[secondLow] < prices[firstLow] && rsis[secondLow]-rsis[firstLow] >= divergenceMinRSIGap {
+		return fmt.Sprintf("看涨背离（价格新低 %.4f < %.4f，RSI7 走高 %.1f > %.1f）",
+			prices[secondLow], prices[firstLow], rsis[secondLow], rsis[firstLow]), true
+	}
+	return "", false
+}
+
+// OI/价格关系判定阈值
+const (
+	oiTrendMinChangePct    = 2.0 // 最新持仓量相对均值变化超过该百分比视为OI上升
+	priceTrendMinChangePct = 1.0 // 4小时涨跌幅超过该百分比视为价格有方向
+)
+
+// OI/价格关系
+const (
+	oiPriceStrongUp   = "OI上升+价格上涨（多头资金流入，强势上涨）"
+	oiPriceStrongDown = "OI上升+价格下跌（空头资金流入，强势下跌）"
+)
+
+// oiPriceRelation 根据持仓量变化和4小时涨跌判断资金推动的趋势方向
+// 只有OI明显上升时才有结论，OI下降或价格无方向时返回空串
+func oiPriceRelation(data *market.Data) string {
+	if data == nil || data.OpenInterest == nil || data.OpenInterest.Average <= 0 {
+		return ""
+	}
+	oiChangePct := (data.OpenInterest.Latest - data.OpenInterest.Average) / data.OpenInterest.Average * 100
+	if oiChangePct < oiTrendMinChangePct {
+		return ""
+	}
+	switch {
+	case data.PriceChange4h >= priceTrendMinChangePct:
+		return oiPriceStrongUp
+	case data.PriceChange4h <= -priceTrendMinChangePct:
+		return oiPriceStrongDown
+	default:
+		return ""
+	}
+}
+
+// indicatorValues 输出趋势判断所用的具体指标数值（用于详细的验证错误信息）
+func indicatorValues(symbol string, data *market.Data) string {
+	if data == nil {
+		return fmt.Sprintf("%s 无市场数据", symbol)
+	}
+	s := fmt.Sprintf("%s price=%.4f ema20=%.4f macd=%.4f rsi7=%.2f 4h=%+.2f%%",
+		symbol, data.CurrentPrice, data.CurrentEMA20, data.CurrentMACD, data.CurrentRSI7, data.PriceChange4h)
+	if data.LongerTermContext != nil {
+		s += fmt.Sprintf(" ema20_4h=%.4f ema50_4h=%.4f", data.LongerTermContext.EMA20, data.LongerTermContext.EMA50)
+	}
+	return s
+}
+
+// priceReturns 由价格序列计算逐期收益率
+func priceReturns(prices []float64) []float64 {
+	var returns []float64
+	for i := 1; i < len(prices); i++ {
+		if prices[i-1] > 0 {
+			returns = append(returns, (prices[i]-prices[i-1])/prices[i-1])
+		}
+	}
+	return returns
+}
+
+// betaToBTC 用3分钟中间价序列的收益率近似计算币种相对BTC的beta
+// beta = cov(币种收益, BTC收益) / var(BTC收益)
+func betaToBTC(coin, btc *market.Data) (float64, bool) {
+	if coin == nil || btc == nil || coin.IntradaySeries == nil || btc.IntradaySeries == nil {
+		return 0, false
+	}
+	if coin.Symbol == btc.Symbol {
+		return 1, true
+	}
+
+	coinReturns := priceReturns(coin.IntradaySeries.MidPrices)
+	btcReturns := priceReturns(btc.IntradaySeries.MidPrices)
+
+	// 对齐到相同长度（取最新的部分）
+	n := len(coinReturns)
+	if len(btcReturns) < n {
+		n = len(btcReturns)
+	}
+	if n < 2 {
+		return 0, false
+	}
+	coinReturns = coinReturns[len(coinReturns)-n:]
+	btcReturns = btcReturns[len(btcReturns)-n:]
+
+	coinMean, btcMean := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		coinMean += coinReturns[i]
+		btcMean += btcReturns[i]
+	}
+	coinMean /= float64(n)
+	btcMean /= float64(n)
+
+	covariance, variance := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		covariance += (coinReturns[i] - coinMean) * (btcReturns[i] - btcMean)
+		variance += (btcReturns[i] - btcMean) * (btcReturns[i] - btcMean)
+	}
+	if variance == 0 {
+		return 0, false
+	}
+	return covariance / variance, true
+}
+
+// calculatePortfolioBTCBeta 计算组合相对BTC的beta（按持仓名义价值占净值比例加权，空仓为负）
+// 缺少BTC数据或账户净值为0时返回 false
+func calculatePortfolioBTCBeta(ctx *Context) (float64, bool) {
+	btcData, ok := ctx.MarketDataMap["BTCUSDT"]
+	if !ok || ctx.Account.TotalEquity <= 0 || len(ctx.Positions) == 0 {
+		return 0, false
+	}
+
+	portfolioBeta := 0.0
+	for _, pos := range ctx.Positions {
+		beta, ok := betaToBTC(ctx.MarketDataMap[pos.Symbol], btcData)
+		if !ok {
+			continue
+		}
+		weight := pos.Quantity * pos.MarkPrice / ctx.Account.TotalEquity
+		if pos.Side == "short" {
+			weight = -weight
+		}
+		portfolioBeta += weight * beta
+	}
+	return portfolioBeta, true
+}
+
+// typicalCandleMove 3分钟K线的典型波动幅度（相邻中间价绝对变化的平均值）
+func typicalCandleMove(data *market.Data) (float64, bool) {
+	if data == nil || data.IntradaySeries == nil || len(data.IntradaySeries.MidPrices) < 2 {
+		return 0, false
+	}
+
+	prices := data.IntradaySeries.MidPrices
+	total := 0.0
+	for i := 1; i < len(prices); i++ {
+		diff := prices[i] - prices[i-1]
+		if diff < 0 {
+			diff = -diff
+		}
+		total += diff
+	}
+	return total / float64(len(prices)-1), true
+}
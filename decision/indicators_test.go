@@ -0,0 +1,99 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+func TestTrendLabel(t *testing.T) {
+	cases := []struct {
+		name      string
+		data      *market.Data
+		wantEmoji string
+		wantLabel string
+	}{
+		{"bullish", &market.Data{CurrentPrice: 101, CurrentEMA20: 100, CurrentMACD: 0.5}, "📈", trendBullish},
+		{"bearish", &market.Data{CurrentPrice: 99, CurrentEMA20: 100, CurrentMACD: -0.5}, "📉", trendBearish},
+		{"neutral", &market.Data{CurrentPrice: 101, CurrentEMA20: 100, CurrentMACD: -0.5}, "➡️", trendChoppy},
+		{"nil", nil, "➡️", trendChoppy},
+	}
+	for _, tc := range cases {
+		emoji, label := TrendLabel(tc.data)
+		if emoji != tc.wantEmoji || label != tc.wantLabel {
+			t.Errorf("%s: TrendLabel = %s %s，期望 %s %s", tc.name, emoji, label, tc.wantEmoji, tc.wantLabel)
+		}
+	}
+}
+
+func TestTrendConflictAligned(t *testing.T) {
+	conflict, fourH, threeMin := TrendConflict(testMarketData("SOLUSDT", 100))
+	if conflict || fourH != trendBullish || threeMin != trendBullish {
+		t.Fatalf("conflict=%v 4h=%s 3m=%s，期望双向上涨且无冲突", conflict, fourH, threeMin)
+	}
+}
+
+func TestTrendConflictDisagreeing(t *testing.T) {
+	data := bullish4hWithBearishShortTerm("SOLUSDT", 45)
+	conflict, fourH, threeMin := TrendConflict(data)
+	if !conflict || fourH != trendBullish || threeMin != trendBearish {
+		t.Fatalf("conflict=%v 4h=%s 3m=%s，期望4h上涨、3m下跌的冲突", conflict, fourH, threeMin)
+	}
+
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = data
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+	if !strings.Contains(buildUserPrompt(ctx), "冲突，按规则应选择 wait") {
+		t.Fatal("user prompt 应标注趋势冲突")
+	}
+
+	ctx.RiskConfig.RejectTrendConflict = true
+	d := shortDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil || !strings.Contains(err.Error(), "冲突") {
+		t.Fatalf("RejectTrendConflict 时冲突币种禁止开仓，得到 %v", err)
+	}
+}
+
+func TestDetectDivergenceBearish(t *testing.T) {
+	data := testMarketData("SOLUSDT", 110)
+	// 价格创新高（105 → 110），RSI7 走低（78 → 65）
+	data.IntradaySeries.MidPrices = []float64{100, 103, 105, 102, 100, 101, 104, 107, 110, 109}
+	data.IntradaySeries.RSI7Values = []float64{60, 70, 78, 65, 55, 56, 60, 63, 65, 62}
+
+	label, ok := DetectDivergence(data)
+	if !ok || !strings.Contains(label, "看跌背离") {
+		t.Fatalf("应检测到看跌背离，得到 %q ok=%v", label, ok)
+	}
+
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = data
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+	if !strings.Contains(buildUserPrompt(ctx), "**RSI背离**: 看跌背离") {
+		t.Fatal("user prompt 应渲染币种的RSI背离")
+	}
+}
+
+func TestDetectDivergenceBullish(t *testing.T) {
+	data := testMarketData("SOLUSDT", 90)
+	// 价格创新低（95 → 90），RSI7 走高（22 → 35）
+	data.IntradaySeries.MidPrices = []float64{100, 97, 95, 98, 100, 99, 96, 93, 90, 91}
+	data.IntradaySeries.RSI7Values = []float64{40, 30, 22, 35, 45, 44, 40, 37, 35, 38}
+
+	if label, ok := DetectDivergence(data); !ok || !strings.Contains(label, "看涨背离") {
+		t.Fatalf("应检测到看涨背离，得到 %q ok=%v", label, ok)
+	}
+}
+
+func TestDetectDivergenceNone(t *testing.T) {
+	data := testMarketData("SOLUSDT", 110)
+	// 价格和RSI同步走高
+	data.IntradaySeries.MidPrices = []float64{100, 103, 105, 102, 100, 101, 104, 107, 110, 109}
+	data.IntradaySeries.RSI7Values = []float64{60, 65, 68, 62, 58, 60, 66, 70, 75, 72}
+	if label, ok := DetectDivergence(data); ok {
+		t.Fatalf("价格与RSI同向时不应报告背离: %q", label)
+	}
+	if _, ok := DetectDivergence(testMarketData("SOLUSDT", 100)); ok {
+		t.Fatal("数据点不足时不应报告背离")
+	}
+}
@@ -0,0 +1,141 @@
+package decision
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// journalReasoningExcerpt 日志中理由摘录的最大字符数
+const journalReasoningExcerpt = 80
+
+// JournalEntry 交易日志中的一个周期
+type JournalEntry struct {
+	Cycle    int           `json:"cycle"`
+	Decision *FullDecision `json:"decision"`
+	// EntryPrices 开仓决策的入场参考价（entry_price，未给出时为决策时的当前价），用于复盘计算风险回报比
+	EntryPrices map[string]float64 `json:"entry_prices,omitempty"`
+}
+
+// Journal 跨周期累积的决策日志，可导出为CSV/JSON供复盘
+type Journal struct {
+	mu      sync.Mutex
+	entries []JournalEntry
+}
+
+// NewJournal 创建交易日志
+func NewJournal() *Journal {
+	return &Journal{}
+}
+
+// Append 追加一个周期的完整决策（周期号自动递增，从1开始）
+// ctx 为该周期的上下文，用于记录开仓决策的当前价；为 nil 时只记录决策中给出的 entry_price
+func (j *Journal) Append(fd *FullDecision, ctx *Context) {
+	if fd == nil {
+		return
+	}
+
+	entryPrices := make(map[string]float64)
+	for i := range fd.Decisions {
+		d := &fd.Decisions[i]
+		if !d.Action.IsOpen() {
+			continue
+		}
+		if price := journalEntryPrice(d, ctx); price > 0 {
+			entryPrices[d.Symbol] = price
+		}
+	}
+	if len(entryPrices) == 0 {
+		entryPrices = nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries = append(j.entries, JournalEntry{Cycle: len(j.entries) + 1, Decision: fd, EntryPrices: entryPrices})
+}
+
+// journalEntryPrice 开仓决策的实际参考价：entry_price > 决策时的当前价；都未知时返回0（不做估算）
+func journalEntryPrice(d *Decision, ctx *Context) float64 {
+	if d.EntryPrice > 0 {
+		return d.EntryPrice
+	}
+	if ctx != nil {
+		if marketData, ok := ctx.MarketDataMap[d.Symbol]; ok && marketData.CurrentPrice > 0 {
+			return marketData.CurrentPrice
+		}
+	}
+	return 0
+}
+
+// Entries 返回日志条目的副本
+func (j *Journal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]JournalEntry, len(j.entries))
+	copy(entries, j.entries)
+	return entries
+}
+
+// ExportCSV 导出CSV，每个决策一行
+// 列: cycle, timestamp, symbol, action, confidence, position_size_usd, risk_reward, reasoning
+// risk_reward 按记录的入场参考价计算，入场价未知时留空
+func (j *Journal) ExportCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"cycle", "timestamp", "symbol", "action", "confidence", "position_size_usd", "risk_reward", "reasoning"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("写入CSV表头失败: %w", err)
+	}
+
+	for _, entry := range j.Entries() {
+		for _, d := range entry.Decision.Decisions {
+			riskReward := ""
+			if d.Action.IsOpen() {
+				entryPrice := d.EntryPrice
+				if entryPrice <= 0 {
+					entryPrice = entry.EntryPrices[d.Symbol]
+				}
+				if entryPrice > 0 {
+					if _, _, ratio := calculateRiskReward(d.Action, entryPrice, d.StopLoss, d.TakeProfit); ratio > 0 {
+						riskReward = strconv.FormatFloat(ratio, 'f', 2, 64)
+					}
+				}
+			}
+
+			record := []string{
+				strconv.Itoa(entry.Cycle),
+				entry.Decision.Timestamp.Format("2006-01-02 15:04:05"),
+				d.Symbol,
+				string(d.Action),
+				strconv.Itoa(d.Confidence),
+				strconv.FormatFloat(d.PositionSizeUSD, 'f', 2, 64),
+				riskReward,
+				truncateRunes(d.Reasoning, journalReasoningExcerpt),
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("写入CSV记录失败: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ExportJSON 导出完整日志（JSON数组）
+func (j *Journal) ExportJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(j.Entries())
+}
+
+// truncateRunes 按字符（而非字节）截断字符串，避免截断中文
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,91 @@
+package decision
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleJournal() *Journal {
+	j := NewJournal()
+	open := longDecision("SOLUSDT")
+	open.Reasoning = strings.Repeat("突破", 50)
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	j.Append(&FullDecision{Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Decisions: []Decision{open}}, ctx)
+	j.Append(&FullDecision{Timestamp: time.Date(2026, 1, 1, 0, 3, 0, 0, time.UTC), Decisions: []Decision{
+		{Symbol: "SOLUSDT", Action: ActionHold, Confidence: 70, Reasoning: "继续持有"},
+		{Symbol: "BTCUSDT", Action: ActionWait, Reasoning: "观望"},
+	}}, ctx)
+	j.Append(nil, ctx) // 忽略
+	return j
+}
+
+func TestJournalExportCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleJournal().ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("读取CSV失败: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("期望表头+3行，得到 %d 行", len(records))
+	}
+	if strings.Join(records[0], ",") != "cycle,timestamp,symbol,action,confidence,position_size_usd,risk_reward,reasoning" {
+		t.Fatalf("表头 = %v", records[0])
+	}
+
+	open := records[1]
+	if open[0] != "1" || open[2] != "SOLUSDT" || open[3] != "open_long" || open[4] != "80" || open[5] != "1000.00" {
+		t.Fatalf("开仓行 = %v", open)
+	}
+	// 按记录的当前价100计算：风险5、收益15
+	if open[6] != "3.00" {
+		t.Fatalf("risk_reward = %q，期望 3.00", open[6])
+	}
+	if n := len([]rune(open[7])); n != journalReasoningExcerpt+3 {
+		t.Fatalf("理由应截断为 %d 个字符加省略号，得到 %d", journalReasoningExcerpt, n)
+	}
+
+	if hold := records[2]; hold[0] != "2" || hold[3] != "hold" || hold[6] != "" {
+		t.Fatalf("持有行 = %v", hold)
+	}
+}
+
+func TestJournalExportJSONRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleJournal().ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+
+	var entries []JournalEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("读取JSON失败: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Cycle != 2 || len(entries[1].Decision.Decisions) != 2 {
+		t.Fatalf("JSON 日志内容不符: %+v", entries)
+	}
+}
+
+func TestJournalExportCSVLeavesRiskRewardEmptyWithoutPrice(t *testing.T) {
+	j := NewJournal()
+	j.Append(&FullDecision{Decisions: []Decision{longDecision("SOLUSDT")}}, nil)
+
+	var buf bytes.Buffer
+	if err := j.ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("读取CSV失败: %v", err)
+	}
+	if records[1][6] != "" {
+		t.Fatalf("入场价未知时 risk_reward 应留空，得到 %q", records[1][6])
+	}
+}
@@ -0,0 +1,38 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUserPromptFlagsOverLeveragedLegacyPosition(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	// 山寨币杠杆上限已下调为 5x，旧仓位是 10x
+	legacy := PositionInfo{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 10, Leverage: 10}
+	ctx.Positions = []PositionInfo{legacy}
+
+	violations := ctx.positionLimitViolations(legacy)
+	if len(violations) != 1 || !strings.Contains(violations[0], "杠杆 10x > 上限 5x") {
+		t.Fatalf("positionLimitViolations = %v", violations)
+	}
+	if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, "超出当前风控上限") {
+		t.Fatal("user prompt 应标注超出当前风控上限的持仓")
+	}
+
+	// 符合当前规则的持仓不标注
+	ctx.Positions[0].Leverage = 3
+	if prompt := buildUserPrompt(ctx); strings.Contains(prompt, "超出当前风控上限") {
+		t.Fatal("符合当前规则的持仓不应被标注")
+	}
+}
+
+func TestPositionLimitViolationsOversizedPosition(t *testing.T) {
+	ctx := testContext()
+	// 山寨币单币种上限 1.5 × 10000 = 15000，旧仓位 20000
+	pos := PositionInfo{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 200, Leverage: 3}
+	violations := ctx.positionLimitViolations(pos)
+	if len(violations) != 1 || !strings.Contains(violations[0], "仓位价值") {
+		t.Fatalf("positionLimitViolations = %v", violations)
+	}
+}
@@ -0,0 +1,46 @@
+package decision
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSymbolLeverageCapOverridesBucket(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.SymbolLeverageCaps = map[string]int{"SOLUSDT": 8, "DOGEUSDT": 3, "BTCUSDT": 4}
+
+	cases := map[string]int{
+		"SOLUSDT":  8, // 高于山寨币默认 5x
+		"DOGEUSDT": 3, // 低于山寨币默认 5x
+		"BTCUSDT":  4, // 低于 BTC/ETH 默认 10x
+		"ETHUSDT":  10,
+		"BNBUSDT":  5,
+	}
+	for symbol, want := range cases {
+		if got, _ := ctx.symbolLimits(symbol, ctx.Account.TotalEquity); got != want {
+			t.Errorf("%s 杠杆上限 = %dx，期望 %dx", symbol, got, want)
+		}
+	}
+}
+
+func TestValidateDecisionRejectsLeverageAboveSymbolCap(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["DOGEUSDT"] = testMarketData("DOGEUSDT", 100)
+	ctx.RiskConfig.SymbolLeverageCaps = map[string]int{"DOGEUSDT": 3}
+
+	d := longDecision("DOGEUSDT")
+	d.Leverage = 4 // 山寨币默认允许，但超过 DOGE 专属上限
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); ruleCodeOf(err) != RuleLeverage {
+		t.Fatalf("期望 %s 拒绝，得到 %v", RuleLeverage, err)
+	}
+}
+
+func TestRelevantLeverageCapsOnlyListsPromptSymbols(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.SymbolLeverageCaps = map[string]int{"SOLUSDT": 8, "DOGEUSDT": 3}
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	if got := relevantLeverageCaps(ctx); !reflect.DeepEqual(got, []string{"SOLUSDT 8x"}) {
+		t.Fatalf("relevantLeverageCaps = %v", got)
+	}
+}
@@ -0,0 +1,30 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighLeverageRequiresHigherConfidence(t *testing.T) {
+	ctx := testContext()
+	ctx.BTCETHLeverage = 20
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.RiskConfig.LeverageConfidenceTiers = []LeverageConfidenceTier{
+		{AboveLeverage: 10, MinConfidence: 85},
+		{AboveLeverage: 15, MinConfidence: 90},
+	}
+
+	d := longDecision("BTCUSDT")
+	d.Leverage = 15
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "要求信心度≥85") {
+		t.Fatalf("15x 杠杆信心度80应被拒绝，得到 %v", err)
+	}
+
+	d = longDecision("BTCUSDT")
+	d.Leverage = 15
+	d.Confidence = 92
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("15x 杠杆信心度92应通过: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package decision
+
+import "testing"
+
+func TestSnapLeverage(t *testing.T) {
+	tiers := []int{3, 5, 10, 20}
+	cases := map[int]struct {
+		want int
+		ok   bool
+	}{
+		17: {10, true},
+		20: {20, true},
+		4:  {3, true},
+		2:  {0, false},
+	}
+	for leverage, tc := range cases {
+		got, ok := snapLeverage(leverage, tiers)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("snapLeverage(%d) = %d/%v，期望 %d/%v", leverage, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestValidateDecisionSnapsLeverageToTier(t *testing.T) {
+	ctx := testContext()
+	ctx.BTCETHLeverage = 20
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.RiskConfig.SymbolLeverageTiers = map[string][]int{"BTCUSDT": {3, 5, 10, 20}}
+
+	d := longDecision("BTCUSDT")
+	d.Leverage = 17
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if d.Leverage != 10 || d.OriginalLeverage != 17 {
+		t.Fatalf("杠杆应从 17x 向下对齐到 10x，得到 %dx（原始 %dx）", d.Leverage, d.OriginalLeverage)
+	}
+
+	// 拒绝模式
+	ctx.RiskConfig.RejectOffTierLeverage = true
+	d = longDecision("BTCUSDT")
+	d.Leverage = 17
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+		t.Fatal("RejectOffTierLeverage 时不在档位上的杠杆应被拒绝")
+	}
+}
@@ -0,0 +1,37 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLiquidationAlertLeadsPrompt(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{
+		// 距强平价 3%
+		{Symbol: "SOLUSDT", Side: "long", EntryPrice: 105, MarkPrice: 100, LiquidationPrice: 97, Quantity: 10, Leverage: 20},
+		// 距强平价 20%，不触发
+		{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, LiquidationPrice: 80, Quantity: 1, Leverage: 5},
+	}
+
+	user := buildUserPrompt(ctx)
+	if !strings.HasPrefix(user, "# 🚨 LIQUIDATION RISK") {
+		t.Fatal("接近强平的持仓应使强平风险警报位于prompt最前面")
+	}
+	alert := user[:strings.Index(user, "---")]
+	if !strings.Contains(alert, "SOLUSDT LONG") || !strings.Contains(alert, "3.00%") {
+		t.Fatalf("警报应列出距强平价3%%的持仓:\n%s", alert)
+	}
+	if strings.Contains(alert, "BTCUSDT") {
+		t.Fatal("距强平价较远的持仓不应出现在警报中")
+	}
+}
+
+func TestLiquidationAlertAbsentWhenSafe(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, LiquidationPrice: 80, Quantity: 1, Leverage: 5}}
+
+	if strings.Contains(buildUserPrompt(ctx), "LIQUIDATION RISK") {
+		t.Fatal("没有持仓接近强平价时不应输出警报")
+	}
+}
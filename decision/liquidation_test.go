@@ -0,0 +1,29 @@
+package decision
+
+import "testing"
+
+func TestValidateDecisionRejectsStopBeyondLiquidation(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.BTCETHLeverage = 20
+
+	// 20x 做多强平价约 95.5，止损 90 在强平价之外
+	d := longDecision("BTCUSDT")
+	d.Leverage = 20
+	d.StopLoss = 90
+	d.TakeProfit = 130
+	d.RiskUSD = 100
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); ruleCodeOf(err) != RuleLiquidation {
+		t.Fatalf("期望 %s 拒绝，得到 %v", RuleLiquidation, err)
+	}
+}
+
+func TestEstimateLiquidationPrice(t *testing.T) {
+	// 20x 做多: 100 × (1 − 0.05 + 0.005) = 95.5；做空: 100 × (1 + 0.05 − 0.005) = 104.5
+	if got := estimateLiquidationPrice(ActionOpenLong, 100, 20); got < 95.49 || got > 95.51 {
+		t.Fatalf("做多强平价 = %.4f，期望 95.5", got)
+	}
+	if got := estimateLiquidationPrice(ActionOpenShort, 100, 20); got < 104.49 || got > 104.51 {
+		t.Fatalf("做空强平价 = %.4f，期望 104.5", got)
+	}
+}
@@ -0,0 +1,49 @@
+package decision
+
+import (
+	"testing"
+
+	"nofx/market"
+)
+
+// oiValueContext 候选币种 SMALLUSDT 持仓价值为 10M USD，现有持仓 TINYUSDT 持仓价值为 1M USD
+func oiValueContext() *Context {
+	ctx := testContext()
+	ctx.MarketDataProvider = MarketDataProviderFunc(func(symbol string) (*market.Data, error) {
+		data := testMarketData(symbol, 100)
+		switch symbol {
+		case "SMALLUSDT":
+			data.OpenInterest = &market.OIData{Latest: 100_000, Average: 100_000}
+		case "TINYUSDT":
+			data.OpenInterest = &market.OIData{Latest: 10_000, Average: 10_000}
+		}
+		return data, nil
+	})
+	ctx.Positions = []PositionInfo{{Symbol: "TINYUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 3}}
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SMALLUSDT", Sources: []string{"ai500"}}}
+	return ctx
+}
+
+func TestMinOIValueUSDConfigurable(t *testing.T) {
+	stubOITop(t, nil, nil)
+
+	ctx := oiValueContext()
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["SMALLUSDT"]; ok {
+		t.Fatal("默认15M下限应过滤持仓价值10M的币种")
+	}
+	if _, ok := ctx.MarketDataMap["TINYUSDT"]; !ok {
+		t.Fatal("现有持仓不受流动性过滤影响")
+	}
+
+	ctx = oiValueContext()
+	ctx.MinOIValueUSD = 5_000_000
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["SMALLUSDT"]; !ok {
+		t.Fatal("下限调低到5M后持仓价值10M的币种应保留")
+	}
+}
@@ -0,0 +1,46 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDecisionShrinksOpenNearMarginCap(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.ScaleSizeByMarginHeadroom = true
+	// 已用 7000 / 净值 10000 = 70%，距离 80% 上限还剩 1000 USDT 保证金
+	ctx.Account.MarginUsed = 7000
+	ctx.Account.MarginUsedPct = 70
+
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 6000
+	d.RiskUSD = 300
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if want := 1000.0 * float64(d.Leverage); d.PositionSizeUSD > want+0.01 {
+		t.Fatalf("仓位应缩小到剩余额度内（≤ %.2f），得到 %.2f", want, d.PositionSizeUSD)
+	}
+}
+
+func TestMarginCapPctDrivesFreeMarginAndPrompts(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MarginCapPct = 50
+	ctx.Account.MarginUsed = 2000
+
+	if _, freeMargin := calculateAvailableSlots(ctx); freeMargin != 3000 {
+		t.Fatalf("freeMargin = %.2f，期望 50%% 上限下的 3000", freeMargin)
+	}
+
+	system, user, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	if !strings.Contains(system, "≤ 50%") || strings.Contains(system, "≤ 80%") {
+		t.Fatal("system prompt 应使用配置的保证金使用率上限 50%")
+	}
+	if !strings.Contains(user, "上限 50%") || strings.Contains(user, "上限 80%") {
+		t.Fatal("user prompt 应使用配置的保证金使用率上限 50%")
+	}
+}
@@ -0,0 +1,32 @@
+package decision
+
+import "testing"
+
+func TestMaxNewOpensTrimsToLimit(t *testing.T) {
+	ctx := testContext()
+	first, second := longDecision("SOLUSDT"), longDecision("ETHUSDT")
+	second.Confidence = 90
+	decisions := []Decision{first, second, {Symbol: "BTCUSDT", Action: ActionHold}}
+
+	if err := enforceMaxNewOpens(decisions, ctx); err != nil {
+		t.Fatalf("默认策略应裁剪而不是拒绝: %v", err)
+	}
+	// 默认每周期1个：保留信心度更高的 ETHUSDT
+	if decisions[0].Action != ActionWait || decisions[1].Action != ActionOpenLong || decisions[2].Action != ActionHold {
+		t.Fatalf("裁剪结果错误: %s %s %s", decisions[0].Action, decisions[1].Action, decisions[2].Action)
+	}
+}
+
+func TestMaxNewOpensRejectPolicy(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.RejectExcessOpens = true
+	decisions := []Decision{longDecision("SOLUSDT"), longDecision("ETHUSDT")}
+	if err := enforceMaxNewOpens(decisions, ctx); err == nil {
+		t.Fatal("拒绝策略下超出上限应返回错误")
+	}
+
+	ctx.RiskConfig.MaxNewOpensPerCycle = 2
+	if err := enforceMaxNewOpens(decisions, ctx); err != nil {
+		t.Fatalf("未超出上限时不应报错: %v", err)
+	}
+}
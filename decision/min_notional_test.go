@@ -0,0 +1,22 @@
+package decision
+
+import "testing"
+
+func TestBelowSymbolMinNotionalRejected(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.RiskConfig.SymbolMinNotional = map[string]float64{"BTCUSDT": 5000}
+
+	d := longDecision("BTCUSDT")
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || ruleCodeOf(err) != RuleMinNotional {
+		t.Fatalf("BTC 仓位 1000 低于最小下单金额 5000 应被拒绝，得到 %v", err)
+	}
+
+	d = longDecision("BTCUSDT")
+	d.PositionSizeUSD = 5000
+	d.RiskUSD = 250
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("达到最小下单金额时应通过: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package decision
+
+import "testing"
+
+func TestNetDirectionalConfidenceMixedOpens(t *testing.T) {
+	short := longDecision("ETHUSDT")
+	short.Action = ActionOpenShort
+	short.Confidence = 70
+
+	highLong := longDecision("SOLUSDT")
+	highLong.Confidence = 90
+
+	fd := &FullDecision{Decisions: []Decision{
+		longDecision("BTCUSDT"), // +80
+		highLong,                // +90
+		short,                   // −70
+		{Symbol: "XRPUSDT", Action: ActionCloseLong, Confidence: 95},
+		{Symbol: "DOGEUSDT", Action: ActionHold, Confidence: 60},
+	}}
+	if net := fd.NetDirectionalConfidence(); net != 100 {
+		t.Fatalf("NetDirectionalConfidence = %d, want 100", net)
+	}
+
+	if net := (&FullDecision{Decisions: []Decision{short}}).NetDirectionalConfidence(); net != -70 {
+		t.Fatalf("只有开空时应为负数，得到 %d", net)
+	}
+}
@@ -0,0 +1,60 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+// stopContext 持有入场100、现价105、止损95的 SOLUSDT 多仓
+func stopContext() *Context {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 105, Quantity: 10, Leverage: 5, StopLoss: 95}}
+	return ctx
+}
+
+func holdWithStop(newStop float64) Decision {
+	return Decision{Symbol: "SOLUSDT", Action: ActionHold, NewStopLoss: newStop, Reasoning: "调整止损"}
+}
+
+func TestLoosenedStopRejected(t *testing.T) {
+	ctx := stopContext()
+	d := holdWithStop(90)
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "禁止放宽止损") {
+		t.Fatalf("多仓止损从95下移到90应被拒绝，得到 %v", err)
+	}
+
+	ctx.RiskConfig.AllowStopLoosening = true
+	d = holdWithStop(90)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("AllowStopLoosening 时允许放宽: %v", err)
+	}
+}
+
+func TestTightenedStopAllowed(t *testing.T) {
+	ctx := stopContext()
+	d := holdWithStop(100)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("多仓止损上移到保本应允许: %v", err)
+	}
+
+	// 越过当前价的止损会立即触发
+	d = holdWithStop(106)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+		t.Fatal("新止损越过当前价时应被拒绝")
+	}
+}
+
+func TestLoosenedShortStopRejected(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "short", EntryPrice: 100, MarkPrice: 95, Quantity: 10, Leverage: 5, StopLoss: 105}}
+
+	d := holdWithStop(110)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+		t.Fatal("空仓止损从105上移到110应被拒绝")
+	}
+	d = holdWithStop(100)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("空仓止损下移应允许: %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package decision
+
+import "testing"
+
+func TestCloseDecisionWithOpenOnlyFields(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 105, Quantity: 10, Leverage: 3}}
+
+	d := Decision{Symbol: "SOLUSDT", Action: ActionCloseLong, StopLoss: 95, Reasoning: "止盈离场"}
+	if fields := openOnlyFields(&d); len(fields) != 1 || fields[0] != "stop_loss" {
+		t.Fatalf("openOnlyFields = %v，期望 [stop_loss]", fields)
+	}
+
+	// 默认只记录警告
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("默认模式下携带开仓参数的平仓只应警告: %v", err)
+	}
+
+	// 严格模式拒绝
+	ctx.StrictNonOpenFields = true
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+		t.Fatal("严格模式下携带 stop_loss 的平仓应被拒绝")
+	}
+
+	// 不携带开仓参数的平仓在严格模式下正常通过
+	clean := Decision{Symbol: "SOLUSDT", Action: ActionCloseLong, Reasoning: "止盈离场"}
+	if err := validateDecision(&clean, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("干净的平仓决策应通过: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationReport NormalizeAndValidate 的结构化结果
+type ValidationReport struct {
+	Normalizations []string            `json:"normalizations,omitempty"` // 标准化过程中做出的修改
+	Rejections     []DecisionRejection `json:"rejections,omitempty"`     // 被拒绝的决策及原因
+}
+
+// DecisionRejection 被拒绝的决策（Index 为原始下标，批次级别的拒绝为 -1）
+type DecisionRejection struct {
+	Index  int      `json:"index"`
+	Symbol string   `json:"symbol,omitempty"`
+	Action Action   `json:"action,omitempty"`
+	Code   RuleCode `json:"code,omitempty"` // 违反的规则编号（无法归类时为空）
+	Reason string   `json:"reason"`
+	Hint   string   `json:"hint,omitempty"` // 对应规则的修正建议
+}
+
+// Valid 是否没有任何决策被拒绝
+func (r ValidationReport) Valid() bool {
+	return len(r.Rejections) == 0
+}
+
+// NormalizeAndValidate 对决策列表做标准化（币种名、动作、信心度范围）后按 cfg 逐个验证
+// 字符串形式的信心度在JSON解析时已转换（见 Decision.UnmarshalJSON）；负数的价格/仓位不做修正，由验证拒绝
+// 与 GetFullDecision 内部的验证不同，单个决策失败不会拒绝整批：返回通过验证的决策和完整的报告，便于集成方直接使用
+func NormalizeAndValidate(decisions []Decision, ctx *Context, cfg RiskConfig) ([]Decision, ValidationReport) {
+	var report ValidationReport
+	if err := cfg.Validate(); err != nil {
+		report.Rejections = append(report.Rejections, DecisionRejection{Index: -1, Reason: fmt.Sprintf("风控配置无效: %v", err)})
+		return nil, report
+	}
+
+	vctx := *ctx
+	vctx.RiskConfig = cfg
+
+	normalized := make([]Decision, len(decisions))
+	for i, d := range decisions {
+		report.Normalizations = append(report.Normalizations, normalizeDecision(&d)...)
+		normalized[i] = d
+	}
+
+	// 批次级别的夏普停用/开仓数量/节流规则（可能把开仓转为wait）
+	if filtered := enforceSharpeHalt(normalized, &vctx); filtered > 0 {
+		report.Normalizations = append(report.Normalizations, fmt.Sprintf("夏普比率处于停用区间，%d 个开仓/加仓决策转为wait", filtered))
+	}
+	if err := enforceMaxNewOpens(normalized, &vctx); err != nil {
+		report.Rejections = append(report.Rejections, DecisionRejection{Index: -1, Reason: err.Error()})
+		return nil, report
+	}
+	enforceOpenThrottle(normalized, &vctx)
+	if overridden := enforceMinHold(normalized, &vctx); overridden > 0 {
+		report.Normalizations = append(report.Normalizations, fmt.Sprintf("%d 个平仓决策未满最小持仓时间，转为hold", overridden))
+	}
+
+	var accepted []Decision
+	for i := range normalized {
+		d := &normalized[i]
+		accountEquity := vctx.Account.TotalEquity
+		if d.Action.IsOpen() {
+			acct, err := vctx.accountFor(d.Account)
+			if err != nil {
+				report.Rejections = append(report.Rejections, DecisionRejection{Index: i, Symbol: d.Symbol, Action: d.Action, Reason: err.Error()})
+				continue
+			}
+			accountEquity = acct.TotalEquity
+		}
+		if err := validateDecisionCached(d, accountEquity, &vctx); err != nil {
+			report.Rejections = append(report.Rejections, DecisionRejection{
+				Index: i, Symbol: d.Symbol, Action: d.Action,
+				Code: ruleCodeOf(err), Reason: err.Error(), Hint: RemediationHint(err),
+			})
+			continue
+		}
+		accepted = append(accepted, *d)
+	}
+
+	// 组合层面的规则作用于通过验证的决策
+	for _, check := range []func([]Decision, *Context) error{validateGrossNotional, validateSameDirectionPositions} {
+		if err := check(accepted, &vctx); err != nil {
+			report.Rejections = append(report.Rejections, DecisionRejection{Index: -1, Reason: err.Error()})
+			return nil, report
+		}
+	}
+
+	return accepted, report
+}
+
+// normalizeDecision 标准化单个决策，返回所做的修改说明
+// 币种名大写并在缺少计价币种时补全 USDT 后缀，动作映射为标准值（含本地化动作词），信心度限制在0-100
+func normalizeDecision(d *Decision) []string {
+	var changes []string
+
+	if symbol := canonicalSymbol(d.Symbol); symbol != d.Symbol {
+		changes = append(changes, fmt.Sprintf("symbol %q → %q", d.Symbol, symbol))
+		d.Symbol = symbol
+	}
+	if action := normalizeAction(string(d.Action)); action != d.Action {
+		changes = append(changes, fmt.Sprintf("%s action %q → %q", d.Symbol, d.Action, action))
+		d.Action = action
+	}
+	if d.Confidence < 0 || d.Confidence > 100 {
+		clamped := d.Confidence
+		if clamped < 0 {
+			clamped = 0
+		} else {
+			clamped = 100
+		}
+		changes = append(changes, fmt.Sprintf("%s confidence %d → %d", d.Symbol, d.Confidence, clamped))
+		d.Confidence = clamped
+	}
+	return changes
+}
+
+// knownQuoteAssets 已知的计价币种后缀（带有这些后缀的币种名不再补全 USDT）
+var knownQuoteAssets = []string{"USDT", "USDC", "FDUSD", "BUSD", "TUSD"}
+
+// canonicalSymbol 标准化币种名（去空白、大写、去掉分隔符，缺少计价币种时补全 USDT）
+func canonicalSymbol(symbol string) string {
+	s := strings.ToUpper(strings.TrimSpace(symbol))
+	if s == "" {
+		return s
+	}
+	s = strings.NewReplacer("/", "", "-", "", "_", "").Replace(s)
+	for _, quote := range knownQuoteAssets {
+		if strings.HasSuffix(s, quote) && len(s) > len(quote) {
+			return s
+		}
+	}
+	return s + "USDT"
+}
@@ -0,0 +1,71 @@
+package decision
+
+import "testing"
+
+func TestNormalizeAndValidateCleansDecisions(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	d := longDecision(" sol/usdt ")
+	d.Action = "做多"
+	d.Confidence = 120
+
+	accepted, report := NormalizeAndValidate([]Decision{d}, ctx, RiskConfig{})
+	if !report.Valid() {
+		t.Fatalf("标准化后的决策应通过验证: %+v", report.Rejections)
+	}
+	if len(accepted) != 1 {
+		t.Fatalf("应返回1个通过验证的决策，得到 %d", len(accepted))
+	}
+	got := accepted[0]
+	if got.Symbol != "SOLUSDT" || got.Action != ActionOpenLong || got.Confidence != 100 {
+		t.Fatalf("标准化结果错误: symbol=%q action=%q confidence=%d", got.Symbol, got.Action, got.Confidence)
+	}
+	if len(report.Normalizations) != 3 {
+		t.Fatalf("应记录币种、动作、信心度3项标准化，得到 %v", report.Normalizations)
+	}
+}
+
+func TestNormalizeAndValidateReportsRejection(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+
+	bad := longDecision("BTCUSDT")
+	bad.TakeProfit = 101 // 风险回报比 1:0.2
+	decisions := []Decision{{Symbol: "SOLUSDT", Action: ActionWait, Reasoning: "观望"}, bad}
+
+	accepted, report := NormalizeAndValidate(decisions, ctx, RiskConfig{})
+	if len(accepted) != 1 || accepted[0].Symbol != "SOLUSDT" {
+		t.Fatalf("单个决策失败不应拒绝整批，得到 %+v", accepted)
+	}
+	if len(report.Rejections) != 1 {
+		t.Fatalf("应报告1个被拒绝的决策，得到 %+v", report.Rejections)
+	}
+	rejection := report.Rejections[0]
+	if rejection.Index != 1 || rejection.Symbol != "BTCUSDT" || rejection.Code != RuleRiskReward || rejection.Hint == "" {
+		t.Fatalf("拒绝报告内容错误: %+v", rejection)
+	}
+}
+
+func TestNormalizeAndValidateRejectsInvalidConfig(t *testing.T) {
+	ctx := testContext()
+	accepted, report := NormalizeAndValidate([]Decision{longDecision("SOLUSDT")}, ctx, RiskConfig{MaxPositions: -1})
+	if accepted != nil || len(report.Rejections) != 1 || report.Rejections[0].Index != -1 {
+		t.Fatalf("风控配置无效时应整批拒绝，得到 %+v / %+v", accepted, report)
+	}
+}
+
+func TestCanonicalSymbolKeepsKnownQuoteAssets(t *testing.T) {
+	cases := map[string]string{
+		"btc":       "BTCUSDT",
+		"eth/usdt":  "ETHUSDT",
+		"BTCUSDC":   "BTCUSDC",
+		"sol-fdusd": "SOLFDUSD",
+		"usdc":      "USDCUSDT",
+	}
+	for in, want := range cases {
+		if got := canonicalSymbol(in); got != want {
+			t.Errorf("canonicalSymbol(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
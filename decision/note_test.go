@@ -0,0 +1,31 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNoteValidatesWithMinimalFields(t *testing.T) {
+	ctx := testContext()
+
+	d := Decision{Symbol: "SOLUSDT", Action: ActionNote, Reasoning: "资金费率持续为负，观察空头拥挤"}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("只有 symbol 和 reasoning 的 note 应通过验证: %v", err)
+	}
+
+	d.Reasoning = "  "
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+		t.Fatal("缺少 reasoning 的 note 应被拒绝")
+	}
+}
+
+func TestNoteIsDocumentedInSystemPrompt(t *testing.T) {
+	ctx := testContext()
+	system, _, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	if !strings.Contains(system, "**note（可选）**") {
+		t.Fatal("system prompt 应说明可选的 note 动作")
+	}
+}
@@ -0,0 +1,47 @@
+package decision
+
+import (
+	"errors"
+	"testing"
+
+	"nofx/pool"
+)
+
+// stubOITop 替换 OI Top 数据源，测试结束后恢复
+func stubOITop(t *testing.T, positions []pool.OIPosition, err error) {
+	t.Helper()
+	orig := poolOITopPositions
+	poolOITopPositions = func() ([]pool.OIPosition, error) { return positions, err }
+	t.Cleanup(func() { poolOITopPositions = orig })
+}
+
+func TestFetchMarketDataRequireOITop(t *testing.T) {
+	stubOITop(t, nil, errors.New("oi top unavailable"))
+
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"BTCUSDT": 100})
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+	ctx.RequireOITop = true
+
+	if err := fetchMarketDataForContext(ctx); err == nil {
+		t.Fatal("RequireOITop 时 OI Top 加载失败应返回错误")
+	}
+}
+
+func TestFetchMarketDataOptionalOITop(t *testing.T) {
+	stubOITop(t, nil, errors.New("oi top unavailable"))
+
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"BTCUSDT": 100})
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("默认模式下 OI Top 加载失败不应中止决策: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["BTCUSDT"]; !ok {
+		t.Fatal("OI Top 失败时仍应获取持仓的市场数据")
+	}
+	if len(ctx.OITopDataMap) != 0 {
+		t.Fatalf("OI Top 失败时不应有 OI Top 数据: %v", ctx.OITopDataMap)
+	}
+}
@@ -0,0 +1,60 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+// risingOIRisingPrice OI 高于均值 10%、4小时上涨 1.5%（强势上涨），短线指标转空以满足开空的指标确认
+func risingOIRisingPrice(symbol string) *market.Data {
+	data := bullish4hWithBearishShortTerm(symbol, 55)
+	data.OpenInterest.Latest = data.OpenInterest.Average * 1.1
+	return data
+}
+
+func TestShortAgainstRisingOIRejected(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["ETHUSDT"] = risingOIRisingPrice("ETHUSDT")
+	ctx.RiskConfig.OITrendPolicy = oiTrendReject
+
+	d := shortDecision("ETHUSDT")
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "与OI/价格关系相反") {
+		t.Fatalf("OI上升+价格上涨时做空应被拒绝，得到 %v", err)
+	}
+
+	// 顺势做多不受影响
+	ctx.MarketDataMap["ETHUSDT"] = testMarketData("ETHUSDT", 100)
+	ctx.MarketDataMap["ETHUSDT"].OpenInterest.Latest *= 1.1
+	d = longDecision("ETHUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("顺着OI/价格关系开仓应通过: %v", err)
+	}
+}
+
+func TestShortAgainstRisingOIPenalized(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["ETHUSDT"] = risingOIRisingPrice("ETHUSDT")
+	ctx.RiskConfig.OITrendPolicy = oiTrendPenalize
+	ctx.RiskConfig.OITrendPenalty = 15
+
+	d := shortDecision("ETHUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("penalize 模式不应拒绝: %v", err)
+	}
+	if d.Confidence != 65 {
+		t.Fatalf("信心度应从 80 扣减到 65，得到 %d", d.Confidence)
+	}
+}
+
+func TestOITrendCheckDisabledByDefault(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["ETHUSDT"] = risingOIRisingPrice("ETHUSDT")
+
+	d := shortDecision("ETHUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil || d.Confidence != 80 {
+		t.Fatalf("未配置策略时不检查OI趋势: err=%v confidence=%d", err, d.Confidence)
+	}
+}
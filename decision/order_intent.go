@@ -0,0 +1,107 @@
+package decision
+
+import "fmt"
+
+// 订单方向
+const (
+	OrderSideBuy  = "BUY"
+	OrderSideSell = "SELL"
+)
+
+// 订单类型
+const (
+	OrderTypeMarket     = "MARKET"
+	OrderTypeLimit      = "LIMIT"
+	OrderTypeStop       = "STOP_MARKET"
+	OrderTypeTakeProfit = "TAKE_PROFIT_MARKET"
+)
+
+// OrderIntent 标准化的下单意图（与具体交易所无关）
+type OrderIntent struct {
+	Symbol       string  `json:"symbol"`
+	Side         string  `json:"side"`                    // BUY / SELL
+	Type         string  `json:"type"`                    // MARKET / LIMIT / STOP_MARKET / TAKE_PROFIT_MARKET
+	Quantity     float64 `json:"quantity"`                // 数量（币）；平仓时0表示全部平仓
+	Price        float64 `json:"price,omitempty"`         // 限价单价格
+	TriggerPrice float64 `json:"trigger_price,omitempty"` // 止损/止盈触发价
+	Leverage     int     `json:"leverage,omitempty"`
+	ReduceOnly   bool    `json:"reduce_only"`
+}
+
+// ToOrderIntents 将决策转换为可执行的下单意图
+// 开仓：市价入场单 + 只减仓的止损单和止盈单（数量 = 仓位USD / 当前价格）
+// 平仓：只减仓的市价单（数量为0表示全部平仓）
+// hold/wait/note 不产生订单
+func (d Decision) ToOrderIntents(currentPrice float64) ([]OrderIntent, error) {
+	switch d.Action {
+	case ActionOpenLong, ActionOpenShort:
+		if currentPrice <= 0 {
+			return nil, fmt.Errorf("%s 当前价格无效: %.4f", d.Symbol, currentPrice)
+		}
+		if d.PositionSizeUSD <= 0 {
+			return nil, fmt.Errorf("%s 仓位大小必须大于0: %.2f", d.Symbol, d.PositionSizeUSD)
+		}
+
+		entrySide, exitSide := OrderSideBuy, OrderSideSell
+		if d.Action == ActionOpenShort {
+			entrySide, exitSide = OrderSideSell, OrderSideBuy
+		}
+		quantity := d.PositionSizeUSD / currentPrice
+
+		intents := []OrderIntent{{
+			Symbol:   d.Symbol,
+			Side:     entrySide,
+			Type:     OrderTypeMarket,
+			Quantity: quantity,
+			Leverage: d.Leverage,
+		}}
+		if d.StopLoss > 0 {
+			intents = append(intents, OrderIntent{
+				Symbol:       d.Symbol,
+				Side:         exitSide,
+				Type:         OrderTypeStop,
+				Quantity:     quantity,
+				TriggerPrice: d.StopLoss,
+				ReduceOnly:   true,
+			})
+		}
+		if d.TakeProfit > 0 {
+			intents = append(intents, OrderIntent{
+				Symbol:       d.Symbol,
+				Side:         exitSide,
+				Type:         OrderTypeTakeProfit,
+				Quantity:     quantity,
+				TriggerPrice: d.TakeProfit,
+				ReduceOnly:   true,
+			})
+		}
+		return intents, nil
+
+	case ActionCloseLong, ActionCloseShort:
+		side := OrderSideSell
+		if d.Action == ActionCloseShort {
+			side = OrderSideBuy
+		}
+		return []OrderIntent{{
+			Symbol:     d.Symbol,
+			Side:       side,
+			Type:       OrderTypeMarket,
+			Quantity:   0, // 全部平仓
+			ReduceOnly: true,
+		}}, nil
+
+	case ActionScaleIn:
+		// 加仓方向取决于现有持仓，单凭决策无法确定
+		return nil, fmt.Errorf("%s scale_in 需要结合现有持仓方向生成订单", d.Symbol)
+
+	case ActionReduceAll:
+		// 减仓数量取决于每个现有持仓，单凭决策无法确定
+		return nil, fmt.Errorf("reduce_all 需要结合现有持仓生成订单")
+
+	case ActionHold, ActionWait, ActionNote, ActionRequestData:
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("无效的action: %s", d.Action)
+	}
+}
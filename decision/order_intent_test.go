@@ -0,0 +1,45 @@
+package decision
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToOrderIntentsOpenWithBracket(t *testing.T) {
+	d := longDecision("SOLUSDT")
+	intents, err := d.ToOrderIntents(100)
+	if err != nil {
+		t.Fatalf("ToOrderIntents: %v", err)
+	}
+
+	want := []OrderIntent{
+		{Symbol: "SOLUSDT", Side: OrderSideBuy, Type: OrderTypeMarket, Quantity: 10, Leverage: 3},
+		{Symbol: "SOLUSDT", Side: OrderSideSell, Type: OrderTypeStop, Quantity: 10, TriggerPrice: 95, ReduceOnly: true},
+		{Symbol: "SOLUSDT", Side: OrderSideSell, Type: OrderTypeTakeProfit, Quantity: 10, TriggerPrice: 115, ReduceOnly: true},
+	}
+	if !reflect.DeepEqual(intents, want) {
+		t.Fatalf("ToOrderIntents =\n%+v\n期望\n%+v", intents, want)
+	}
+}
+
+func TestToOrderIntentsReduceOnlyClose(t *testing.T) {
+	d := Decision{Symbol: "ETHUSDT", Action: ActionCloseShort}
+	intents, err := d.ToOrderIntents(2000)
+	if err != nil {
+		t.Fatalf("ToOrderIntents: %v", err)
+	}
+	want := []OrderIntent{{Symbol: "ETHUSDT", Side: OrderSideBuy, Type: OrderTypeMarket, Quantity: 0, ReduceOnly: true}}
+	if !reflect.DeepEqual(intents, want) {
+		t.Fatalf("ToOrderIntents = %+v，期望 %+v", intents, want)
+	}
+}
+
+func TestToOrderIntentsErrors(t *testing.T) {
+	d := longDecision("SOLUSDT")
+	if _, err := d.ToOrderIntents(0); err == nil {
+		t.Fatal("当前价格无效时应返回错误")
+	}
+	if intents, err := (Decision{Symbol: "SOLUSDT", Action: ActionWait}).ToOrderIntents(100); err != nil || intents != nil {
+		t.Fatalf("wait 不应产生订单，得到 %v / %v", intents, err)
+	}
+}
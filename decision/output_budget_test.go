@@ -0,0 +1,59 @@
+package decision
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nofx/mcp"
+)
+
+// fakeAIClient 返回指向本地假AI服务的客户端（每次调用都回复 response）
+func fakeAIClient(t *testing.T, response string) *mcp.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": response}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	client := mcp.New()
+	client.SetCustomAPI(server.URL, "test-key", "test-model")
+	return client
+}
+
+func TestOutputTokenBudgetInSystemPrompt(t *testing.T) {
+	ctx := testContext()
+	ctx.MaxOutputTokens = 1500
+	if !strings.Contains(buildSystemPrompt(ctx.systemPromptOptions(10, 5)), "不超过约 1500 tokens") {
+		t.Fatal("system prompt 应包含输出token预算")
+	}
+	ctx.MaxOutputTokens = 0
+	if strings.Contains(buildSystemPrompt(ctx.systemPromptOptions(10, 5)), "输出长度预算") {
+		t.Fatal("未配置预算时不应写入输出长度预算")
+	}
+}
+
+func TestOutputTokenOverageRecorded(t *testing.T) {
+	stubOITop(t, nil, nil)
+	response := strings.Repeat("持仓走势正常，继续持有。", 20) + "\n" +
+		`[{"symbol":"BTCUSDT","action":"hold","reasoning":"趋势未变"}]`
+
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"BTCUSDT": 100})
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+	ctx.MaxOutputTokens = 50
+
+	decision, err := GetFullDecision(ctx, fakeAIClient(t, response))
+	if err != nil {
+		t.Fatalf("GetFullDecision: %v", err)
+	}
+	if decision.OutputTokenBudget != 50 {
+		t.Fatalf("应记录输出预算 50，得到 %d", decision.OutputTokenBudget)
+	}
+	if decision.OutputTokens <= decision.OutputTokenBudget {
+		t.Fatalf("输出约 %d tokens 应记录为超出预算 %d", decision.OutputTokens, decision.OutputTokenBudget)
+	}
+}
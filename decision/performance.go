@@ -0,0 +1,215 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TradeOutcome 单笔交易结果（与 logger.TradeOutcome 的JSON字段对应）
+type TradeOutcome struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`
+	OpenPrice  float64   `json:"open_price"`
+	ClosePrice float64   `json:"close_price"`
+	PnL        float64   `json:"pn_l"`
+	PnLPct     float64   `json:"pn_l_pct"`
+	Duration   string    `json:"duration"`
+	Confidence int       `json:"confidence,omitempty"` // 开仓时的信心度（未记录时为0）
+	CloseTime  time.Time `json:"close_time"`
+}
+
+// SymbolPerformance 币种表现统计
+type SymbolPerformance struct {
+	Symbol        string  `json:"symbol"`
+	TotalTrades   int     `json:"total_trades"`
+	WinningTrades int     `json:"winning_trades"`
+	LosingTrades  int     `json:"losing_trades"`
+	WinRate       float64 `json:"win_rate"`
+	TotalPnL      float64 `json:"total_pn_l"`
+	AvgPnL        float64 `json:"avg_pn_l"`
+}
+
+// PerformanceData 历史表现数据（由 Context.Performance 解析而来）
+type PerformanceData struct {
+	TotalTrades   int                           `json:"total_trades"`
+	WinningTrades int                           `json:"winning_trades"`
+	LosingTrades  int                           `json:"losing_trades"`
+	WinRate       float64                       `json:"win_rate"`
+	AvgWin        float64                       `json:"avg_win"`
+	AvgLoss       float64                       `json:"avg_loss"`
+	ProfitFactor  float64                       `json:"profit_factor"`
+	SharpeRatio   float64                       `json:"sharpe_ratio"`
+	RecentTrades  []TradeOutcome                `json:"recent_trades"` // 解析后按平仓时间从旧到新排列（logger 输出为最新在前）
+	SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`
+	BestSymbol    string                        `json:"best_symbol"`
+	WorstSymbol   string                        `json:"worst_symbol"`
+}
+
+// parsePerformance 宽松地解析历史表现数据
+// 未知字段忽略、缺失字段取零值；单个字段类型不匹配时只丢弃该字段，返回失败的字段名，
+// 只有整体不是JSON对象时才返回错误
+func parsePerformance(performance interface{}) (*PerformanceData, []string, error) {
+	jsonData, err := json.Marshal(performance)
+	if err != nil {
+		return nil, nil, fmt.Errorf("序列化表现数据失败: %w", err)
+	}
+
+	var perfData PerformanceData
+	if err := json.Unmarshal(jsonData, &perfData); err == nil {
+		perfData.fillBestWorst()
+		perfData.sortRecentTrades()
+		return &perfData, nil, nil
+	}
+
+	// 整体解析失败：逐字段解析，尽量保留可用数据
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &raw); err != nil {
+		return nil, nil, fmt.Errorf("表现数据不是有效的JSON对象: %w", err)
+	}
+
+	perfData = PerformanceData{}
+	var failedFields []string
+	v := reflect.ValueOf(&perfData).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		value, ok := raw[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, v.Field(i).Addr().Interface()); err != nil {
+			v.Field(i).Set(reflect.Zero(t.Field(i).Type))
+			failedFields = append(failedFields, name)
+		}
+	}
+
+	perfData.fillBestWorst()
+	perfData.sortRecentTrades()
+	return &perfData, failedFields, nil
+}
+
+// calibrationMinTrades 计算信心度校准时，盈利和亏损交易各自至少需要的笔数
+const calibrationMinTrades = 3
+
+// confidenceCalibration 计算最近盈利/亏损交易的平均信心度（只统计记录了信心度的交易）
+// 任一侧样本不足时返回 false
+func confidenceCalibration(trades []TradeOutcome) (avgWin, avgLoss float64, ok bool) {
+	var winSum, lossSum, winCount, lossCount int
+	for _, trade := range trades {
+		if trade.Confidence <= 0 {
+			continue
+		}
+		if trade.PnL > 0 {
+			winSum += trade.Confidence
+			winCount++
+		} else if trade.PnL < 0 {
+			lossSum += trade.Confidence
+			lossCount++
+		}
+	}
+	if winCount < calibrationMinTrades || lossCount < calibrationMinTrades {
+		return 0, 0, false
+	}
+	return float64(winSum) / float64(winCount), float64(lossSum) / float64(lossCount), true
+}
+
+// sortRecentTrades 按平仓时间把最近交易排为从旧到新（最后一个是最新的一笔）
+// logger.AnalyzePerformance 输出的顺序是最新在前，包内统一按时间排序后使用
+func (p *PerformanceData) sortRecentTrades() {
+	sortTradesByCloseTime(p.RecentTrades)
+}
+
+// sortTradesByCloseTime 按平仓时间从旧到新原地排序
+// 有交易缺少平仓时间时无法可靠排序，保持原顺序（视为已从旧到新）
+func sortTradesByCloseTime(trades []TradeOutcome) {
+	for _, trade := range trades {
+		if trade.CloseTime.IsZero() {
+			return
+		}
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].CloseTime.Before(trades[j].CloseTime)
+	})
+}
+
+// fillBestWorst 上游未提供最佳/最差币种时，根据 SymbolStats 在本地计算
+func (p *PerformanceData) fillBestWorst() {
+	if p.BestSymbol != "" && p.WorstSymbol != "" {
+		return
+	}
+	best, worst := selectBestWorst(p.SymbolStats)
+	if p.BestSymbol == "" {
+		p.BestSymbol = best
+	}
+	if p.WorstSymbol == "" {
+		p.WorstSymbol = worst
+	}
+}
+
+// selectBestWorst 按总盈亏选出表现最佳和最差的币种
+// 盈亏相同时按币种名字母序取第一个，保证结果稳定（不受map遍历顺序影响）
+func selectBestWorst(stats map[string]*SymbolPerformance) (best, worst string) {
+	symbols := make([]string, 0, len(stats))
+	for symbol, stat := range stats {
+		if stat != nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return "", ""
+	}
+	sort.Strings(symbols)
+
+	best, worst = symbols[0], symbols[0]
+	for _, symbol := range symbols[1:] {
+		pnl := stats[symbol].TotalPnL
+		if pnl > stats[best].TotalPnL {
+			best = symbol
+		}
+		if pnl < stats[worst].TotalPnL {
+			worst = symbol
+		}
+	}
+	return best, worst
+}
+
+// DecayedStats 按时间衰减加权后的胜率和盈亏比
+type DecayedStats struct {
+	WinRate      float64 // 加权胜率（百分比）
+	ProfitFactor float64 // 加权盈亏比（没有亏损时为0）
+}
+
+// ComputeDecayedStats 按交易先后做指数衰减加权，计算胜率和盈亏比
+// trades 带平仓时间时按平仓时间确定先后（不修改传入的切片），否则视为已从旧到新排列；halfLife 为半衰期（以交易笔数计），即往前数 halfLife 笔的交易权重减半
+// 没有交易或 halfLife <= 0 时返回 false
+func ComputeDecayedStats(trades []TradeOutcome, halfLife float64) (DecayedStats, bool) {
+	if len(trades) == 0 || halfLife <= 0 {
+		return DecayedStats{}, false
+	}
+	trades = append([]TradeOutcome(nil), trades...)
+	sortTradesByCloseTime(trades)
+
+	var totalWeight, winWeight, grossWin, grossLoss float64
+	for i, trade := range trades {
+		age := float64(len(trades) - 1 - i) // 最新一笔 age=0
+		weight := math.Pow(0.5, age/halfLife)
+		totalWeight += weight
+		if trade.PnL > 0 {
+			winWeight += weight
+			grossWin += weight * trade.PnL
+		} else if trade.PnL < 0 {
+			grossLoss += weight * -trade.PnL
+		}
+	}
+
+	stats := DecayedStats{WinRate: winWeight / totalWeight * 100}
+	if grossLoss > 0 {
+		stats.ProfitFactor = grossWin / grossLoss
+	}
+	return stats, true
+}
@@ -0,0 +1,199 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newestFirstPerformance 模拟 logger.AnalyzePerformance 的输出：RecentTrades 最新在前
+func newestFirstPerformance(now time.Time, pnls ...float64) *PerformanceData {
+	perf := &PerformanceData{}
+	for i, pnl := range pnls {
+		perf.RecentTrades = append(perf.RecentTrades, TradeOutcome{
+			Symbol:    "BTCUSDT",
+			Side:      "long",
+			PnL:       pnl,
+			PnLPct:    pnl,
+			CloseTime: now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	return perf
+}
+
+func TestParsePerformanceOrdersRecentTradesByCloseTime(t *testing.T) {
+	now := time.Now()
+	perfData, _, err := parsePerformance(newestFirstPerformance(now, 1, 2, 3))
+	if err != nil {
+		t.Fatalf("parsePerformance: %v", err)
+	}
+	for i := 1; i < len(perfData.RecentTrades); i++ {
+		if perfData.RecentTrades[i].CloseTime.Before(perfData.RecentTrades[i-1].CloseTime) {
+			t.Fatalf("RecentTrades 未按平仓时间从旧到新排列: %+v", perfData.RecentTrades)
+		}
+	}
+	if last := perfData.RecentTrades[len(perfData.RecentTrades)-1]; last.PnL != 1 {
+		t.Fatalf("最新一笔应为 PnL=1，得到 %.0f", last.PnL)
+	}
+}
+
+func TestBigWinCooldownUsesNewestTrade(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.BigWinPnLPct = 20
+	ctx.RiskConfig.BigWinCooldownMinutes = 60
+
+	// 最新一笔是大赢，更早的一笔是小亏
+	ctx.Performance = newestFirstPerformance(time.Now(), 30, -5)
+	if active, lastPnLPct := ctx.bigWinCooldown(); !active || lastPnLPct != 30 {
+		t.Fatalf("最新一笔大赢应触发冷却，得到 active=%v lastPnLPct=%.2f", active, lastPnLPct)
+	}
+
+	// 大赢是更早的一笔，最新一笔是小亏
+	ctx.Performance = newestFirstPerformance(time.Now(), -5, 30)
+	if active, lastPnLPct := ctx.bigWinCooldown(); active || lastPnLPct != -5 {
+		t.Fatalf("更早的大赢不应触发冷却，得到 active=%v lastPnLPct=%.2f", active, lastPnLPct)
+	}
+}
+
+func TestConsecutiveLossesCountsFromNewest(t *testing.T) {
+	ctx := testContext()
+	ctx.Performance = newestFirstPerformance(time.Now(), -1, -2, -3, 10, -4)
+	if got := ctx.consecutiveLosses(); got != 3 {
+		t.Fatalf("consecutiveLosses = %d，期望 3", got)
+	}
+
+	ctx.Performance = newestFirstPerformance(time.Now(), 10, -1, -2, -3)
+	if got := ctx.consecutiveLosses(); got != 0 {
+		t.Fatalf("最新一笔盈利时 consecutiveLosses = %d，期望 0", got)
+	}
+}
+
+func TestComputeDecayedStatsWeightsNewestByCloseTime(t *testing.T) {
+	// 最新一笔盈利，之后全是更早的亏损；半衰期很短时加权胜率应由最新一笔主导
+	trades := newestFirstPerformance(time.Now(), 10, -1, -1, -1, -1).RecentTrades
+	stats, ok := ComputeDecayedStats(trades, 0.5)
+	if !ok {
+		t.Fatal("ComputeDecayedStats 返回 false")
+	}
+	if stats.WinRate < 50 {
+		t.Fatalf("最新一笔盈利应主导加权胜率，得到 %.2f%%", stats.WinRate)
+	}
+	if trades[0].PnL != 10 {
+		t.Fatal("ComputeDecayedStats 不应修改传入的切片")
+	}
+}
+
+func TestDecayedWinRateDominatedByRecentLosses(t *testing.T) {
+	// 最近3笔亏损，更早的7笔盈利：整体胜率70%，加权胜率应低于50%
+	perf := newestFirstPerformance(time.Now(), -5, -5, -5, 5, 5, 5, 5, 5, 5, 5)
+	perf.TotalTrades, perf.WinningTrades, perf.LosingTrades = 10, 7, 3
+	perf.WinRate = 70
+
+	stats, ok := ComputeDecayedStats(perf.RecentTrades, 2)
+	if !ok {
+		t.Fatal("ComputeDecayedStats 返回 false")
+	}
+	if stats.WinRate >= 50 {
+		t.Fatalf("近期连续亏损应拉低加权胜率，得到 %.2f%%", stats.WinRate)
+	}
+
+	ctx := testContext()
+	ctx.Performance = perf
+	ctx.PerformanceHalfLife = 2
+	user := buildUserPrompt(ctx)
+	if !strings.Contains(user, "近期加权胜率") || !strings.Contains(user, "近期表现明显差于整体统计") {
+		t.Fatal("配置半衰期后 prompt 应显示加权统计并提示近期表现变差")
+	}
+}
+
+func TestParsePerformancePartialPayload(t *testing.T) {
+	payload := map[string]interface{}{
+		"total_trades":  12,
+		"win_rate":      "58%", // 类型不匹配：只丢弃该字段
+		"sharpe_ratio":  0.8,
+		"renamed_field": "ignored",
+	}
+
+	perfData, failedFields, err := parsePerformance(payload)
+	if err != nil {
+		t.Fatalf("部分匹配的表现数据不应整体失败: %v", err)
+	}
+	if perfData.TotalTrades != 12 || perfData.SharpeRatio != 0.8 {
+		t.Fatalf("可解析的字段应保留，得到 %+v", perfData)
+	}
+	if len(failedFields) != 1 || failedFields[0] != "win_rate" {
+		t.Fatalf("failedFields = %v，期望 [win_rate]", failedFields)
+	}
+
+	ctx := testContext()
+	ctx.Performance = payload
+	prompt := buildUserPrompt(ctx)
+	if !strings.Contains(prompt, "部分历史表现数据解析失败（win_rate）") {
+		t.Fatal("user prompt 应提示部分字段解析失败")
+	}
+	if !strings.Contains(prompt, "0.8") {
+		t.Fatal("user prompt 应保留解析成功的夏普比率")
+	}
+}
+
+func TestParsePerformanceRejectsNonObject(t *testing.T) {
+	if _, _, err := parsePerformance([]int{1, 2, 3}); err == nil {
+		t.Fatal("非JSON对象的表现数据应返回错误")
+	}
+}
+
+func TestSelectBestWorstTieBreaksAlphabetically(t *testing.T) {
+	stats := map[string]*SymbolPerformance{
+		"SOLUSDT":  {Symbol: "SOLUSDT", TotalPnL: 50},
+		"BTCUSDT":  {Symbol: "BTCUSDT", TotalPnL: 50},
+		"XRPUSDT":  {Symbol: "XRPUSDT", TotalPnL: -20},
+		"ETHUSDT":  {Symbol: "ETHUSDT", TotalPnL: -20},
+		"DOGEUSDT": nil,
+	}
+	// 多次调用，确保结果不受 map 遍历顺序影响
+	for i := 0; i < 20; i++ {
+		best, worst := selectBestWorst(stats)
+		if best != "BTCUSDT" || worst != "ETHUSDT" {
+			t.Fatalf("best=%s worst=%s, want BTCUSDT/ETHUSDT", best, worst)
+		}
+	}
+
+	if best, worst := selectBestWorst(nil); best != "" || worst != "" {
+		t.Fatalf("无数据时应返回空, got %s/%s", best, worst)
+	}
+}
+
+// tradesWithConfidence 交替生成盈利和亏损交易（按给定信心度）
+func tradesWithConfidence(winConf, lossConf int, pairs int) []TradeOutcome {
+	var trades []TradeOutcome
+	for i := 0; i < pairs; i++ {
+		trades = append(trades,
+			TradeOutcome{Symbol: "BTCUSDT", PnL: 10, Confidence: winConf},
+			TradeOutcome{Symbol: "BTCUSDT", PnL: -10, Confidence: lossConf})
+	}
+	return trades
+}
+
+func TestCalibrationNoteForUncalibratedHistory(t *testing.T) {
+	var sb strings.Builder
+	writeCalibrationNote(&sb, &PerformanceData{RecentTrades: tradesWithConfidence(82, 80, 5)}, 5)
+	if !strings.Contains(sb.String(), "没有区分度") {
+		t.Fatal("盈亏交易的平均信心度相近时应提示未校准")
+	}
+
+	sb.Reset()
+	writeCalibrationNote(&sb, &PerformanceData{RecentTrades: tradesWithConfidence(88, 70, 5)}, 5)
+	if sb.Len() != 0 {
+		t.Fatalf("信心度有区分度时不应提示: %s", sb.String())
+	}
+}
+
+func TestConfidenceCalibrationIgnoresUnrecordedConfidence(t *testing.T) {
+	if _, _, ok := confidenceCalibration(tradesWithConfidence(0, 0, 5)); ok {
+		t.Fatal("未记录信心度的交易不应参与统计")
+	}
+	avgWin, avgLoss, ok := confidenceCalibration(tradesWithConfidence(90, 60, calibrationMinTrades))
+	if !ok || avgWin != 90 || avgLoss != 60 {
+		t.Fatalf("avgWin=%.1f avgLoss=%.1f ok=%v", avgWin, avgLoss, ok)
+	}
+}
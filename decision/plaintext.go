@@ -0,0 +1,45 @@
+package decision
+
+import (
+	"strings"
+	"unicode"
+)
+
+// toPlainText 将富文本prompt转为纯文本：去除emoji和加粗标记，保留标题、列表和全部规则内容
+// 部分本地模型在大量emoji/markdown下表现变差
+func toPlainText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		var sb strings.Builder
+		for _, r := range line {
+			if isEmojiRune(r) {
+				continue
+			}
+			sb.WriteRune(r)
+		}
+		line = strings.ReplaceAll(sb.String(), "**", "")
+
+		// 保留行首缩进，合并去除emoji后留下的多余空格
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		indent := line[:len(line)-len(trimmed)]
+		lines[i] = strings.TrimRightFunc(indent+strings.Join(strings.Fields(trimmed), " "), unicode.IsSpace)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isEmojiRune 判断是否为emoji（或emoji的组合修饰符）
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // 各类表情、符号与象形文字
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // 杂项符号与装饰符号（✅ ❌ ⚠ ➡ 等）
+		return true
+	case r >= 0x23E9 && r <= 0x23FA: // ⏩ ⏳ 等
+		return true
+	case r >= 0x2B05 && r <= 0x2B55: // ⬆ ⭐ 等
+		return true
+	case r == 0xFE0F || r == 0x200D: // 变体选择符与零宽连接符
+		return true
+	}
+	return false
+}
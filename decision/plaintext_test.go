@@ -0,0 +1,47 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func containsEmoji(s string) (rune, bool) {
+	for _, r := range s {
+		if isEmojiRune(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+func TestPlainTextPromptsHaveNoEmoji(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"BTCUSDT": 100})
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 95, MarkPrice: 100, Quantity: 10, Leverage: 5, UnrealizedPnL: 50, UnrealizedPnLPct: 26}}
+
+	richSystem, richUser, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	if _, ok := containsEmoji(richSystem + richUser); !ok {
+		t.Fatal("默认的富文本 prompt 应包含emoji")
+	}
+
+	ctx.PlainText = true
+	system, user, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	for name, prompt := range map[string]string{"system": system, "user": user} {
+		if r, ok := containsEmoji(prompt); ok {
+			t.Errorf("%s prompt 包含emoji %q", name, r)
+		}
+		if strings.Contains(prompt, "**") {
+			t.Errorf("%s prompt 仍包含加粗标记", name)
+		}
+	}
+	// 规则内容保留
+	if !strings.Contains(system, "风险回报比") || !strings.Contains(system, "# ") {
+		t.Fatal("纯文本 system prompt 应保留规则和标题")
+	}
+}
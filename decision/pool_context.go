@@ -0,0 +1,42 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"nofx/pool"
+)
+
+// 候选币种数据源（默认使用 pool 包，可替换以便离线调试）
+var (
+	poolTopRatedCoins  = pool.GetTopRatedCoins
+	poolOITopPositions = pool.GetOITopPositions
+)
+
+// BuildContextFromPool 从 pool 包获取 AI500 评分前 ai500Limit 个币种和 OI Top 币种，合并来源后填入新 Context 的 CandidateCoins
+// 顺序为 AI500 评分顺序在前、OI Top 排名在后，两边都有的币种合并来源；账户、持仓等其余字段由调用方补充。
+// 单个数据源失败只记录日志，两个数据源都失败时返回错误
+func BuildContextFromPool(ai500Limit int) (*Context, error) {
+	var candidates []CandidateCoin
+
+	ai500Symbols, ai500Err := poolTopRatedCoins(ai500Limit)
+	if ai500Err != nil {
+		log.Printf("⚠️  获取AI500数据失败: %v", ai500Err)
+	}
+	for _, symbol := range ai500Symbols {
+		candidates = append(candidates, CandidateCoin{Symbol: symbol, Sources: []string{"ai500"}})
+	}
+
+	oiPositions, oiErr := poolOITopPositions()
+	if oiErr != nil {
+		log.Printf("⚠️  获取OI Top数据失败: %v", oiErr)
+	}
+	for _, pos := range oiPositions {
+		candidates = append(candidates, CandidateCoin{Symbol: pos.Symbol, Sources: []string{"oi_top"}})
+	}
+
+	if ai500Err != nil && oiErr != nil {
+		return nil, fmt.Errorf("获取候选币种失败: AI500: %v; OI Top: %w", ai500Err, oiErr)
+	}
+
+	return &Context{CandidateCoins: dedupeCandidates(candidates)}, nil
+}
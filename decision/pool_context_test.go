@@ -0,0 +1,44 @@
+package decision
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"nofx/pool"
+)
+
+// stubTopRated 替换 AI500 数据源，测试结束后恢复
+func stubTopRated(t *testing.T, symbols []string, err error) {
+	t.Helper()
+	orig := poolTopRatedCoins
+	poolTopRatedCoins = func(int) ([]string, error) { return symbols, err }
+	t.Cleanup(func() { poolTopRatedCoins = orig })
+}
+
+func TestBuildContextFromPoolMergesSources(t *testing.T) {
+	stubTopRated(t, []string{"BTCUSDT", "SOLUSDT"}, nil)
+	stubOITop(t, []pool.OIPosition{{Symbol: "SOLUSDT", Rank: 1}, {Symbol: "DOGEUSDT", Rank: 2}}, nil)
+
+	ctx, err := BuildContextFromPool(2)
+	if err != nil {
+		t.Fatalf("BuildContextFromPool: %v", err)
+	}
+	want := []CandidateCoin{
+		{Symbol: "BTCUSDT", Sources: []string{"ai500"}},
+		{Symbol: "SOLUSDT", Sources: []string{"ai500", "oi_top"}},
+		{Symbol: "DOGEUSDT", Sources: []string{"oi_top"}},
+	}
+	if !reflect.DeepEqual(ctx.CandidateCoins, want) {
+		t.Fatalf("候选币种合并错误:\n得到 %+v\n期望 %+v", ctx.CandidateCoins, want)
+	}
+}
+
+func TestBuildContextFromPoolFailsWhenBothSourcesFail(t *testing.T) {
+	stubTopRated(t, nil, errors.New("ai500 unavailable"))
+	stubOITop(t, nil, errors.New("oi top unavailable"))
+
+	if _, err := BuildContextFromPool(5); err == nil {
+		t.Fatal("两个数据源都失败时应返回错误")
+	}
+}
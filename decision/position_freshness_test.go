@@ -0,0 +1,78 @@
+package decision
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"nofx/market"
+)
+
+// freshnessSpy 记录每个币种是走普通路径还是带时效要求的路径
+type freshnessSpy struct {
+	mu     sync.Mutex
+	plain  map[string]int
+	fresh  map[string]time.Duration
+	prices map[string]float64
+}
+
+func newFreshnessSpy(prices map[string]float64) *freshnessSpy {
+	return &freshnessSpy{plain: make(map[string]int), fresh: make(map[string]time.Duration), prices: prices}
+}
+
+func (p *freshnessSpy) Get(symbol string) (*market.Data, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.plain[symbol]++
+	return testMarketData(symbol, p.prices[symbol]), nil
+}
+
+func (p *freshnessSpy) GetFresh(symbol string, maxAge time.Duration) (*market.Data, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fresh[symbol] = maxAge
+	return testMarketData(symbol, p.prices[symbol]), nil
+}
+
+func TestPositionSymbolsUseStrictFreshness(t *testing.T) {
+	stubOITop(t, nil, nil)
+	spy := newFreshnessSpy(map[string]float64{"BTCUSDT": 100, "SOLUSDT": 100})
+
+	ctx := testContext()
+	ctx.MarketDataProvider = spy
+	ctx.PositionDataMaxAge = 10 * time.Second
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if maxAge, ok := spy.fresh["BTCUSDT"]; !ok || maxAge != 10*time.Second || spy.plain["BTCUSDT"] != 0 {
+		t.Fatalf("持仓币种应只走时效控制路径，得到 fresh=%v plain=%d", spy.fresh, spy.plain["BTCUSDT"])
+	}
+	if _, ok := spy.fresh["SOLUSDT"]; ok || spy.plain["SOLUSDT"] != 1 {
+		t.Fatalf("候选币种应走普通路径，得到 fresh=%v plain=%d", spy.fresh, spy.plain["SOLUSDT"])
+	}
+}
+
+func TestPositionSymbolsUseSeparateProvider(t *testing.T) {
+	stubOITop(t, nil, nil)
+	candidates := newCountingProvider(map[string]float64{"BTCUSDT": 100, "SOLUSDT": 100})
+	positions := newCountingProvider(map[string]float64{"BTCUSDT": 100})
+
+	ctx := testContext()
+	ctx.MarketDataProvider = candidates
+	ctx.PositionDataProvider = positions
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if positions.callCount("BTCUSDT") != 1 || candidates.callCount("BTCUSDT") != 0 {
+		t.Fatal("持仓币种应使用单独配置的数据源")
+	}
+	if candidates.callCount("SOLUSDT") != 1 {
+		t.Fatal("候选币种应使用默认数据源")
+	}
+}
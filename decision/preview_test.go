@@ -0,0 +1,56 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreviewPromptsForPopulatedContext(t *testing.T) {
+	stubOITop(t, nil, nil)
+
+	provider := newCountingProvider(map[string]float64{"BTCUSDT": 100, "SOLUSDT": 20})
+	ctx := testContext()
+	ctx.MarketDataProvider = provider
+	ctx.DryRun = true
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+
+	system, user, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	if system == "" || user == "" {
+		t.Fatal("system 和 user prompt 都不应为空")
+	}
+	if !strings.Contains(user, "BTCUSDT") || !strings.Contains(user, "SOLUSDT") {
+		t.Fatal("user prompt 应包含持仓和候选币种的数据")
+	}
+	if provider.callCount("BTCUSDT") == 0 || provider.callCount("SOLUSDT") == 0 {
+		t.Fatal("预览应获取市场数据")
+	}
+}
+
+func TestPreviewPromptsLeavesContextUntouched(t *testing.T) {
+	stubOITop(t, nil, nil)
+
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"SOLUSDT": 20})
+	ctx.CandidateCoins = []CandidateCoin{
+		{Symbol: "SOLUSDT", Sources: []string{"ai500"}},
+		{Symbol: "SOLUSDT", Sources: []string{"oi_top"}},
+	}
+	before := len(ctx.MarketDataMap)
+
+	if _, _, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage); err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	if len(ctx.MarketDataMap) != before {
+		t.Fatal("预览不应改写调用方的 MarketDataMap")
+	}
+	if len(ctx.CandidateCoins) != 2 {
+		t.Fatal("预览不应改写调用方的 CandidateCoins")
+	}
+	if ctx.PromptReport != nil {
+		t.Fatal("预览不应改写调用方的 PromptReport")
+	}
+}
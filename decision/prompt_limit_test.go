@@ -0,0 +1,25 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetFullDecisionRejectsOversizedPrompt(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(map[string]float64{"BTCUSDT": 100})
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5}}
+	ctx.MaxPromptChars = 500
+
+	var callbackErr error
+	ctx.OnError = func(err error) { callbackErr = err }
+
+	// prompt 超限时在调用AI之前返回，不需要可用的客户端
+	_, err := GetFullDecision(ctx, nil)
+	if err == nil || !strings.Contains(err.Error(), "prompt过长") || !strings.Contains(err.Error(), "超过上限 500") {
+		t.Fatalf("prompt 超过硬上限时应返回明确错误，得到 %v", err)
+	}
+	if callbackErr != err {
+		t.Fatal("错误应同样传给 OnError 回调")
+	}
+}
@@ -0,0 +1,32 @@
+package decision
+
+import "fmt"
+
+// PromptReport 记录 user prompt 实际包含/省略了哪些段落以及做过的截断
+// 用于排查"模型为什么没有考虑X"（由 buildUserPrompt 写入 Context.PromptReport）
+type PromptReport struct {
+	Included    []string `json:"included"`              // 已包含的段落
+	Omitted     []string `json:"omitted,omitempty"`     // 被省略的段落及原因
+	Truncations []string `json:"truncations,omitempty"` // 截断/精简操作
+}
+
+// include 记录已包含的段落
+func (r *PromptReport) include(section string) {
+	if r != nil {
+		r.Included = append(r.Included, section)
+	}
+}
+
+// omit 记录被省略的段落及原因
+func (r *PromptReport) omit(section, reason string) {
+	if r != nil {
+		r.Omitted = append(r.Omitted, fmt.Sprintf("%s: %s", section, reason))
+	}
+}
+
+// truncate 记录截断/精简操作
+func (r *PromptReport) truncate(format string, args ...interface{}) {
+	if r != nil {
+		r.Truncations = append(r.Truncations, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,32 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptReportListsDisabledSection(t *testing.T) {
+	ctx := fullBookContext()
+	ctx.OnlyManagePositionsWhenFull = true
+
+	buildUserPrompt(ctx)
+	report := ctx.PromptReport
+	if report == nil {
+		t.Fatal("buildUserPrompt 应写入 PromptReport")
+	}
+	omitted := strings.Join(report.Omitted, "\n")
+	if !strings.Contains(omitted, SectionCandidates+": ") {
+		t.Fatalf("持仓已满时候选币种段落应记为省略，得到 %v", report.Omitted)
+	}
+	for _, section := range report.Included {
+		if section == SectionCandidates {
+			t.Fatal("被省略的段落不应同时记为已包含")
+		}
+	}
+
+	ctx.OnlyManagePositionsWhenFull = false
+	buildUserPrompt(ctx)
+	if omitted := strings.Join(ctx.PromptReport.Omitted, "\n"); strings.Contains(omitted, SectionCandidates+": ") {
+		t.Fatalf("关闭 OnlyManagePositionsWhenFull 后候选币种不应被省略，得到 %v", ctx.PromptReport.Omitted)
+	}
+}
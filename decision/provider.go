@@ -0,0 +1,57 @@
+package decision
+
+import (
+	"time"
+
+	"nofx/market"
+)
+
+// MarketDataProvider 市场数据来源（可替换为缓存、mock或其他交易所数据）
+type MarketDataProvider interface {
+	Get(symbol string) (*market.Data, error)
+}
+
+// MarketDataProviderFunc 将普通函数适配为 MarketDataProvider
+type MarketDataProviderFunc func(symbol string) (*market.Data, error)
+
+// Get 实现 MarketDataProvider
+func (f MarketDataProviderFunc) Get(symbol string) (*market.Data, error) {
+	return f(symbol)
+}
+
+// defaultMarketDataProvider 默认数据源（market.Get）
+var defaultMarketDataProvider MarketDataProvider = MarketDataProviderFunc(market.Get)
+
+// marketDataProvider 返回上下文使用的数据源（未注入时使用 market.Get）
+func (ctx *Context) marketDataProvider() MarketDataProvider {
+	if ctx.MarketDataProvider != nil {
+		return ctx.MarketDataProvider
+	}
+	return defaultMarketDataProvider
+}
+
+// FreshnessAwareProvider 支持指定数据最大时效的数据源（如带缓存的数据源：缓存数据超过 maxAge 时重新拉取）
+type FreshnessAwareProvider interface {
+	MarketDataProvider
+	GetFresh(symbol string, maxAge time.Duration) (*market.Data, error)
+}
+
+// positionProvider 返回持仓币种使用的数据源（未单独配置时与候选币种相同）
+func (ctx *Context) positionProvider() MarketDataProvider {
+	if ctx.PositionDataProvider != nil {
+		return ctx.PositionDataProvider
+	}
+	return ctx.marketDataProvider()
+}
+
+// fetchPositionMarketData 获取持仓币种的市场数据
+// 平仓/持有决策依赖最新价格：配置了 PositionDataMaxAge 且数据源支持时效控制时，要求数据不超过该时效
+func (ctx *Context) fetchPositionMarketData(symbol string) (*market.Data, error) {
+	provider := ctx.positionProvider()
+	if ctx.PositionDataMaxAge > 0 {
+		if fresh, ok := provider.(FreshnessAwareProvider); ok {
+			return fresh.GetFresh(symbol, ctx.PositionDataMaxAge)
+		}
+	}
+	return provider.Get(symbol)
+}
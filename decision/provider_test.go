@@ -0,0 +1,23 @@
+package decision
+
+import (
+	"testing"
+
+	"nofx/market"
+)
+
+func TestInjectedProviderSuppliesMarketData(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = MarketDataProviderFunc(func(symbol string) (*market.Data, error) {
+		return testMarketData(symbol, 42), nil
+	})
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	data, ok := ctx.MarketDataMap["SOLUSDT"]
+	if !ok || data.CurrentPrice != 42 {
+		t.Fatalf("应使用注入数据源返回的数据，得到 %+v", data)
+	}
+}
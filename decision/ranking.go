@@ -0,0 +1,86 @@
+package decision
+
+import (
+	"log"
+	"math/rand"
+	"sort"
+)
+
+// rankingMinTrades 币种历史交易数少于该值时胜率不可靠，不参与重排
+const rankingMinTrades = 3
+
+// defaultJitterBandSize 候选币种随机扰动的默认分组大小
+const defaultJitterBandSize = 3
+
+// jitterCandidates 将候选币种按排名每 bandSize 个分为一组，在组内随机打乱顺序
+// 多个机器人使用同一prompt时会扎堆同一批交易，组内扰动可轻微分散关注点而不打乱整体排名；
+// 相同 seed 得到相同顺序
+func jitterCandidates(candidates []CandidateCoin, seed int64, bandSize int) []CandidateCoin {
+	if bandSize <= 0 {
+		bandSize = defaultJitterBandSize
+	}
+	if len(candidates) < 2 || bandSize < 2 {
+		return candidates
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	shuffled := make([]CandidateCoin, len(candidates))
+	copy(shuffled, candidates)
+	for start := 0; start < len(shuffled); start += bandSize {
+		end := start + bandSize
+		if end > len(shuffled) {
+			end = len(shuffled)
+		}
+		band := shuffled[start:end]
+		rng.Shuffle(len(band), func(i, j int) {
+			band[i], band[j] = band[j], band[i]
+		})
+	}
+	return shuffled
+}
+
+// applyCandidateJitter 按 ctx.CandidateJitterSeed 对 ctx.CandidateCoins 做组内扰动（seed 为0时不扰动）
+func applyCandidateJitter(ctx *Context) {
+	if ctx.CandidateJitterSeed == 0 {
+		return
+	}
+	ctx.CandidateCoins = jitterCandidates(ctx.CandidateCoins, ctx.CandidateJitterSeed, ctx.CandidateJitterBand)
+}
+
+// rankCandidatesByWinRate 按模型在各币种上的历史胜率重排候选币种
+// 基础分为原始排名（越靠前越高），胜率高于50%加分、低于50%减分；
+// weight 表示胜率100%（或0%）时最多前移（或后移）的名次
+func rankCandidatesByWinRate(candidates []CandidateCoin, stats map[string]*SymbolPerformance, weight float64) []CandidateCoin {
+	if weight <= 0 || len(stats) == 0 || len(candidates) < 2 {
+		return candidates
+	}
+
+	scores := make(map[string]float64, len(candidates))
+	for i, coin := range candidates {
+		score := float64(len(candidates) - i)
+		if stat, ok := stats[coin.Symbol]; ok && stat != nil && stat.TotalTrades >= rankingMinTrades {
+			score += weight * (stat.WinRate - 50) / 50
+		}
+		scores[coin.Symbol] = score
+	}
+
+	ranked := make([]CandidateCoin, len(candidates))
+	copy(ranked, candidates)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].Symbol] > scores[ranked[j].Symbol]
+	})
+	return ranked
+}
+
+// applyWinRateRanking 根据 ctx.Performance 中的币种统计重排 ctx.CandidateCoins
+func applyWinRateRanking(ctx *Context) {
+	if ctx.CandidateWinRateWeight <= 0 || ctx.Performance == nil {
+		return
+	}
+	perfData, _, err := parsePerformance(ctx.Performance)
+	if err != nil {
+		log.Printf("⚠️  历史表现数据无法解析，跳过候选币种胜率重排: %v", err)
+		return
+	}
+	ctx.CandidateCoins = rankCandidatesByWinRate(ctx.CandidateCoins, perfData.SymbolStats, ctx.CandidateWinRateWeight)
+}
@@ -0,0 +1,99 @@
+package decision
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func candidateSymbols(candidates []CandidateCoin) []string {
+	symbols := make([]string, len(candidates))
+	for i, c := range candidates {
+		symbols[i] = c.Symbol
+	}
+	return symbols
+}
+
+func TestRankCandidatesByWinRate(t *testing.T) {
+	candidates := []CandidateCoin{{Symbol: "LOWUSDT"}, {Symbol: "HIGHUSDT"}}
+	stats := map[string]*SymbolPerformance{
+		"LOWUSDT":  {Symbol: "LOWUSDT", TotalTrades: 10, WinRate: 20},
+		"HIGHUSDT": {Symbol: "HIGHUSDT", TotalTrades: 10, WinRate: 80},
+	}
+
+	ranked := rankCandidatesByWinRate(candidates, stats, 2)
+	if got := candidateSymbols(ranked); got[0] != "HIGHUSDT" {
+		t.Fatalf("高胜率币种应排在前面，得到 %v", got)
+	}
+	if candidates[0].Symbol != "LOWUSDT" {
+		t.Fatal("不应修改传入的切片")
+	}
+
+	// 权重为0时保持原顺序
+	if got := candidateSymbols(rankCandidatesByWinRate(candidates, stats, 0)); got[0] != "LOWUSDT" {
+		t.Fatalf("权重为0时不应重排，得到 %v", got)
+	}
+}
+
+func TestRankCandidatesIgnoresThinHistory(t *testing.T) {
+	candidates := []CandidateCoin{{Symbol: "LOWUSDT"}, {Symbol: "HIGHUSDT"}}
+	stats := map[string]*SymbolPerformance{
+		"HIGHUSDT": {Symbol: "HIGHUSDT", TotalTrades: rankingMinTrades - 1, WinRate: 100},
+	}
+	if got := candidateSymbols(rankCandidatesByWinRate(candidates, stats, 5)); got[0] != "LOWUSDT" {
+		t.Fatalf("交易数不足的胜率不应参与重排，得到 %v", got)
+	}
+}
+
+// numberedCandidates 生成 n 个按排名排列的候选币种（C00USDT, C01USDT, ...）
+func numberedCandidates(n int) []CandidateCoin {
+	candidates := make([]CandidateCoin, n)
+	for i := range candidates {
+		candidates[i] = CandidateCoin{Symbol: fmt.Sprintf("C%02dUSDT", i), Sources: []string{"ai500"}}
+	}
+	return candidates
+}
+
+func TestJitterCandidatesFixedSeedReproducible(t *testing.T) {
+	candidates := numberedCandidates(12)
+	first := candidateSymbols(jitterCandidates(candidates, 42, 4))
+	second := candidateSymbols(jitterCandidates(candidates, 42, 4))
+	if strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Fatalf("相同 seed 应得到相同顺序:\n%v\n%v", first, second)
+	}
+	if strings.Join(candidateSymbols(candidates), ",") != strings.Join(candidateSymbols(numberedCandidates(12)), ",") {
+		t.Fatal("扰动不应修改传入的切片")
+	}
+
+	// 只在组内打乱：每组成员不变
+	original := candidateSymbols(candidates)
+	for start := 0; start < len(first); start += 4 {
+		got := append([]string(nil), first[start:start+4]...)
+		want := append([]string(nil), original[start:start+4]...)
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("第 %d 组成员变化: %v, want %v", start/4, got, want)
+		}
+	}
+}
+
+func TestJitterCandidatesDifferentSeedsDiffer(t *testing.T) {
+	candidates := numberedCandidates(12)
+	base := strings.Join(candidateSymbols(jitterCandidates(candidates, 1, 4)), ",")
+	for seed := int64(2); seed <= 10; seed++ {
+		if strings.Join(candidateSymbols(jitterCandidates(candidates, seed, 4)), ",") != base {
+			return
+		}
+	}
+	t.Fatal("不同 seed 应得到不同的顺序")
+}
+
+func TestApplyCandidateJitterDisabledWithoutSeed(t *testing.T) {
+	ctx := testContext()
+	ctx.CandidateCoins = numberedCandidates(6)
+	applyCandidateJitter(ctx)
+	if got := strings.Join(candidateSymbols(ctx.CandidateCoins), ","); got != strings.Join(candidateSymbols(numberedCandidates(6)), ",") {
+		t.Fatalf("seed 为0时不应扰动: %s", got)
+	}
+}
@@ -0,0 +1,86 @@
+package decision
+
+import "fmt"
+
+// ReconcileWithPositions 检查决策与当前真实持仓是否一致，返回完整性警告列表
+//   - 平仓的币种当前没有对应方向的持仓
+//   - 开仓的币种已有同方向持仓（会导致仓位叠加）
+//   - 开仓的币种已有反方向持仓，且本批决策没有先平掉
+func ReconcileWithPositions(decisions []Decision, positions []PositionInfo) []string {
+	held := make(map[string]bool) // symbol_side
+	for _, pos := range positions {
+		held[pos.Symbol+"_"+pos.Side] = true
+	}
+
+	closing := make(map[string]bool)
+	for _, d := range decisions {
+		switch d.Action {
+		case ActionCloseLong:
+			closing[d.Symbol+"_long"] = true
+		case ActionCloseShort:
+			closing[d.Symbol+"_short"] = true
+		}
+	}
+
+	var warnings []string
+	for _, d := range decisions {
+		switch d.Action {
+		case ActionCloseLong, ActionCloseShort:
+			side := positionSide(d.Action)
+			if !held[d.Symbol+"_"+side] {
+				warnings = append(warnings, fmt.Sprintf("%s %s: 当前没有%s持仓，无需平仓", d.Symbol, d.Action, side))
+			}
+		case ActionOpenLong, ActionOpenShort:
+			side := positionSide(d.Action)
+			opposite := "short"
+			if side == "short" {
+				opposite = "long"
+			}
+			if held[d.Symbol+"_"+side] {
+				warnings = append(warnings, fmt.Sprintf("%s %s: 已有%s持仓，重复开仓会导致仓位叠加", d.Symbol, d.Action, side))
+			}
+			if held[d.Symbol+"_"+opposite] && !closing[d.Symbol+"_"+opposite] {
+				warnings = append(warnings, fmt.Sprintf("%s %s: 已有%s持仓且未在本批决策中平仓", d.Symbol, d.Action, opposite))
+			}
+		}
+	}
+
+	return warnings
+}
+
+// validateCloseSide 检查平仓方向与实际持仓是否一致
+// 该币种有持仓但没有对应方向的持仓时拒绝（如多仓却给出 close_short）；
+// 完全没有持仓时不在这里拦截，由 ReconcileWithPositions 给出警告
+func validateCloseSide(d *Decision, ctx *Context) error {
+	side := positionSide(d.Action)
+	var heldSides []string
+	for _, pos := range ctx.Positions {
+		if pos.Symbol != d.Symbol {
+			continue
+		}
+		if ctx.isMultiAccount() && d.Account != "" && pos.Account != d.Account {
+			continue
+		}
+		if pos.Side == side {
+			return nil
+		}
+		heldSides = append(heldSides, pos.Side)
+	}
+	if len(heldSides) == 0 {
+		return nil
+	}
+
+	expected := ActionCloseLong
+	if heldSides[0] == "short" {
+		expected = ActionCloseShort
+	}
+	return fmt.Errorf("%s %s 与持仓方向不符：当前持有%s仓，应使用 %s", d.Symbol, d.Action, heldSides[0], expected)
+}
+
+// positionSide 开仓/平仓动作对应的持仓方向（"long" / "short"）
+func positionSide(action Action) string {
+	if action == ActionOpenShort || action == ActionCloseShort {
+		return "short"
+	}
+	return "long"
+}
@@ -0,0 +1,66 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReconcileWithPositions(t *testing.T) {
+	positions := []PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long"},
+		{Symbol: "ETHUSDT", Side: "short"},
+	}
+
+	cases := []struct {
+		name      string
+		decisions []Decision
+		want      string // 期望的警告片段，为空表示无警告
+	}{
+		{"close on flat symbol", []Decision{{Symbol: "SOLUSDT", Action: ActionCloseLong}}, "无需平仓"},
+		{"close wrong side", []Decision{{Symbol: "BTCUSDT", Action: ActionCloseShort}}, "无需平仓"},
+		{"duplicate open", []Decision{{Symbol: "BTCUSDT", Action: ActionOpenLong}}, "仓位叠加"},
+		{"open against held side", []Decision{{Symbol: "ETHUSDT", Action: ActionOpenLong}}, "未在本批决策中平仓"},
+		{"reverse after close", []Decision{{Symbol: "ETHUSDT", Action: ActionCloseShort}, {Symbol: "ETHUSDT", Action: ActionOpenLong}}, ""},
+		{"coherent", []Decision{{Symbol: "BTCUSDT", Action: ActionCloseLong}, {Symbol: "SOLUSDT", Action: ActionOpenShort}}, ""},
+	}
+	for _, tc := range cases {
+		warnings := ReconcileWithPositions(tc.decisions, positions)
+		if tc.want == "" {
+			if len(warnings) != 0 {
+				t.Errorf("%s: 不应有警告，得到 %v", tc.name, warnings)
+			}
+			continue
+		}
+		if len(warnings) != 1 || !strings.Contains(warnings[0], tc.want) {
+			t.Errorf("%s: 期望包含 %q 的一条警告，得到 %v", tc.name, tc.want, warnings)
+		}
+	}
+}
+
+func TestValidateDecisionRejectsCloseSideMismatch(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 10, Leverage: 5}}
+
+	d := Decision{Symbol: "SOLUSDT", Action: ActionCloseShort, Reasoning: "止盈"}
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "与持仓方向不符") || !strings.Contains(err.Error(), string(ActionCloseLong)) {
+		t.Fatalf("多仓上的 close_short 应被拒绝并提示应使用 close_long，得到 %v", err)
+	}
+
+	d = Decision{Symbol: "SOLUSDT", Action: ActionCloseLong, Reasoning: "止盈"}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("方向一致的平仓应通过: %v", err)
+	}
+}
+
+func TestValidateDecisionCloseWithoutPosition(t *testing.T) {
+	ctx := testContext()
+	d := Decision{Symbol: "SOLUSDT", Action: ActionCloseShort, Reasoning: "止盈"}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("没有持仓时不按方向不符拒绝（由 ReconcileWithPositions 警告）: %v", err)
+	}
+	warnings := ReconcileWithPositions([]Decision{d}, ctx.Positions)
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "当前没有short持仓") {
+		t.Fatalf("warnings=%v", warnings)
+	}
+}
@@ -0,0 +1,38 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReduceAllValidatesWithJustPercent(t *testing.T) {
+	ctx := testContext()
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 10, Leverage: 5}}
+
+	d := Decision{Action: ActionReduceAll, ReduceByPct: 50, Reasoning: "整体降风险"}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("只有减仓比例的 reduce_all 应通过验证: %v", err)
+	}
+}
+
+func TestReduceAllRejectsInvalidParams(t *testing.T) {
+	ctx := testContext()
+	cases := map[string]Decision{
+		"zero pct":      {Action: ActionReduceAll, ReduceByPct: 0},
+		"over 100":      {Action: ActionReduceAll, ReduceByPct: 120},
+		"with leverage": {Action: ActionReduceAll, ReduceByPct: 50, Leverage: 3},
+		"with stop":     {Action: ActionReduceAll, ReduceByPct: 50, StopLoss: 95},
+	}
+	for name, d := range cases {
+		if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+			t.Errorf("%s: reduce_all 参数无效时应被拒绝", name)
+		}
+	}
+}
+
+func TestReduceAllDocumentedInSystemPrompt(t *testing.T) {
+	system := buildSystemPrompt(testContext().systemPromptOptions(10, 5))
+	if !strings.Contains(system, "reduce_all") || !strings.Contains(system, "reduce_by_pct") {
+		t.Fatal("system prompt 应说明 reduce_all 的用法")
+	}
+}
@@ -0,0 +1,129 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// 风控状态名称（DetermineRegime 的返回值）
+const (
+	RegimeLosing     = "losing"      // 夏普比率持续亏损：禁止开新仓
+	RegimeLossStreak = "loss_streak" // 连续亏损：prompt 要求暂停开新仓 1 个周期（由模型执行，代码不拦截）
+	RegimeMild       = "mild"        // 夏普比率轻微亏损：收缩模式（配置了 ReducedRegimeMaxLeverage 时限制杠杆）
+	RegimeHealthy    = "healthy"     // 稳健正收益（或无历史数据）
+	RegimeExcellent  = "excellent"   // 优异表现
+)
+
+// 风控约束的键（DetermineRegime 返回的 constraints）
+const (
+	ConstraintOpensAllowed  = "opens_allowed"
+	ConstraintMaxLeverage   = "max_leverage"
+	ConstraintMinConfidence = "min_confidence"
+	ConstraintPositionScale = "position_scale"
+	ConstraintCooldown      = "cooldown"
+)
+
+// lossStreakCount 连续亏损达到该笔数时进入 loss_streak 状态（与 prompt 中的连续亏损保护规则一致）
+const lossStreakCount = 3
+
+// DetermineRegime 汇总本周期实际生效的风控状态及具体约束
+// 综合夏普比率分档、连续亏损和大赢后冷却，优先级: 持续亏损 > 连续亏损 > 收缩 > 稳健/优异
+// 约束只反映代码中真正执行的规则（enforceSharpeHalt、杠杆信心度档位、收缩模式杠杆上限、大赢后冷却），
+// 未配置的规则不会出现在 constraints 中；连续亏损只体现在状态名称上
+func DetermineRegime(ctx *Context, cfg RiskConfig) (name string, constraints map[string]string) {
+	vctx := *ctx
+	vctx.RiskConfig = cfg
+
+	btcEthLev, altLev := vctx.BTCETHLeverage, vctx.AltcoinLeverage
+	constraints = map[string]string{
+		ConstraintOpensAllowed:  "true",
+		ConstraintPositionScale: "100%",
+	}
+
+	sharpeRegime, ok := vctx.sharpeRegime()
+	if !ok {
+		sharpeRegime = sharpeRegimeSteady
+	}
+	if reducedCap := cfg.ReducedRegimeMaxLeverage; sharpeRegime == sharpeRegimeCaution && reducedCap > 0 {
+		btcEthLev, altLev = min(btcEthLev, reducedCap), min(altLev, reducedCap)
+	}
+	switch {
+	case sharpeRegime == sharpeRegimeHalt:
+		name = RegimeLosing
+		constraints[ConstraintOpensAllowed] = "false"
+	case vctx.consecutiveLosses() >= lossStreakCount:
+		name = RegimeLossStreak
+	case sharpeRegime == sharpeRegimeCaution:
+		name = RegimeMild
+	case sharpeRegime == sharpeRegimeStrong:
+		name = RegimeExcellent
+	default:
+		name = RegimeHealthy
+	}
+
+	if constraints[ConstraintOpensAllowed] == "true" {
+		constraints[ConstraintMaxLeverage] = fmt.Sprintf("BTC/ETH %dx, 山寨币 %dx", btcEthLev, altLev)
+		if tiers := formatConfidenceTiers(cfg.LeverageConfidenceTiers); tiers != "" {
+			constraints[ConstraintMinConfidence] = tiers
+		}
+		if active, lastPnLPct := vctx.bigWinCooldown(); active {
+			factor := cfg.bigWinSizeFactor()
+			constraints[ConstraintPositionScale] = fmt.Sprintf("%.0f%%", factor*100)
+			constraints[ConstraintCooldown] = fmt.Sprintf("大赢后冷却（上一笔 %+.2f%%）", lastPnLPct)
+		}
+	}
+	return name, constraints
+}
+
+// formatConfidenceTiers 按杠杆从低到高描述杠杆信心度档位（与 requiredConfidenceForLeverage 的判断一致，未配置时为空）
+func formatConfidenceTiers(tiers []LeverageConfidenceTier) string {
+	sorted := append([]LeverageConfidenceTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].AboveLeverage < sorted[j].AboveLeverage })
+	parts := make([]string, 0, len(sorted))
+	for _, tier := range sorted {
+		if tier.MinConfidence <= 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("杠杆>%dx时≥%d", tier.AboveLeverage, tier.MinConfidence))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// consecutiveLosses 返回最近连续亏损的笔数（无历史数据时为0）
+func (ctx *Context) consecutiveLosses() int {
+	if ctx.Performance == nil {
+		return 0
+	}
+	perfData, _, err := parsePerformance(ctx.Performance)
+	if err != nil {
+		log.Printf("⚠️  历史表现数据无法解析，无法统计连续亏损: %v", err)
+		return 0
+	}
+	count := 0
+	for i := len(perfData.RecentTrades) - 1; i >= 0 && perfData.RecentTrades[i].PnL < 0; i-- {
+		count++
+	}
+	return count
+}
+
+// formatRegimeConstraints 按键名排序输出约束（用于prompt和日志）
+func formatRegimeConstraints(constraints map[string]string) string {
+	keys := make([]string, 0, len(constraints))
+	for key := range constraints {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+constraints[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeRegimeBanner 写入本周期生效的风控状态横幅
+func writeRegimeBanner(sb *strings.Builder, ctx *Context) {
+	name, constraints := DetermineRegime(ctx, ctx.RiskConfig)
+	sb.WriteString(fmt.Sprintf("🧭 **本周期风控状态**: %s | %s\n\n", name, formatRegimeConstraints(constraints)))
+}
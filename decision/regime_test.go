@@ -0,0 +1,136 @@
+package decision
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetermineRegimeLosing(t *testing.T) {
+	ctx := testContext()
+	ctx.CycleReturns = []float64{-1, -2, -1, -3}
+
+	name, constraints := DetermineRegime(ctx, ctx.RiskConfig)
+	if name != RegimeLosing {
+		t.Fatalf("regime = %s，期望 %s", name, RegimeLosing)
+	}
+	if constraints[ConstraintOpensAllowed] != "false" {
+		t.Fatalf("opens_allowed = %q，期望 false", constraints[ConstraintOpensAllowed])
+	}
+
+	// 报告的约束必须被执行：开仓转为wait
+	decisions := []Decision{longDecision("BTCUSDT")}
+	if filtered := enforceSharpeHalt(decisions, ctx); filtered != 1 || decisions[0].Action != ActionWait {
+		t.Fatalf("持续亏损时开仓应转为wait，filtered=%d action=%s", filtered, decisions[0].Action)
+	}
+}
+
+func TestDetermineRegimeLossStreak(t *testing.T) {
+	ctx := testContext()
+	ctx.CycleReturns = []float64{1, -0.8}
+	ctx.Performance = newestFirstPerformance(time.Now(), -1, -1, -1)
+
+	name, constraints := DetermineRegime(ctx, ctx.RiskConfig)
+	if name != RegimeLossStreak {
+		t.Fatalf("regime = %s，期望 %s", name, RegimeLossStreak)
+	}
+	// 连续亏损的暂停由模型按prompt执行，代码不拦截，因此不报告为禁止开仓
+	if constraints[ConstraintOpensAllowed] != "true" {
+		t.Fatalf("opens_allowed = %q，期望 true", constraints[ConstraintOpensAllowed])
+	}
+}
+
+func TestDetermineRegimeMild(t *testing.T) {
+	ctx := testContext()
+	ctx.CycleReturns = []float64{1, -1.2}
+
+	name, constraints := DetermineRegime(ctx, ctx.RiskConfig)
+	if name != RegimeMild {
+		t.Fatalf("regime = %s，期望 %s", name, RegimeMild)
+	}
+	// 未配置收缩模式杠杆上限时不报告任何实际不存在的限制
+	if got := constraints[ConstraintMaxLeverage]; got != "BTC/ETH 10x, 山寨币 5x" {
+		t.Fatalf("max_leverage = %q", got)
+	}
+	if got := constraints[ConstraintPositionScale]; got != "100%" {
+		t.Fatalf("position_scale = %q，期望 100%%", got)
+	}
+	if _, ok := constraints[ConstraintMinConfidence]; ok {
+		t.Fatalf("未配置杠杆信心度档位时不应报告 min_confidence: %v", constraints)
+	}
+
+	// 配置上限后报告的杠杆与 validateDecision 实际收紧后的一致
+	ctx.RiskConfig.ReducedRegimeMaxLeverage = 3
+	_, constraints = DetermineRegime(ctx, ctx.RiskConfig)
+	if got := constraints[ConstraintMaxLeverage]; got != "BTC/ETH 3x, 山寨币 3x" {
+		t.Fatalf("max_leverage = %q，期望收缩到3x", got)
+	}
+}
+
+func TestDetermineRegimeHealthy(t *testing.T) {
+	ctx := testContext()
+	ctx.CycleReturns = []float64{1, -0.8}
+	ctx.RiskConfig.LeverageConfidenceTiers = []LeverageConfidenceTier{
+		{AboveLeverage: 10, MinConfidence: 90},
+		{AboveLeverage: 5, MinConfidence: 80},
+	}
+
+	name, constraints := DetermineRegime(ctx, ctx.RiskConfig)
+	if name != RegimeHealthy {
+		t.Fatalf("regime = %s，期望 %s", name, RegimeHealthy)
+	}
+	if constraints[ConstraintOpensAllowed] != "true" {
+		t.Fatalf("opens_allowed = %q，期望 true", constraints[ConstraintOpensAllowed])
+	}
+	if got := constraints[ConstraintMinConfidence]; got != "杠杆>5x时≥80; 杠杆>10x时≥90" {
+		t.Fatalf("min_confidence = %q，应由杠杆信心度档位推导", got)
+	}
+}
+
+func TestDetermineRegimeExcellent(t *testing.T) {
+	ctx := testContext()
+	ctx.CycleReturns = []float64{1, 2}
+	ctx.RiskConfig.BigWinPnLPct = 20
+	ctx.RiskConfig.BigWinSizeFactor = 0.4
+	ctx.RiskConfig.BigWinCooldownMinutes = 60
+	ctx.Performance = newestFirstPerformance(time.Now(), 30)
+
+	name, constraints := DetermineRegime(ctx, ctx.RiskConfig)
+	if name != RegimeExcellent {
+		t.Fatalf("regime = %s，期望 %s", name, RegimeExcellent)
+	}
+	// 仓位系数来自大赢后冷却，而不是硬编码的比例
+	if got := constraints[ConstraintPositionScale]; got != "40%" {
+		t.Fatalf("position_scale = %q，期望 40%%", got)
+	}
+	if _, ok := constraints[ConstraintCooldown]; !ok {
+		t.Fatalf("大赢后冷却应出现在约束中: %v", constraints)
+	}
+}
+
+func TestReducedRegimeCapsLeverageUnderMildDrawdown(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["BTCUSDT"] = testMarketData("BTCUSDT", 100)
+	ctx.RiskConfig.ReducedRegimeMaxLeverage = 5
+
+	// 稳健区间：10x 在 BTC 的正常上限内，不做调整
+	ctx.CycleReturns = []float64{1, -0.8}
+	d := longDecision("BTCUSDT")
+	d.Leverage = 10
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if d.Leverage != 10 {
+		t.Fatalf("非收缩模式下杠杆不应调整，得到 %dx", d.Leverage)
+	}
+
+	// 轻微亏损区间：10x 降至收缩模式上限 5x
+	ctx.CycleReturns = []float64{1, -1.2}
+	d = longDecision("BTCUSDT")
+	d.Leverage = 10
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if d.Leverage != 5 || d.OriginalLeverage != 10 {
+		t.Fatalf("收缩模式下杠杆应从 10x 降至 5x，得到 %dx（原始 %dx）", d.Leverage, d.OriginalLeverage)
+	}
+}
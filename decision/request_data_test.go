@@ -0,0 +1,30 @@
+package decision
+
+import "testing"
+
+func TestRequestDataValidates(t *testing.T) {
+	ctx := testContext()
+	d := Decision{Symbol: "SOLUSDT", Action: ActionRequestData, Timeframe: "15m", Lookback: 200, Reasoning: "需要更长的历史确认突破"}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("只有币种和数据参数的 request_data 应通过验证: %v", err)
+	}
+
+	fd := &FullDecision{Decisions: []Decision{d, {Symbol: "BTCUSDT", Action: ActionWait}}}
+	if requests := fd.DataRequests(); len(requests) != 1 || requests[0].Symbol != "SOLUSDT" {
+		t.Fatalf("DataRequests=%+v", requests)
+	}
+}
+
+func TestRequestDataRejectsInvalidParams(t *testing.T) {
+	ctx := testContext()
+	for _, d := range []Decision{
+		{Action: ActionRequestData, Timeframe: "15m", Lookback: 200},
+		{Symbol: "SOLUSDT", Action: ActionRequestData, Timeframe: "7m", Lookback: 200},
+		{Symbol: "SOLUSDT", Action: ActionRequestData, Timeframe: "15m", Lookback: 0},
+		{Symbol: "SOLUSDT", Action: ActionRequestData, Timeframe: "15m", Lookback: maxRequestDataLookback + 1},
+	} {
+		if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil {
+			t.Errorf("参数无效的 request_data 应被拒绝: %+v", d)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRiskConfigValidateDefaults(t *testing.T) {
+	if err := (RiskConfig{}).Validate(); err != nil {
+		t.Fatalf("零值配置（全部使用默认值）应有效: %v", err)
+	}
+}
+
+func TestRiskConfigValidateRejectsInvalid(t *testing.T) {
+	cases := map[string]struct {
+		cfg  RiskConfig
+		want string
+	}{
+		"negative max positions": {RiskConfig{MaxPositions: -1}, "max_positions"},
+		"margin cap above 100":   {RiskConfig{MarginCapPct: 120}, "margin_cap_pct"},
+		"inverted rsi extremes":  {RiskConfig{RSIExtremeLow: 80, RSIExtremeHigh: 20}, "rsi_extreme_low"},
+		"symbol cap too high":    {RiskConfig{SymbolLeverageCaps: map[string]int{"SOLUSDT": maxLeverageLimit + 1}}, "symbol_leverage_caps[SOLUSDT]"},
+		"symbol cap zero":        {RiskConfig{SymbolLeverageCaps: map[string]int{"SOLUSDT": 0}}, "symbol_leverage_caps[SOLUSDT]"},
+		"unordered sharpe":       {RiskConfig{SharpeThresholds: SharpeThresholds{Halt: 1, Caution: 0, Strong: 2}}, "sharpe_thresholds"},
+		"unknown oi policy":      {RiskConfig{OITrendPolicy: "ignore"}, "oi_trend_policy"},
+		"big win factor":         {RiskConfig{BigWinSizeFactor: 1.5}, "big_win_size_factor"},
+		"negative min rr":        {RiskConfig{MinRiskReward: -1}, "min_risk_reward"},
+		"min rr below 1":         {RiskConfig{MinRiskReward: 0.5}, "min_risk_reward"},
+		"empty leverage tiers":   {RiskConfig{SymbolLeverageTiers: map[string][]int{"BTCUSDT": {}}}, "symbol_leverage_tiers[BTCUSDT]"},
+	}
+	for name, tc := range cases {
+		err := tc.cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: 期望包含 %q 的错误，得到 %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestPreviewPromptsRejectsInvalidConfig(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MarginCapPct = 150
+	if _, _, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage); err == nil || !strings.Contains(err.Error(), "风控配置无效") {
+		t.Fatalf("无效配置应在构建prompt前被拒绝，得到 %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinRiskRewardConfigurable(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	// 入场100，止损95，止盈115：风险回报比 1:3
+	ctx.RiskConfig.MinRiskReward = 3
+	d := longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("要求1:3时应通过: %v", err)
+	}
+
+	ctx.RiskConfig.MinRiskReward = 5
+	d = longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); ruleCodeOf(err) != RuleRiskReward {
+		t.Fatalf("要求1:5时应因风险回报比被拒绝，得到 %v", err)
+	}
+}
+
+func TestSystemPromptUsesConfiguredMinRiskReward(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MinRiskReward = 5
+	if !strings.Contains(buildSystemPrompt(ctx.systemPromptOptions(10, 5)), "必须 ≥ 1:5") {
+		t.Fatal("system prompt 中的风险回报比要求应与验证使用的值一致")
+	}
+}
+
+func TestSystemPromptRiskRewardExamplesFollowConfig(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MinRiskReward = 2.25
+	prompt := buildSystemPrompt(ctx.systemPromptOptions(10, 5))
+	if !strings.Contains(prompt, "必须 ≥ 1:2.25") || !strings.Contains(prompt, "≥ 2.25:1") {
+		t.Fatal("风险回报比应按配置值原样渲染为 2.25")
+	}
+	if strings.Contains(prompt, "1.5:1（仍可接受）") || strings.Contains(prompt, "≥ 2:1") {
+		t.Fatal("prompt 中不应再出现与配置冲突的固定风险回报比示例")
+	}
+}
+
+func TestRiskRewardUsesEntryPriceWhenProvided(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.MinRiskReward = 4
+
+	// 未给出 entry_price：按当前价100计算，风险5 收益15，1:3
+	d := longDecision("SOLUSDT")
+	if got := entryPriceFor(&d, ctx); got != 100 {
+		t.Fatalf("未给出 entry_price 时应使用当前价，得到 %.2f", got)
+	}
+	if _, _, rr := calculateRiskReward(d.Action, entryPriceFor(&d, ctx), d.StopLoss, d.TakeProfit); rr != 3 {
+		t.Fatalf("按当前价计算的风险回报比应为3，得到 %.2f", rr)
+	}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); ruleCodeOf(err) != RuleRiskReward {
+		t.Fatalf("按当前价计算时1:3低于要求的1:4，应被拒绝，得到 %v", err)
+	}
+
+	// 给出 entry_price=98：风险3 收益17，约1:5.67
+	d = longDecision("SOLUSDT")
+	d.EntryPrice = 98
+	if _, _, rr := calculateRiskReward(d.Action, entryPriceFor(&d, ctx), d.StopLoss, d.TakeProfit); rr < 5.6 || rr > 5.7 {
+		t.Fatalf("按 entry_price 计算的风险回报比应约为5.67，得到 %.2f", rr)
+	}
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("按 entry_price 计算满足要求时应通过: %v", err)
+	}
+
+	if !strings.Contains(buildSystemPrompt(ctx.systemPromptOptions(10, 5)), "`entry_price`") {
+		t.Fatal("system prompt 应说明 entry_price 字段")
+	}
+}
@@ -0,0 +1,33 @@
+package decision
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFullDecisionRecordsRiskConfig(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig = RiskConfig{
+		MinRiskReward:           2.5,
+		MaxPositions:            4,
+		SymbolLeverageCaps:      map[string]int{"SOLUSDT": 3},
+		SymbolSlippagePct:       map[string]float64{"SOLUSDT": 0.2},
+		LeverageConfidenceTiers: []LeverageConfidenceTier{{AboveLeverage: 5, MinConfidence: 80}},
+	}
+	applied := ctx.RiskConfig.clone()
+
+	decision, err := parseFullDecisionResponse(`观望。[{"symbol":"BTCUSDT","action":"wait","reasoning":"观望"}]`, ctx)
+	if err != nil {
+		t.Fatalf("parseFullDecisionResponse: %v", err)
+	}
+	if !reflect.DeepEqual(decision.RiskConfig, applied) {
+		t.Fatalf("记录的风控参数与传入的不一致:\n got %+v\nwant %+v", decision.RiskConfig, applied)
+	}
+
+	// 快照是副本：之后调整配置不影响已记录的决策
+	ctx.RiskConfig.SymbolLeverageCaps["SOLUSDT"] = 10
+	ctx.RiskConfig.LeverageConfidenceTiers[0].MinConfidence = 95
+	if decision.RiskConfig.SymbolLeverageCaps["SOLUSDT"] != 3 || decision.RiskConfig.LeverageConfidenceTiers[0].MinConfidence != 80 {
+		t.Fatal("修改 Context 的配置不应影响已记录的快照")
+	}
+}
@@ -0,0 +1,85 @@
+package decision
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RuleCode 验证规则编号，用于把拒绝原因映射为给模型的修正建议
+type RuleCode string
+
+const (
+	RuleRiskReward    RuleCode = "risk_reward"    // 风险回报比过低
+	RuleLeverage      RuleCode = "leverage"       // 杠杆超出范围
+	RulePositionValue RuleCode = "position_value" // 仓位价值超出上限
+	RuleMinNotional   RuleCode = "min_notional"   // 低于交易所最小下单金额
+	RuleStopTakeOrder RuleCode = "stop_take"      // 止损/止盈方向错误
+	RuleLiquidation   RuleCode = "liquidation"    // 止损在强平价之外
+)
+
+// ruleHints 各规则的通用修正建议（RuleViolation 未给出具体建议时使用）
+var ruleHints = map[RuleCode]string{
+	RuleRiskReward:    "风险回报比不足，请扩大止盈目标或收紧止损。",
+	RuleLeverage:      "杠杆超出允许范围，请在系统提示的杠杆上限内重新选择。",
+	RulePositionValue: "仓位价值超出单币种上限，请缩小 position_size_usd。",
+	RuleMinNotional:   "仓位低于交易所最小下单金额，请加大仓位或放弃该币种。",
+	RuleStopTakeOrder: "止损和止盈方向反了：做多时止损 < 入场 < 止盈，做空时止盈 < 入场 < 止损。",
+	RuleLiquidation:   "止损比强平价更远，会先被强平，请降低杠杆或收紧止损。",
+}
+
+// ErrDecisionValidation 决策验证失败（区别于AI调用或JSON提取失败，可重新提示模型修正）
+var ErrDecisionValidation = errors.New("决策验证失败")
+
+// RuleViolation 带规则编号和修正建议的验证错误
+type RuleViolation struct {
+	Code RuleCode
+	Hint string // 针对本次违规的具体建议（为空时使用该规则的通用建议）
+	Err  error
+}
+
+func (v *RuleViolation) Error() string { return v.Err.Error() }
+
+func (v *RuleViolation) Unwrap() error { return v.Err }
+
+// ruleErrorf 构造带规则编号的验证错误
+func ruleErrorf(code RuleCode, hint string, format string, args ...interface{}) error {
+	return &RuleViolation{Code: code, Hint: hint, Err: fmt.Errorf(format, args...)}
+}
+
+// ruleCodeOf 返回错误对应的规则编号（非 RuleViolation 时为空）
+func ruleCodeOf(err error) RuleCode {
+	var violation *RuleViolation
+	if errors.As(err, &violation) {
+		return violation.Code
+	}
+	return ""
+}
+
+// RemediationHint 返回验证错误对应的修正建议（无法识别的错误返回空）
+func RemediationHint(err error) string {
+	var violation *RuleViolation
+	if !errors.As(err, &violation) {
+		return ""
+	}
+	if violation.Hint != "" {
+		return violation.Hint
+	}
+	return ruleHints[violation.Code]
+}
+
+// rejectionFeedback 生成重新提示模型时附加的拒绝说明
+// explain 为 true 时尽量给出与违规规则对应的简短修正建议，否则（或无对应建议时）使用原始错误
+func rejectionFeedback(err error, explain bool) string {
+	var sb strings.Builder
+	sb.WriteString("## ⚠️ 上一次决策未通过风控验证\n\n")
+	if hint := RemediationHint(err); explain && hint != "" {
+		sb.WriteString(fmt.Sprintf("- [%s] %s\n", ruleCodeOf(err), hint))
+	} else {
+		// 去掉附带的思维链，只保留错误本身
+		msg := strings.SplitN(err.Error(), "\n\n", 2)[0]
+		sb.WriteString(fmt.Sprintf("- %s\n", msg))
+	}
+	sb.WriteString("\n请修正上述问题后重新输出完整的决策（思维链 + JSON数组）；无法满足规则时选择 wait。\n")
+	return sb.String()
+}
@@ -0,0 +1,31 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRiskRewardRejectionFeedbackHint(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.MinRiskReward = 2.5
+
+	d := longDecision("SOLUSDT")
+	d.TakeProfit = 110.5 // 风险5 收益10.5，2.1:1
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if ruleCodeOf(err) != RuleRiskReward {
+		t.Fatalf("应违反风险回报比规则，得到 %v", err)
+	}
+
+	want := "你的风险回报比为2.1:1，最低要求2.5:1。请扩大止盈目标或收紧止损。"
+	if hint := RemediationHint(err); hint != want {
+		t.Fatalf("修正建议 = %q，期望 %q", hint, want)
+	}
+	feedback := rejectionFeedback(err, true)
+	if !strings.Contains(feedback, "[risk_reward] "+want) {
+		t.Fatalf("重新提示应附带规则编号和修正建议:\n%s", feedback)
+	}
+	if feedback = rejectionFeedback(err, false); !strings.Contains(feedback, "风险回报比过低(2.10:1)") {
+		t.Fatalf("未开启 explain 时应使用原始错误:\n%s", feedback)
+	}
+}
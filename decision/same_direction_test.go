@@ -0,0 +1,58 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+// twoLongsContext 已持有两个多仓，同方向最多2个
+func twoLongsContext() *Context {
+	ctx := testContext()
+	ctx.RiskConfig.MaxSameDirectionPositions = 2
+	ctx.Positions = []PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 1, Leverage: 5},
+		{Symbol: "ETHUSDT", Side: "long", EntryPrice: 50, MarkPrice: 50, Quantity: 1, Leverage: 5},
+	}
+	return ctx
+}
+
+func TestThirdLongRejectedWhileShortAllowed(t *testing.T) {
+	ctx := twoLongsContext()
+
+	err := validateSameDirectionPositions([]Decision{longDecision("SOLUSDT")}, ctx)
+	if err == nil || !strings.Contains(err.Error(), "同方向最多2个") {
+		t.Fatalf("第三个多仓应被拒绝，得到 %v", err)
+	}
+
+	short := longDecision("SOLUSDT")
+	short.Action = ActionOpenShort
+	if err := validateSameDirectionPositions([]Decision{short}, ctx); err != nil {
+		t.Fatalf("空仓不受多仓数量限制: %v", err)
+	}
+}
+
+func TestSameDirectionCountsNetOfCloses(t *testing.T) {
+	ctx := twoLongsContext()
+	decisions := []Decision{
+		{Symbol: "ETHUSDT", Action: ActionCloseLong},
+		longDecision("SOLUSDT"),
+	}
+	if err := validateSameDirectionPositions(decisions, ctx); err != nil {
+		t.Fatalf("本批先平掉一个多仓后再开多应允许: %v", err)
+	}
+
+	// 同一批新开两个多仓：第二个超出
+	ctx.Positions = ctx.Positions[:1]
+	decisions = []Decision{longDecision("SOLUSDT"), longDecision("XRPUSDT")}
+	if err := validateSameDirectionPositions(decisions, ctx); err == nil {
+		t.Fatal("现有1个 + 新开2个多仓应超过上限")
+	}
+}
+
+func TestValidateDecisionsEnforcesSameDirectionLimit(t *testing.T) {
+	ctx := twoLongsContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	if err := validateDecisions([]Decision{longDecision("SOLUSDT")}, ctx); err == nil || !strings.Contains(err.Error(), "同方向最多") {
+		t.Fatalf("validateDecisions 应拒绝第三个多仓，得到 %v", err)
+	}
+}
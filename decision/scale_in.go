@@ -0,0 +1,61 @@
+package decision
+
+import "fmt"
+
+// findPosition 查找决策币种对应的现有持仓（多账户模式下按 account 匹配）
+func (ctx *Context) findPosition(symbol, account string) (PositionInfo, bool) {
+	for _, pos := range ctx.Positions {
+		if pos.Symbol != symbol {
+			continue
+		}
+		if ctx.isMultiAccount() && pos.Account != account {
+			continue
+		}
+		return pos, true
+	}
+	return PositionInfo{}, false
+}
+
+// validateScaleIn 验证加仓决策
+// 加仓沿用现有持仓的方向和杠杆，加仓后的总仓位价值（现有 + 新增）必须满足与新开仓相同的单币种上限；
+// 交易所下单时会清掉旧的止损止盈单，因此必须重新给出整个仓位的止损和止盈
+func validateScaleIn(d *Decision, ctx *Context) error {
+	pos, ok := ctx.findPosition(d.Symbol, d.Account)
+	if !ok {
+		return fmt.Errorf("scale_in %s: 当前没有持仓，无法加仓", d.Symbol)
+	}
+	acct, err := ctx.accountFor(pos.Account)
+	if err != nil {
+		return err
+	}
+
+	if err := resolvePositionSizePct(d, acct.TotalEquity); err != nil {
+		return err
+	}
+	if d.PositionSizeUSD <= 0 {
+		return fmt.Errorf("加仓大小必须大于0: %.2f", d.PositionSizeUSD)
+	}
+	if minNotional := ctx.RiskConfig.SymbolMinNotional[d.Symbol]; d.PositionSizeUSD < minNotional {
+		return fmt.Errorf("%s 加仓 %.2f USDT 低于交易所最小下单金额 %.2f USDT", d.Symbol, d.PositionSizeUSD, minNotional)
+	}
+
+	// 加仓后的总仓位不能突破单币种上限（按实际成交数量计算，而非开仓时的计划数量）
+	_, maxPositionValue := ctx.symbolLimits(d.Symbol, acct.TotalEquity)
+	existingValue := pos.Quantity * pos.MarkPrice
+	totalValue := existingValue + d.PositionSizeUSD
+	if totalValue > maxPositionValue*1.01 {
+		return fmt.Errorf("scale_in %s: 加仓后仓位价值 %.0f USDT（现有 %.0f + 新增 %.0f）超过单币种上限 %.0f USDT",
+			d.Symbol, totalValue, existingValue, d.PositionSizeUSD, maxPositionValue)
+	}
+
+	if d.StopLoss <= 0 || d.TakeProfit <= 0 {
+		return fmt.Errorf("scale_in 必须重新给出整个仓位的止损和止盈")
+	}
+	if pos.Side == "long" && d.StopLoss >= d.TakeProfit {
+		return fmt.Errorf("多仓加仓时止损价必须小于止盈价")
+	}
+	if pos.Side == "short" && d.StopLoss <= d.TakeProfit {
+		return fmt.Errorf("空仓加仓时止损价必须大于止盈价")
+	}
+	return nil
+}
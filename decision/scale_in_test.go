@@ -0,0 +1,64 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+// scaleInContext 持有价值 10000 USDT 的 SOLUSDT 多仓（山寨币上限 1.5 × 10000 = 15000）
+func scaleInContext() *Context {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.Positions = []PositionInfo{{Symbol: "SOLUSDT", Side: "long", EntryPrice: 100, MarkPrice: 100, Quantity: 100, Leverage: 5}}
+	return ctx
+}
+
+func scaleInDecision(sizeUSD float64) Decision {
+	return Decision{Symbol: "SOLUSDT", Action: ActionScaleIn, PositionSizeUSD: sizeUSD, StopLoss: 95, TakeProfit: 115, Confidence: 80, Reasoning: "test"}
+}
+
+func TestScaleInRejectedBeyondAltCap(t *testing.T) {
+	ctx := scaleInContext()
+	d := scaleInDecision(6000)
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "超过单币种上限") {
+		t.Fatalf("加仓后 16000 USDT 超过 15000 上限应被拒绝，得到 %v", err)
+	}
+}
+
+func TestScaleInAllowedWithinAltCap(t *testing.T) {
+	ctx := scaleInContext()
+	d := scaleInDecision(4000)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("加仓后 14000 USDT 未超过上限应通过: %v", err)
+	}
+}
+
+func TestPartiallyFilledPositionRendersFillRatio(t *testing.T) {
+	ctx := scaleInContext()
+	ctx.Positions[0].IntendedQuantity = 200
+
+	if ratio, ok := ctx.Positions[0].FillRatio(); !ok || ratio != 0.5 {
+		t.Fatalf("FillRatio=%.2f ok=%v, want 0.5", ratio, ok)
+	}
+	user := buildUserPrompt(ctx)
+	if !strings.Contains(user, "部分成交") || !strings.Contains(user, "成交率 50%") {
+		t.Fatal("user prompt 应标注部分成交的持仓及成交率")
+	}
+
+	// 加仓按实际数量（100 × 100 = 10000）计算，而非计划数量（20000）
+	d := scaleInDecision(4000)
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("按实际数量计算未超过上限，应通过: %v", err)
+	}
+}
+
+func TestFullyFilledPositionHasNoFillNote(t *testing.T) {
+	ctx := scaleInContext()
+	if _, ok := ctx.Positions[0].FillRatio(); ok {
+		t.Fatal("未记录计划数量时 FillRatio 应返回 false")
+	}
+	if strings.Contains(buildUserPrompt(ctx), "部分成交") {
+		t.Fatal("未部分成交的持仓不应显示成交率")
+	}
+}
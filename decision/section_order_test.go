@@ -0,0 +1,46 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSectionOrderMovesPerformanceToEnd(t *testing.T) {
+	ctx := testContext()
+	ctx.Performance = newestFirstPerformance(time.Now(), 1, -1)
+
+	performanceHeader := "## 📋 HISTORICAL PERFORMANCE REVIEW"
+	accountHeader := "## 💰 ACCOUNT STATUS"
+	candidatesHeader := "## 🎯 CANDIDATE COINS"
+
+	user := buildUserPrompt(ctx)
+	if perfAt := strings.Index(user, performanceHeader); perfAt < 0 || perfAt > strings.Index(user, accountHeader) {
+		t.Fatal("默认顺序中历史表现应位于账户信息之前")
+	}
+
+	ctx.SectionOrder = []string{SectionAccount, SectionPositions, SectionCandidates, SectionPerformance}
+	user = buildUserPrompt(ctx)
+	perfAt := strings.Index(user, performanceHeader)
+	if perfAt < 0 || perfAt < strings.Index(user, accountHeader) || perfAt < strings.Index(user, candidatesHeader) {
+		t.Fatal("调整顺序后历史表现应位于其他段落之后")
+	}
+}
+
+func TestValidateSectionOrder(t *testing.T) {
+	if err := validateSectionOrder([]string{SectionAccount, SectionPositions, SectionCandidates}); err == nil {
+		t.Error("缺少段落时应报错")
+	}
+	if err := validateSectionOrder([]string{SectionAccount, SectionAccount, SectionCandidates, SectionPerformance}); err == nil {
+		t.Error("段落重复时应报错")
+	}
+	if err := validateSectionOrder([]string{SectionAccount, "news", SectionCandidates, SectionPerformance}); err == nil {
+		t.Error("未知段落应报错")
+	}
+
+	ctx := testContext()
+	ctx.SectionOrder = []string{SectionAccount}
+	if _, _, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage); err == nil {
+		t.Error("段落顺序配置无效时 PreviewPrompts 应报错")
+	}
+}
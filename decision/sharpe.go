@@ -0,0 +1,137 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// SharpeThresholds 夏普比率状态分界线（system prompt 的规则说明和 user prompt 的当前状态共用）
+//   - Sharpe < Halt: 持续亏损，禁止开新仓
+//   - Halt ≤ Sharpe < Caution: 轻微亏损，收缩模式
+//   - Caution ≤ Sharpe < Strong: 稳健正收益
+//   - Sharpe ≥ Strong: 优异表现，扩张模式
+type SharpeThresholds struct {
+	Halt    float64 `json:"halt"`
+	Caution float64 `json:"caution"`
+	Strong  float64 `json:"strong"`
+}
+
+// defaultSharpeThresholds 默认分界线（-0.5 / 0 / 0.7）
+var defaultSharpeThresholds = SharpeThresholds{Halt: -0.5, Caution: 0, Strong: 0.7}
+
+// 夏普比率状态
+const (
+	sharpeRegimeHalt    = "halt"
+	sharpeRegimeCaution = "caution"
+	sharpeRegimeSteady  = "steady"
+	sharpeRegimeStrong  = "strong"
+)
+
+// sharpeThresholds 返回夏普比率分界线（全部未配置时使用默认值）
+func (c RiskConfig) sharpeThresholds() SharpeThresholds {
+	if c.SharpeThresholds == (SharpeThresholds{}) {
+		return defaultSharpeThresholds
+	}
+	return c.SharpeThresholds
+}
+
+// validate 检查分界线递增
+func (t SharpeThresholds) validate() error {
+	if !(t.Halt < t.Caution && t.Caution < t.Strong) {
+		return fmt.Errorf("sharpe_thresholds 必须满足 halt < caution < strong: %.2f / %.2f / %.2f", t.Halt, t.Caution, t.Strong)
+	}
+	return nil
+}
+
+// ComputeSharpe 由逐周期收益率计算夏普比率：(平均收益 - 无风险利率) / 收益标准差
+// 与 prompt 中的定义一致，为周期级别（非年化）；标准差为0时按收益方向返回 ±999，数据不足两期返回0
+func ComputeSharpe(returns []float64, riskFreeRate float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stdDev := math.Sqrt(variance / float64(len(returns)))
+
+	excess := mean - riskFreeRate
+	if stdDev == 0 {
+		switch {
+		case excess > 0:
+			return 999
+		case excess < 0:
+			return -999
+		default:
+			return 0
+		}
+	}
+	return excess / stdDev
+}
+
+// computedSharpe 提供了逐周期收益率时在包内计算夏普比率（不依赖上游预先计算的值）
+func (ctx *Context) computedSharpe() (float64, bool) {
+	if len(ctx.CycleReturns) < 2 {
+		return 0, false
+	}
+	return ComputeSharpe(ctx.CycleReturns, ctx.RiskFreeRate), true
+}
+
+// sharpeRegime 返回当前夏普比率所处的状态（优先使用 CycleReturns 计算，否则读取 ctx.Performance；均无法获得时返回 false）
+func (ctx *Context) sharpeRegime() (string, bool) {
+	if sharpe, ok := ctx.computedSharpe(); ok {
+		return ctx.RiskConfig.sharpeThresholds().regime(sharpe), true
+	}
+	if ctx.Performance == nil {
+		return "", false
+	}
+	perfData, _, err := parsePerformance(ctx.Performance)
+	if err != nil {
+		log.Printf("⚠️  历史表现数据无法解析，无法判断夏普比率状态: %v", err)
+		return "", false
+	}
+	return ctx.RiskConfig.sharpeThresholds().regime(perfData.SharpeRatio), true
+}
+
+// regime 返回夏普比率所处的状态
+func (t SharpeThresholds) regime(sharpe float64) string {
+	switch {
+	case sharpe < t.Halt:
+		return sharpeRegimeHalt
+	case sharpe < t.Caution:
+		return sharpeRegimeCaution
+	case sharpe < t.Strong:
+		return sharpeRegimeSteady
+	default:
+		return sharpeRegimeStrong
+	}
+}
+
+// enforceSharpeHalt 夏普比率低于停用线（SharpeThresholds.Halt）时，把开仓和加仓决策转为wait
+// prompt 中已要求模型此时只能 close/hold/wait，这里在代码层面兜底；返回被转换的决策数量
+func enforceSharpeHalt(decisions []Decision, ctx *Context) int {
+	regime, ok := ctx.sharpeRegime()
+	if !ok || regime != sharpeRegimeHalt {
+		return 0
+	}
+
+	filtered := 0
+	reason := fmt.Sprintf("夏普比率低于%.2g，禁止开新仓", ctx.RiskConfig.sharpeThresholds().Halt)
+	for i := range decisions {
+		if !decisions[i].Action.IsOpen() && decisions[i].Action != ActionScaleIn {
+			continue
+		}
+		log.Printf("⚠️  夏普比率处于停用区间，%s %s 已转为wait", decisions[i].Symbol, decisions[i].Action)
+		convertToWait(&decisions[i], reason)
+		filtered++
+	}
+	return filtered
+}
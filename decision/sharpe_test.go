@@ -0,0 +1,106 @@
+package decision
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestSharpeThresholdsSharedBySystemAndUserPrompt(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.SharpeThresholds = SharpeThresholds{Halt: -1.2, Caution: -0.3, Strong: 1.1}
+	// -1.0 在默认分界线下属于停用区间，在新分界线下属于收缩模式
+	ctx.Performance = &PerformanceData{SharpeRatio: -1.0}
+
+	system, user, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	for _, want := range []string{"夏普比率 < -1.2", "夏普比率 -1.2 ~ -0.3", "夏普比率 > 1.1"} {
+		if !strings.Contains(system, want) {
+			t.Errorf("system prompt 缺少 %q", want)
+		}
+	}
+	if !strings.Contains(user, "Sharpe < -1.2") || strings.Contains(user, "Sharpe < -0.5") {
+		t.Error("user prompt 的强制检查清单应使用配置的停用线")
+	}
+	if !strings.Contains(user, "轻微亏损 - 收缩模式") || strings.Contains(user, "完全禁止开新仓**（") {
+		t.Error("user prompt 的交易模式应按配置的分界线判断为收缩模式")
+	}
+}
+
+func TestSharpeThresholdsValidate(t *testing.T) {
+	cfg := RiskConfig{SharpeThresholds: SharpeThresholds{Halt: 0, Caution: -0.5, Strong: 0.7}}
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("halt ≥ caution 时应报错")
+	}
+}
+
+func TestComputeSharpeKnownInputs(t *testing.T) {
+	series := []float64{2, 4, 4, 4, 5, 5, 7, 9} // 均值5，标准差2
+	cases := []struct {
+		name     string
+		returns  []float64
+		riskFree float64
+		want     float64
+	}{
+		{"known series", series, 0, 2.5},
+		{"with risk-free rate", series, 1, 2},
+		{"zero mean", []float64{1, -1, 1, -1}, 0, 0},
+		{"constant gain", []float64{1, 1, 1}, 0, 999},
+		{"constant loss", []float64{-1, -1, -1}, 0, -999},
+		{"too short", []float64{5}, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := ComputeSharpe(tc.returns, tc.riskFree); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: ComputeSharpe = %.4f, want %.4f", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCycleReturnsOverrideUpstreamSharpe(t *testing.T) {
+	ctx := testContext()
+	ctx.Performance = &PerformanceData{SharpeRatio: -1} // 上游给出的值处于停用区间
+	if regime, _ := ctx.sharpeRegime(); regime != sharpeRegimeHalt {
+		t.Fatalf("仅有上游数据时 regime = %s，期望 halt", regime)
+	}
+
+	ctx.CycleReturns = []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	if sharpe, ok := ctx.computedSharpe(); !ok || math.Abs(sharpe-2.5) > 1e-9 {
+		t.Fatalf("computedSharpe = %.4f ok=%v", sharpe, ok)
+	}
+	if regime, _ := ctx.sharpeRegime(); regime != sharpeRegimeStrong {
+		t.Fatalf("提供 CycleReturns 时应以包内计算为准，regime = %s", regime)
+	}
+}
+
+func TestSharpeHaltFiltersOpensAfterParse(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.Performance = &PerformanceData{SharpeRatio: -0.8}
+	response := "夏普比率为负，但SOL形态很好。\n" +
+		`[{"symbol":"SOLUSDT","action":"open_long","leverage":3,"position_size_usd":1000,"stop_loss":95,"take_profit":115,"confidence":80,"risk_usd":50,"reasoning":"突破"},` +
+		`{"symbol":"BTCUSDT","action":"wait","reasoning":"观望"}]`
+
+	decision, err := parseFullDecisionResponse(response, ctx)
+	if err != nil {
+		t.Fatalf("parseFullDecisionResponse: %v", err)
+	}
+	if decision.SharpeFiltered != 1 {
+		t.Fatalf("SharpeFiltered = %d，期望 1", decision.SharpeFiltered)
+	}
+	sol := decision.Decisions[0]
+	if sol.Action != ActionWait || !strings.Contains(sol.Reasoning, "夏普比率低于-0.5，禁止开新仓") {
+		t.Fatalf("开仓应转为wait并说明原因，得到 %s %q", sol.Action, sol.Reasoning)
+	}
+
+	// 停用线调低到 -1 后，夏普 -0.8 不再禁止开仓
+	ctx.RiskConfig.SharpeThresholds = SharpeThresholds{Halt: -1, Caution: 0, Strong: 0.7}
+	decision, err = parseFullDecisionResponse(response, ctx)
+	if err != nil {
+		t.Fatalf("parseFullDecisionResponse: %v", err)
+	}
+	if decision.SharpeFiltered != 0 || decision.Decisions[0].Action != ActionOpenLong {
+		t.Fatalf("夏普比率高于配置的停用线时不应过滤，得到 filtered=%d action=%s", decision.SharpeFiltered, decision.Decisions[0].Action)
+	}
+}
@@ -0,0 +1,42 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPositionSizePctConvertsToUSD(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 0
+	d.PositionSizePct = 10 // 10% × 10000 = 1000 USDT
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("validateDecision: %v", err)
+	}
+	if d.PositionSizeUSD != 1000 {
+		t.Fatalf("PositionSizeUSD = %.2f，期望 1000", d.PositionSizeUSD)
+	}
+}
+
+func TestPositionSizePctConflict(t *testing.T) {
+	d := longDecision("SOLUSDT")
+	d.PositionSizeUSD = 3000
+	d.PositionSizePct = 10
+	err := resolvePositionSizePct(&d, 10000)
+	if err == nil || !strings.Contains(err.Error(), "不一致") {
+		t.Fatalf("USD 与百分比冲突时应拒绝，得到 %v", err)
+	}
+
+	// 两者一致时通过（1% 以内的误差）
+	d.PositionSizeUSD = 1005
+	if err := resolvePositionSizePct(&d, 10000); err != nil {
+		t.Fatalf("一致的 USD 与百分比应通过: %v", err)
+	}
+
+	d.PositionSizePct = -1
+	if err := resolvePositionSizePct(&d, 10000); err == nil {
+		t.Fatal("负的仓位百分比应被拒绝")
+	}
+}
@@ -0,0 +1,38 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStopTooTightRelativeToSlippageRejected(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	// 预估滑点 1%（1.0），止损距离 5
+	ctx.RiskConfig.SymbolSlippagePct = map[string]float64{"SOLUSDT": 1}
+	ctx.RiskConfig.MinStopSlippageMultiple = 6
+
+	d := longDecision("SOLUSDT")
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "止损距离过近") {
+		t.Fatalf("止损距离只有滑点的5倍（要求6倍）应被拒绝，得到 %v", err)
+	}
+
+	ctx.RiskConfig.MinStopSlippageMultiple = 4
+	d = longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("止损距离满足滑点倍数时应通过: %v", err)
+	}
+}
+
+func TestSlippageCheckSkipsSymbolsWithoutEstimate(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.SymbolSlippagePct = map[string]float64{"ETHUSDT": 5}
+	ctx.RiskConfig.MinStopSlippageMultiple = 6
+
+	d := longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("未配置滑点的币种不检查: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalculateAvailableSlots(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MaxPositions = 5
+	ctx.RiskConfig.MaxNewOpensPerCycle = 3
+	ctx.Positions = []PositionInfo{{Symbol: "BTCUSDT", Side: "long"}, {Symbol: "ETHUSDT", Side: "short"}}
+	ctx.PendingOpens = 1
+	ctx.Account.MarginUsed = 3000
+	ctx.Account.AvailableBalance = 4000
+
+	slots, freeMargin := calculateAvailableSlots(ctx)
+	if slots != 2 {
+		t.Fatalf("slots = %d，期望 5-2-1 = 2", slots)
+	}
+	// 80% 上限剩余 5000，但可用余额只有 4000
+	if freeMargin != 4000 {
+		t.Fatalf("freeMargin = %.2f，期望 4000", freeMargin)
+	}
+
+	ctx.Account.MarginUsed = 8000
+	if slots, freeMargin := calculateAvailableSlots(ctx); slots != 0 || freeMargin != 0 {
+		t.Fatalf("保证金用尽时应返回 0/0，得到 %d/%.2f", slots, freeMargin)
+	}
+
+	// 单周期开仓上限（默认1）
+	ctx.Account.MarginUsed = 0
+	ctx.RiskConfig.MaxNewOpensPerCycle = 0
+	if slots, _ := calculateAvailableSlots(ctx); slots != 1 {
+		t.Fatalf("slots = %d，期望受单周期上限限制为 1", slots)
+	}
+}
+
+func TestPromptsUseConfiguredMaxPositions(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MaxPositions = 5
+
+	system, user, err := PreviewPrompts(ctx, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	if err != nil {
+		t.Fatalf("PreviewPrompts: %v", err)
+	}
+	if !strings.Contains(system, "**最多持仓**: 5个币种") {
+		t.Fatal("system prompt 应使用配置的最多持仓数量")
+	}
+	if !strings.Contains(user, "**本周期可开新仓**: 最多") {
+		t.Fatal("user prompt 缺少可开新仓数量")
+	}
+}
@@ -0,0 +1,50 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func stagnantPosition(held time.Duration) PositionInfo {
+	return PositionInfo{
+		Symbol: "SOLUSDT", Side: "long",
+		EntryPrice: 100, MarkPrice: 100.5, Quantity: 10, Leverage: 3,
+		UnrealizedPnL: 5, UnrealizedPnLPct: 1.5,
+		TakeProfit: 120, StopLoss: 95,
+		UpdateTime: time.Now().Add(-held).UnixMilli(),
+	}
+}
+
+func TestUserPromptFlagsStaleHold(t *testing.T) {
+	const hint = "仍未接近目标"
+
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100.5)
+	ctx.StaleHoldMinutes = 120
+	ctx.Positions = []PositionInfo{stagnantPosition(3 * time.Hour)}
+	if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, hint) {
+		t.Fatal("持仓3小时仍未接近止盈，应提示重新评估")
+	}
+
+	// 价格已走完一半以上的目标距离
+	nearTarget := stagnantPosition(3 * time.Hour)
+	nearTarget.MarkPrice = 112
+	ctx.Positions = []PositionInfo{nearTarget}
+	if prompt := buildUserPrompt(ctx); strings.Contains(prompt, hint) {
+		t.Fatal("已接近止盈目标，不应提示")
+	}
+
+	// 持仓时间不足
+	ctx.Positions = []PositionInfo{stagnantPosition(30 * time.Minute)}
+	if prompt := buildUserPrompt(ctx); strings.Contains(prompt, hint) {
+		t.Fatal("持仓时间未超过阈值，不应提示")
+	}
+
+	// 未开启
+	ctx.StaleHoldMinutes = 0
+	ctx.Positions = []PositionInfo{stagnantPosition(3 * time.Hour)}
+	if prompt := buildUserPrompt(ctx); strings.Contains(prompt, hint) {
+		t.Fatal("StaleHoldMinutes 为0时不应提示")
+	}
+}
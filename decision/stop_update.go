@@ -0,0 +1,39 @@
+package decision
+
+import "fmt"
+
+// validateNewStopLoss 验证 hold 决策中的止损调整（new_stop_loss）
+// 止损只能向有利方向移动（收紧）：多仓只能上移、空仓只能下移；
+// 放宽止损（"移动止损然后祈祷"）会扩大原计划的风险，除非配置允许，否则拒绝
+func validateNewStopLoss(d *Decision, ctx *Context) error {
+	if d.Action != ActionHold {
+		return fmt.Errorf("new_stop_loss 只能用于 hold 决策，当前为 %s", d.Action)
+	}
+	if d.NewStopLoss <= 0 {
+		return fmt.Errorf("new_stop_loss 必须大于0: %.4f", d.NewStopLoss)
+	}
+
+	pos, ok := ctx.findPosition(d.Symbol, d.Account)
+	if !ok {
+		return fmt.Errorf("%s 当前没有持仓，无法调整止损", d.Symbol)
+	}
+
+	// 新止损必须在当前价的亏损一侧，否则会立即触发
+	if pos.MarkPrice > 0 {
+		if (pos.Side == "long" && d.NewStopLoss >= pos.MarkPrice) ||
+			(pos.Side == "short" && d.NewStopLoss <= pos.MarkPrice) {
+			return fmt.Errorf("%s %s仓新止损%.4f已越过当前价%.4f，会立即触发，请直接平仓", d.Symbol, pos.Side, d.NewStopLoss, pos.MarkPrice)
+		}
+	}
+
+	// 现有止损未知时无法判断是否放宽
+	if pos.StopLoss <= 0 || ctx.RiskConfig.AllowStopLoosening {
+		return nil
+	}
+	if (pos.Side == "long" && d.NewStopLoss < pos.StopLoss) ||
+		(pos.Side == "short" && d.NewStopLoss > pos.StopLoss) {
+		return fmt.Errorf("%s %s仓禁止放宽止损: 现有止损%.4f → 新止损%.4f 会扩大风险，止损只能向有利方向移动",
+			d.Symbol, pos.Side, pos.StopLoss, d.NewStopLoss)
+	}
+	return nil
+}
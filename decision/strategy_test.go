@@ -0,0 +1,36 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrategyLabelValidatedAgainstAllowedSet(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.AllowedStrategies = []string{"scalp", "swing"}
+
+	d := longDecision("SOLUSDT")
+	d.Strategy = " Swing "
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("名单内的策略标签应通过: %v", err)
+	}
+	if d.Strategy != "swing" {
+		t.Fatalf("策略标签应统一为名单中的写法，得到 %q", d.Strategy)
+	}
+
+	d = longDecision("SOLUSDT")
+	d.Strategy = "yolo"
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "不在允许的名单中") {
+		t.Fatalf("名单外的策略标签应被拒绝，得到 %v", err)
+	}
+}
+
+func TestStrategyDocumentedInPrompt(t *testing.T) {
+	ctx := testContext()
+	ctx.AllowedStrategies = []string{"scalp", "swing"}
+	if !strings.Contains(buildUserPrompt(ctx), "只能使用: scalp, swing") {
+		t.Fatal("配置策略名单后 prompt 应说明可用的策略标签")
+	}
+}
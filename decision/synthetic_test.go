@@ -0,0 +1,91 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+func TestSyntheticCoinAppearsInPrompt(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(nil)
+	ctx.SyntheticMarketData = map[string]*market.Data{"FAKEUSDT": testMarketData("FAKEUSDT", 1.23)}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["FAKEUSDT"]; !ok {
+		t.Fatal("合成币种应出现在 MarketDataMap 中")
+	}
+	if !isCandidate(ctx.CandidateCoins, "FAKEUSDT") {
+		t.Fatal("合成币种应补充到候选列表")
+	}
+	if prompt := buildUserPrompt(ctx); !strings.Contains(prompt, "FAKEUSDT") {
+		t.Fatal("合成币种应出现在 user prompt 中")
+	}
+}
+
+func TestSyntheticCoinRespectsFiltersUnlessBypassed(t *testing.T) {
+	thin := testMarketData("THINUSDT", 1)
+	thin.OpenInterest = &market.OIData{Latest: 1000, Average: 1000} // 持仓价值仅 1000 USD
+
+	ctx := testContext()
+	ctx.MarketDataProvider = newCountingProvider(nil)
+	ctx.SyntheticMarketData = map[string]*market.Data{"THINUSDT": thin}
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["THINUSDT"]; ok {
+		t.Fatal("未开启 BypassSyntheticFilters 时流动性过滤应生效")
+	}
+
+	ctx = testContext()
+	ctx.MarketDataProvider = newCountingProvider(nil)
+	ctx.SyntheticMarketData = map[string]*market.Data{"THINUSDT": thin}
+	ctx.BypassSyntheticFilters = true
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["THINUSDT"]; !ok {
+		t.Fatal("开启 BypassSyntheticFilters 时合成币种应跳过过滤")
+	}
+}
+
+func TestRepeatedFetchRefreshesMarketData(t *testing.T) {
+	provider := newCountingProvider(map[string]float64{"SOLUSDT": 100})
+	ctx := testContext()
+	ctx.MarketDataProvider = provider
+	ctx.CandidateCoins = []CandidateCoin{{Symbol: "SOLUSDT", Sources: []string{"ai500"}}}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	provider.prices["SOLUSDT"] = 120
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+
+	if calls := provider.callCount("SOLUSDT"); calls != 2 {
+		t.Fatalf("同一 Context 第二次获取应重新调用数据源, calls=%d", calls)
+	}
+	if price := ctx.MarketDataMap["SOLUSDT"].CurrentPrice; price != 120 {
+		t.Fatalf("价格应更新为 120, got %.2f", price)
+	}
+}
+
+func TestSyntheticCoinSkippedWhenFull(t *testing.T) {
+	ctx := fullBookContext()
+	ctx.OnlyManagePositionsWhenFull = true
+	ctx.SyntheticMarketData = map[string]*market.Data{"FAKEUSDT": testMarketData("FAKEUSDT", 1.23)}
+
+	if err := fetchMarketDataForContext(ctx); err != nil {
+		t.Fatalf("fetchMarketDataForContext: %v", err)
+	}
+	if _, ok := ctx.MarketDataMap["FAKEUSDT"]; ok {
+		t.Fatal("持仓已满且只管理持仓时不应分析合成币种")
+	}
+	if isCandidate(ctx.CandidateCoins, "FAKEUSDT") {
+		t.Fatal("持仓已满且只管理持仓时合成币种不应补充到候选列表")
+	}
+}
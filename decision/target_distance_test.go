@@ -0,0 +1,34 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDecisionRejectsTargetWithinOneCandle(t *testing.T) {
+	ctx := testContext()
+	data := testMarketData("SOLUSDT", 100)
+	// 相邻3分钟K线中间价平均波动 4
+	data.IntradaySeries.MidPrices = []float64{96, 100, 96, 100}
+	ctx.MarketDataMap["SOLUSDT"] = data
+	ctx.RiskConfig.MinTargetCandleMultiple = 1
+
+	if move, ok := typicalCandleMove(data); !ok || move != 4 {
+		t.Fatalf("typicalCandleMove = %.4f / %v，期望 4", move, ok)
+	}
+
+	d := longDecision("SOLUSDT")
+	d.StopLoss = 99
+	d.TakeProfit = 103 // 距离3，小于一根K线的典型波动
+	d.RiskUSD = 10
+	err := validateDecision(&d, ctx.Account.TotalEquity, ctx)
+	if err == nil || !strings.Contains(err.Error(), "止盈距离过近") {
+		t.Fatalf("止盈在一根K线波动范围内应被拒绝，得到 %v", err)
+	}
+
+	// 止盈足够远
+	d = longDecision("SOLUSDT")
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("止盈距离足够时应通过: %v", err)
+	}
+}
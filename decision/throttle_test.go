@@ -0,0 +1,49 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenBlockedWhenLastOpenTooRecent(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MinSecondsBetweenOpens = 120
+	ctx.LastOpenTime = time.Now().Add(-30 * time.Second)
+
+	decisions := []Decision{longDecision("SOLUSDT"), {Symbol: "BTCUSDT", Action: ActionHold}}
+	enforceOpenThrottle(decisions, ctx)
+	if decisions[0].Action != ActionWait || !strings.Contains(decisions[0].Reasoning, "距上次开仓不足120秒") {
+		t.Fatalf("距上次开仓仅30秒时开仓应转为wait: %+v", decisions[0])
+	}
+	if decisions[1].Action != ActionHold {
+		t.Fatal("非开仓决策不受节流影响")
+	}
+}
+
+func TestOpenThrottleKeepsFirstOpenAfterInterval(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.MinSecondsBetweenOpens = 120
+	ctx.LastOpenTime = time.Now().Add(-10 * time.Minute)
+
+	decisions := []Decision{longDecision("SOLUSDT"), longDecision("ETHUSDT")}
+	enforceOpenThrottle(decisions, ctx)
+	if decisions[0].Action != ActionOpenLong || decisions[1].Action != ActionWait {
+		t.Fatalf("间隔已满足时只保留第一个开仓: %s %s", decisions[0].Action, decisions[1].Action)
+	}
+}
+
+func TestValidateDecisionsAppliesOpenThrottle(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.RiskConfig.MinSecondsBetweenOpens = 120
+	ctx.LastOpenTime = time.Now().Add(-30 * time.Second)
+
+	decisions := []Decision{longDecision("SOLUSDT")}
+	if err := validateDecisions(decisions, ctx); err != nil {
+		t.Fatalf("validateDecisions: %v", err)
+	}
+	if decisions[0].Action != ActionWait {
+		t.Fatalf("validateDecisions 应把过于频繁的开仓转为wait，得到 %s", decisions[0].Action)
+	}
+}
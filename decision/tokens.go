@@ -0,0 +1,17 @@
+package decision
+
+import "unicode"
+
+// estimateTokens 粗略估算文本的token数：中日韩字符按每字1个token，其余字符按每4个1个token
+// 不同模型的分词器差异较大，结果只用于对比预算，不用于计费
+func estimateTokens(s string) int {
+	cjk, other := 0, 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
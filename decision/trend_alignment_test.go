@@ -0,0 +1,64 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+// shortDecision 构造一个参数合理的开空决策（入场约100，止损105，止盈85）
+func shortDecision(symbol string) Decision {
+	d := longDecision(symbol)
+	d.Action = ActionOpenShort
+	d.StopLoss = 105
+	d.TakeProfit = 85
+	return d
+}
+
+// bullish4hWithBearishShortTerm 4h上升趋势，但短线指标已转空（满足开空的指标确认）
+func bullish4hWithBearishShortTerm(symbol string, rsi float64) *market.Data {
+	data := testMarketData(symbol, 100)
+	data.CurrentEMA20 = 101
+	data.CurrentMACD = -0.1
+	data.CurrentRSI7 = rsi
+	return data
+}
+
+func TestCounterTrendShortAllowedAtExtremeRSI(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.EnforceTrendAlignment = true
+	ctx.MarketDataMap["ETHUSDT"] = bullish4hWithBearishShortTerm("ETHUSDT", 85)
+
+	d := shortDecision("ETHUSDT")
+	d.Confidence = 85
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("RSI 85 且信心度达标时逆势做空应放行: %v", err)
+	}
+
+	d = shortDecision("ETHUSDT")
+	d.Confidence = 80
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil || !strings.Contains(err.Error(), "逆势例外") {
+		t.Fatalf("信心度不足时逆势做空应被拒绝，得到 %v", err)
+	}
+}
+
+func TestCounterTrendShortRejectedAtNormalRSI(t *testing.T) {
+	ctx := testContext()
+	ctx.RiskConfig.EnforceTrendAlignment = true
+	ctx.MarketDataMap["ETHUSDT"] = bullish4hWithBearishShortTerm("ETHUSDT", 55)
+
+	d := shortDecision("ETHUSDT")
+	d.Confidence = 95
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err == nil || !strings.Contains(err.Error(), "逆势例外") {
+		t.Fatalf("RSI 未达极端值时逆势做空应被拒绝，得到 %v", err)
+	}
+
+	// 调低超买阈值后同样的 RSI 55 即视为极端
+	ctx.RiskConfig.RSIExtremeHigh = 50
+	d = shortDecision("ETHUSDT")
+	d.Confidence = 95
+	if err := validateDecision(&d, ctx.Account.TotalEquity, ctx); err != nil {
+		t.Fatalf("可配置的超买阈值应生效: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package decision
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"nofx/market"
+	"sync"
+)
+
+// runValidation 缓存未命中时实际执行的验证（测试中可替换以统计调用次数）
+var runValidation = validateDecision
+
+// maxValidationCacheEntries 缓存条目上限；ctx 跨周期复用同一缓存时，行情变化使旧 key 不再命中，
+// 超过上限后整体清空，避免长期运行时无限增长
+const maxValidationCacheEntries = 512
+
+// ValidationCache 决策验证结果缓存
+// 重新请求AI时经常返回相同的决策，缓存可避免重复验证；
+// key 包含决策内容及验证用到的上下文（净值、杠杆配置、风控参数、持仓、相关币种市场数据），
+// 任一输入变化都会得到不同的 key，因此无需手动失效
+type ValidationCache struct {
+	mu      sync.Mutex
+	entries map[string]validationResult
+	hits    int
+	misses  int
+}
+
+// validationResult 缓存的验证结果（验证过程可能改写决策，如百分比仓位换算、杠杆对齐档位）
+type validationResult struct {
+	decision Decision
+	err      error
+}
+
+// NewValidationCache 创建验证结果缓存
+func NewValidationCache() *ValidationCache {
+	return &ValidationCache{entries: make(map[string]validationResult)}
+}
+
+// Stats 返回缓存命中和未命中次数
+func (c *ValidationCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// validationCacheKey 计算决策及其验证上下文的哈希
+func validationCacheKey(d *Decision, accountEquity float64, ctx *Context) (string, error) {
+	payload := struct {
+		Decision            Decision
+		AccountEquity       float64
+		BTCETHLeverage      int
+		AltcoinLeverage     int
+		StrictNonOpenFields bool
+		VerboseValidation   bool
+		AllowedSymbols      []string
+		AllowedStrategies   []string
+		RiskConfig          RiskConfig
+		Accounts            []AccountInfo
+		Positions           []PositionInfo
+		SymbolData          *market.Data
+		BTCData             *market.Data
+		BigWinCooldown      bool
+		SharpeRegime        string
+	}{
+		Decision:            *d,
+		AccountEquity:       accountEquity,
+		BTCETHLeverage:      ctx.BTCETHLeverage,
+		AltcoinLeverage:     ctx.AltcoinLeverage,
+		StrictNonOpenFields: ctx.StrictNonOpenFields,
+		VerboseValidation:   ctx.VerboseValidation,
+		AllowedSymbols:      ctx.AllowedSymbols,
+		AllowedStrategies:   ctx.AllowedStrategies,
+		RiskConfig:          ctx.RiskConfig,
+		Accounts:            ctx.accountList(),
+		Positions:           ctx.Positions,
+		SymbolData:          ctx.MarketDataMap[d.Symbol],
+		BTCData:             ctx.MarketDataMap["BTCUSDT"],
+	}
+	payload.BigWinCooldown, _ = ctx.bigWinCooldown()
+	payload.SharpeRegime, _ = ctx.sharpeRegime()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// validateDecisionCached 带缓存的 validateDecision（ctx.ValidationCache 为空时直接验证）
+func validateDecisionCached(d *Decision, accountEquity float64, ctx *Context) error {
+	cache := ctx.ValidationCache
+	if cache == nil {
+		return runValidation(d, accountEquity, ctx)
+	}
+
+	key, err := validationCacheKey(d, accountEquity, ctx)
+	if err != nil {
+		// 无法计算key时退化为直接验证
+		return runValidation(d, accountEquity, ctx)
+	}
+
+	cache.mu.Lock()
+	if result, ok := cache.entries[key]; ok {
+		cache.hits++
+		cache.mu.Unlock()
+		*d = result.decision
+		return result.err
+	}
+	cache.misses++
+	cache.mu.Unlock()
+
+	err = runValidation(d, accountEquity, ctx)
+
+	cache.mu.Lock()
+	if len(cache.entries) >= maxValidationCacheEntries {
+		cache.entries = make(map[string]validationResult)
+	}
+	cache.entries[key] = validationResult{decision: *d, err: err}
+	cache.mu.Unlock()
+	return err
+}
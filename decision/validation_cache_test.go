@@ -0,0 +1,112 @@
+package decision
+
+import "testing"
+
+// spyValidation 统计实际执行验证的次数，测试结束后恢复
+func spyValidation(t *testing.T) *int {
+	t.Helper()
+	calls := 0
+	orig := runValidation
+	runValidation = func(d *Decision, accountEquity float64, ctx *Context) error {
+		calls++
+		return orig(d, accountEquity, ctx)
+	}
+	t.Cleanup(func() { runValidation = orig })
+	return &calls
+}
+
+func TestValidationCacheHitSkipsRecomputation(t *testing.T) {
+	calls := spyValidation(t)
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.ValidationCache = NewValidationCache()
+
+	for i := 0; i < 3; i++ {
+		d := longDecision("SOLUSDT")
+		if err := validateDecisionCached(&d, ctx.Account.TotalEquity, ctx); err != nil {
+			t.Fatalf("validateDecisionCached: %v", err)
+		}
+	}
+	if *calls != 1 {
+		t.Fatalf("相同决策应只验证一次, got %d", *calls)
+	}
+	if hits, misses := ctx.ValidationCache.Stats(); hits != 2 || misses != 1 {
+		t.Fatalf("hits=%d misses=%d, want 2/1", hits, misses)
+	}
+}
+
+func TestValidationCacheInvalidatedByInputChanges(t *testing.T) {
+	calls := spyValidation(t)
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.ValidationCache = NewValidationCache()
+
+	validate := func() {
+		d := longDecision("SOLUSDT")
+		_ = validateDecisionCached(&d, ctx.Account.TotalEquity, ctx)
+	}
+
+	validate()
+	// 账户净值变化
+	ctx.Account.TotalEquity = 12000
+	validate()
+	// 市场数据变化
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 101)
+	validate()
+	// 持仓变化
+	ctx.Positions = []PositionInfo{{Symbol: "ETHUSDT", Side: "long", EntryPrice: 50, MarkPrice: 50, Quantity: 1, Leverage: 5}}
+	validate()
+
+	if *calls != 4 {
+		t.Fatalf("每次输入变化都应重新验证, got %d 次", *calls)
+	}
+	validate()
+	if *calls != 4 {
+		t.Fatal("输入未变化时应命中缓存")
+	}
+}
+
+func TestValidationCacheReplaysRejection(t *testing.T) {
+	calls := spyValidation(t)
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.ValidationCache = NewValidationCache()
+
+	for i := 0; i < 2; i++ {
+		d := longDecision("SOLUSDT")
+		d.Leverage = 50
+		if err := validateDecisionCached(&d, ctx.Account.TotalEquity, ctx); err == nil {
+			t.Fatal("杠杆超限应被拒绝（缓存命中时也应返回同样的错误）")
+		}
+	}
+	if *calls != 1 {
+		t.Fatalf("被拒绝的结果同样应缓存, got %d 次", *calls)
+	}
+}
+
+func TestValidationCacheBoundedSize(t *testing.T) {
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+	ctx.ValidationCache = NewValidationCache()
+
+	for i := 0; i < maxValidationCacheEntries+10; i++ {
+		d := longDecision("SOLUSDT")
+		d.PositionSizeUSD = float64(1000 + i)
+		_ = validateDecisionCached(&d, ctx.Account.TotalEquity, ctx)
+	}
+	if n := len(ctx.ValidationCache.entries); n > maxValidationCacheEntries {
+		t.Fatalf("缓存条目数 %d 超过上限 %d", n, maxValidationCacheEntries)
+	}
+}
+
+func TestValidateDecisionCachedWithoutCacheUsesSeam(t *testing.T) {
+	calls := spyValidation(t)
+	ctx := testContext()
+	ctx.MarketDataMap["SOLUSDT"] = testMarketData("SOLUSDT", 100)
+
+	d := longDecision("SOLUSDT")
+	_ = validateDecisionCached(&d, ctx.Account.TotalEquity, ctx)
+	if *calls != 1 {
+		t.Fatalf("未配置缓存时也应通过 runValidation 验证, got %d 次", *calls)
+	}
+}
@@ -49,15 +49,16 @@ type PositionSnapshot struct {
 
 // DecisionAction 决策动作
 type DecisionAction struct {
-	Action    string    `json:"action"`    // open_long, open_short, close_long, close_short
-	Symbol    string    `json:"symbol"`    // 币种
-	Quantity  float64   `json:"quantity"`  // 数量
-	Leverage  int       `json:"leverage"`  // 杠杆（开仓时）
-	Price     float64   `json:"price"`     // 执行价格
-	OrderID   int64     `json:"order_id"`  // 订单ID
-	Timestamp time.Time `json:"timestamp"` // 执行时间
-	Success   bool      `json:"success"`   // 是否成功
-	Error     string    `json:"error"`     // 错误信息
+	Action     string    `json:"action"`               // open_long, open_short, close_long, close_short
+	Symbol     string    `json:"symbol"`               // 币种
+	Quantity   float64   `json:"quantity"`             // 数量
+	Leverage   int       `json:"leverage"`             // 杠杆（开仓时）
+	Confidence int       `json:"confidence,omitempty"` // AI给出的信心度（开仓时）
+	Price      float64   `json:"price"`                // 执行价格
+	OrderID    int64     `json:"order_id"`             // 订单ID
+	Timestamp  time.Time `json:"timestamp"`            // 执行时间
+	Success    bool      `json:"success"`              // 是否成功
+	Error      string    `json:"error"`                // 错误信息
 }
 
 // DecisionLogger 决策日志记录器
@@ -269,20 +270,21 @@ type Statistics struct {
 
 // TradeOutcome 单笔交易结果
 type TradeOutcome struct {
-	Symbol        string    `json:"symbol"`         // 币种
-	Side          string    `json:"side"`           // long/short
-	Quantity      float64   `json:"quantity"`       // 仓位数量
-	Leverage      int       `json:"leverage"`       // 杠杆倍数
-	OpenPrice     float64   `json:"open_price"`     // 开仓价
-	ClosePrice    float64   `json:"close_price"`    // 平仓价
-	PositionValue float64   `json:"position_value"` // 仓位价值（quantity × openPrice）
-	MarginUsed    float64   `json:"margin_used"`    // 保证金使用（positionValue / leverage）
-	PnL           float64   `json:"pn_l"`           // 盈亏（USDT）
-	PnLPct        float64   `json:"pn_l_pct"`       // 盈亏百分比（相对保证金）
-	Duration      string    `json:"duration"`       // 持仓时长
-	OpenTime      time.Time `json:"open_time"`      // 开仓时间
-	CloseTime     time.Time `json:"close_time"`     // 平仓时间
-	WasStopLoss   bool      `json:"was_stop_loss"`  // 是否止损
+	Symbol        string    `json:"symbol"`               // 币种
+	Side          string    `json:"side"`                 // long/short
+	Quantity      float64   `json:"quantity"`             // 仓位数量
+	Leverage      int       `json:"leverage"`             // 杠杆倍数
+	OpenPrice     float64   `json:"open_price"`           // 开仓价
+	ClosePrice    float64   `json:"close_price"`          // 平仓价
+	PositionValue float64   `json:"position_value"`       // 仓位价值（quantity × openPrice）
+	MarginUsed    float64   `json:"margin_used"`          // 保证金使用（positionValue / leverage）
+	PnL           float64   `json:"pn_l"`                 // 盈亏（USDT）
+	PnLPct        float64   `json:"pn_l_pct"`             // 盈亏百分比（相对保证金）
+	Duration      string    `json:"duration"`             // 持仓时长
+	OpenTime      time.Time `json:"open_time"`            // 开仓时间
+	CloseTime     time.Time `json:"close_time"`           // 平仓时间
+	WasStopLoss   bool      `json:"was_stop_loss"`        // 是否止损
+	Confidence    int       `json:"confidence,omitempty"` // 开仓时AI给出的信心度（未记录时为0）
 }
 
 // PerformanceAnalysis 交易表现分析
@@ -358,11 +360,12 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 				case "open_long", "open_short":
 					// 记录开仓
 					openPositions[posKey] = map[string]interface{}{
-						"side":      side,
-						"openPrice": action.Price,
-						"openTime":  action.Timestamp,
-						"quantity":  action.Quantity,
-						"leverage":  action.Leverage,
+						"side":       side,
+						"openPrice":  action.Price,
+						"openTime":   action.Timestamp,
+						"quantity":   action.Quantity,
+						"leverage":   action.Leverage,
+						"confidence": action.Confidence,
 					}
 				case "close_long", "close_short":
 					// 移除已平仓记录
@@ -392,11 +395,12 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 			case "open_long", "open_short":
 				// 更新开仓记录（可能已经在预填充时记录过了）
 				openPositions[posKey] = map[string]interface{}{
-					"side":      side,
-					"openPrice": action.Price,
-					"openTime":  action.Timestamp,
-					"quantity":  action.Quantity,
-					"leverage":  action.Leverage,
+					"side":       side,
+					"openPrice":  action.Price,
+					"openTime":   action.Timestamp,
+					"quantity":   action.Quantity,
+					"leverage":   action.Leverage,
+					"confidence": action.Confidence,
 				}
 
 			case "close_long", "close_short":
@@ -407,6 +411,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 					side := openPos["side"].(string)
 					quantity := openPos["quantity"].(float64)
 					leverage := openPos["leverage"].(int)
+					confidence, _ := openPos["confidence"].(int)
 
 					// 计算实际盈亏（USDT）
 					// 合约交易 PnL 计算：quantity × 价格差
@@ -441,6 +446,7 @@ func (l *DecisionLogger) AnalyzePerformance(lookbackCycles int) (*PerformanceAna
 						Duration:      action.Timestamp.Sub(openTime).String(),
 						OpenTime:      openTime,
 						CloseTime:     action.Timestamp,
+						Confidence:    confidence,
 					}
 
 					analysis.RecentTrades = append(analysis.RecentTrades, outcome)
@@ -78,11 +78,15 @@ type AutoTrader struct {
 	initialBalance        float64
 	dailyPnL              float64
 	lastResetTime         time.Time
+	lastOpenTime          time.Time // 最近一次开仓成功的时间（全局开仓节流）
 	stopUntil             time.Time
 	isRunning             bool
-	startTime             time.Time        // 系统启动时间
-	callCount             int              // AI调用次数
-	positionFirstSeenTime map[string]int64 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	startTime             time.Time          // 系统启动时间
+	callCount             int                // AI调用次数
+	positionFirstSeenTime map[string]int64   // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	positionStopLoss      map[string]float64 // 开仓时设置的止损价 (symbol_side -> 价格)
+	positionTakeProfit    map[string]float64 // 开仓时设置的止盈价 (symbol_side -> 价格)
+	positionIntendedQty   map[string]float64 // 开仓时计划的数量，用于识别部分成交 (symbol_side -> 数量)
 }
 
 // NewAutoTrader 创建自动交易器
@@ -177,6 +181,9 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		callCount:             0,
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
+		positionStopLoss:      make(map[string]float64),
+		positionTakeProfit:    make(map[string]float64),
+		positionIntendedQty:   make(map[string]float64),
 	}, nil
 }
 
@@ -327,7 +334,7 @@ func (at *AutoTrader) runCycle() error {
 	log.Printf("📋 AI决策列表 (%d 个):\n", len(decision.Decisions))
 	for i, d := range decision.Decisions {
 		log.Printf("  [%d] %s: %s - %s", i+1, d.Symbol, d.Action, d.Reasoning)
-		if d.Action == "open_long" || d.Action == "open_short" {
+		if d.Action.IsOpen() {
 			log.Printf("      杠杆: %dx | 仓位: %.2f USDT | 止损: %.4f | 止盈: %.4f",
 				d.Leverage, d.PositionSizeUSD, d.StopLoss, d.TakeProfit)
 		}
@@ -346,13 +353,14 @@ func (at *AutoTrader) runCycle() error {
 	// 执行决策并记录结果
 	for _, d := range sortedDecisions {
 		actionRecord := logger.DecisionAction{
-			Action:    d.Action,
-			Symbol:    d.Symbol,
-			Quantity:  0,
-			Leverage:  d.Leverage,
-			Price:     0,
-			Timestamp: time.Now(),
-			Success:   false,
+			Action:     string(d.Action),
+			Symbol:     d.Symbol,
+			Quantity:   0,
+			Leverage:   d.Leverage,
+			Confidence: d.Confidence,
+			Price:      0,
+			Timestamp:  time.Now(),
+			Success:    false,
 		}
 
 		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
@@ -464,6 +472,9 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 			LiquidationPrice: liquidationPrice,
 			MarginUsed:       marginUsed,
 			UpdateTime:       updateTime,
+			StopLoss:         at.positionStopLoss[posKey],
+			TakeProfit:       at.positionTakeProfit[posKey],
+			IntendedQuantity: at.positionIntendedQty[posKey],
 		})
 	}
 
@@ -471,6 +482,9 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	for key := range at.positionFirstSeenTime {
 		if !currentPositionKeys[key] {
 			delete(at.positionFirstSeenTime, key)
+			delete(at.positionStopLoss, key)
+			delete(at.positionTakeProfit, key)
+			delete(at.positionIntendedQty, key)
 		}
 	}
 
@@ -539,6 +553,8 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
+		LastOpenTime:   at.lastOpenTime,
+		PendingOpens:   0, // 开仓均为市价单，提交即成交，不存在待成交的开仓
 	}
 
 	return ctx, nil
@@ -555,7 +571,16 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 		return at.executeCloseLongWithRecord(decision, actionRecord)
 	case "close_short":
 		return at.executeCloseShortWithRecord(decision, actionRecord)
-	case "hold", "wait":
+	case "scale_in":
+		return at.executeScaleInWithRecord(decision, actionRecord)
+	case "reduce_all":
+		return at.executeReduceAllWithRecord(decision, actionRecord)
+	case "hold":
+		if decision.NewStopLoss > 0 {
+			return at.executeMoveStopLossWithRecord(decision, actionRecord)
+		}
+		return nil
+	case "wait", "note", "request_data":
 		// 无需执行，仅记录
 		return nil
 	default:
@@ -600,17 +625,23 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	}
 
 	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.lastOpenTime = time.Now()
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_long"
 	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionIntendedQty[posKey] = quantity
 
 	// 设置止损止盈
 	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
 		log.Printf("  ⚠ 设置止损失败: %v", err)
+	} else {
+		at.positionStopLoss[posKey] = decision.StopLoss
 	}
 	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
 		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	} else {
+		at.positionTakeProfit[posKey] = decision.TakeProfit
 	}
 
 	return nil
@@ -653,19 +684,224 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	}
 
 	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.lastOpenTime = time.Now()
 
 	// 记录开仓时间
 	posKey := decision.Symbol + "_short"
 	at.positionFirstSeenTime[posKey] = time.Now().UnixMilli()
+	at.positionIntendedQty[posKey] = quantity
 
 	// 设置止损止盈
 	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
 		log.Printf("  ⚠ 设置止损失败: %v", err)
+	} else {
+		at.positionStopLoss[posKey] = decision.StopLoss
 	}
 	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
 		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	} else {
+		at.positionTakeProfit[posKey] = decision.TakeProfit
+	}
+
+	return nil
+}
+
+// executeScaleInWithRecord 按现有持仓方向加仓并记录详细信息
+// 下单会清掉旧的止损止盈单，加仓后按整个仓位重新设置
+func (at *AutoTrader) executeScaleInWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  ➕ 加仓: %s", decision.Symbol)
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var side string
+	var existingQty float64
+	leverage := 0
+	for _, pos := range positions {
+		if pos["symbol"] != decision.Symbol {
+			continue
+		}
+		side, _ = pos["side"].(string)
+		existingQty, _ = pos["positionAmt"].(float64)
+		if existingQty < 0 {
+			existingQty = -existingQty
+		}
+		if lev, ok := pos["leverage"].(float64); ok {
+			leverage = int(lev)
+		}
+		break
+	}
+	if side == "" {
+		return fmt.Errorf("❌ %s 没有持仓，无法加仓", decision.Symbol)
+	}
+	if leverage <= 0 {
+		return fmt.Errorf("❌ %s 无法获取持仓杠杆，拒绝加仓", decision.Symbol)
+	}
+
+	// 获取当前价格
+	marketData, err := market.Get(decision.Symbol)
+	if err != nil {
+		return err
+	}
+
+	// 计算数量
+	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	actionRecord.Quantity = quantity
+	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Leverage = leverage
+
+	// 加仓
+	var order map[string]interface{}
+	if side == "long" {
+		order, err = at.trader.OpenLong(decision.Symbol, quantity, leverage)
+	} else {
+		order, err = at.trader.OpenShort(decision.Symbol, quantity, leverage)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 记录订单ID
+	if orderID, ok := order["orderId"].(int64); ok {
+		actionRecord.OrderID = orderID
+	}
+
+	log.Printf("  ✓ 加仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+
+	// 按加仓后的总数量重新设置止损止盈
+	totalQty := existingQty + quantity
+	positionSide := strings.ToUpper(side)
+	posKey := decision.Symbol + "_" + side
+	at.positionIntendedQty[posKey] = totalQty
+	if err := at.trader.SetStopLoss(decision.Symbol, positionSide, totalQty, decision.StopLoss); err != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", err)
+	} else {
+		at.positionStopLoss[posKey] = decision.StopLoss
+	}
+	if err := at.trader.SetTakeProfit(decision.Symbol, positionSide, totalQty, decision.TakeProfit); err != nil {
+		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	} else {
+		at.positionTakeProfit[posKey] = decision.TakeProfit
+	}
+
+	return nil
+}
+
+// executeReduceAllWithRecord 所有持仓按同一比例减仓并记录详细信息
+// 平仓会清掉该币种的止损止盈单，减仓后按剩余数量重新设置已知的止损止盈
+func (at *AutoTrader) executeReduceAllWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  ✂️ 整体减仓: %.0f%%", decision.ReduceByPct)
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	var failed []string
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		quantity, _ := pos["positionAmt"].(float64)
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		if symbol == "" || quantity == 0 {
+			continue
+		}
+
+		reduceQty := 0.0 // 0 = 全部平仓
+		if decision.ReduceByPct < 100 {
+			reduceQty = quantity * decision.ReduceByPct / 100
+		}
+
+		if side == "long" {
+			_, err = at.trader.CloseLong(symbol, reduceQty)
+		} else {
+			_, err = at.trader.CloseShort(symbol, reduceQty)
+		}
+		if err != nil {
+			log.Printf("  ❌ %s %s 减仓失败: %v", symbol, side, err)
+			failed = append(failed, symbol)
+			continue
+		}
+		log.Printf("  ✓ %s %s 减仓 %.4f", symbol, side, reduceQty)
+		if reduceQty == 0 {
+			continue
+		}
+
+		// 按剩余数量恢复止损止盈
+		remaining := quantity - reduceQty
+		positionSide := strings.ToUpper(side)
+		posKey := symbol + "_" + side
+		at.positionIntendedQty[posKey] = remaining
+		if stopLoss := at.positionStopLoss[posKey]; stopLoss > 0 {
+			if err := at.trader.SetStopLoss(symbol, positionSide, remaining, stopLoss); err != nil {
+				log.Printf("  ⚠ %s 恢复止损失败: %v", symbol, err)
+			}
+		} else {
+			log.Printf("  ⚠ %s 止损价未知，减仓后未恢复止损", symbol)
+		}
+		if takeProfit := at.positionTakeProfit[posKey]; takeProfit > 0 {
+			if err := at.trader.SetTakeProfit(symbol, positionSide, remaining, takeProfit); err != nil {
+				log.Printf("  ⚠ %s 恢复止盈失败: %v", symbol, err)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("以下持仓减仓失败: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// executeMoveStopLossWithRecord 按 hold 决策的 new_stop_loss 调整现有持仓的止损并记录详细信息
+// 交易所只能撤销该币种的全部挂单，撤单后按已知止盈价恢复止盈单
+func (at *AutoTrader) executeMoveStopLossWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  🛡 调整止损: %s → %.4f", decision.Symbol, decision.NewStopLoss)
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var side string
+	var quantity float64
+	for _, pos := range positions {
+		if pos["symbol"] != decision.Symbol {
+			continue
+		}
+		side, _ = pos["side"].(string)
+		quantity, _ = pos["positionAmt"].(float64)
+		if quantity < 0 {
+			quantity = -quantity
+		}
+		break
+	}
+	if side == "" || quantity == 0 {
+		return fmt.Errorf("❌ %s 没有持仓，无法调整止损", decision.Symbol)
+	}
+
+	if err := at.trader.CancelAllOrders(decision.Symbol); err != nil {
+		return fmt.Errorf("取消 %s 旧止损止盈单失败: %w", decision.Symbol, err)
+	}
+
+	positionSide := strings.ToUpper(side)
+	posKey := decision.Symbol + "_" + side
+	if err := at.trader.SetStopLoss(decision.Symbol, positionSide, quantity, decision.NewStopLoss); err != nil {
+		return fmt.Errorf("设置新止损失败: %w", err)
+	}
+	at.positionStopLoss[posKey] = decision.NewStopLoss
+	actionRecord.Quantity = quantity
+
+	if takeProfit := at.positionTakeProfit[posKey]; takeProfit > 0 {
+		if err := at.trader.SetTakeProfit(decision.Symbol, positionSide, quantity, takeProfit); err != nil {
+			log.Printf("  ⚠ %s 恢复止盈失败: %v", decision.Symbol, err)
+		}
+	} else {
+		log.Printf("  ⚠ %s 止盈价未知，调整止损后未恢复止盈", decision.Symbol)
 	}
 
+	log.Printf("  ✓ 止损已调整")
 	return nil
 }
 
@@ -943,13 +1179,13 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 	}
 
 	// 定义优先级
-	getActionPriority := func(action string) int {
+	getActionPriority := func(action decision.Action) int {
 		switch action {
-		case "close_long", "close_short":
+		case "close_long", "close_short", "reduce_all":
 			return 1 // 最高优先级：先平仓
-		case "open_long", "open_short":
-			return 2 // 次优先级：后开仓
-		case "hold", "wait":
+		case "open_long", "open_short", "scale_in":
+			return 2 // 次优先级：后开仓/加仓
+		case "hold", "wait", "note", "request_data":
 			return 3 // 最低优先级：观望
 		default:
 			return 999 // 未知动作放最后